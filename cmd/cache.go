@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"renderctl/internal/avtransport"
+	"renderctl/internal/cache"
+	"renderctl/logger"
+)
+
+// runCache handles "renderctl cache <alias|unalias|list|check> ...".
+func runCache(args []string) {
+	logger.SetVerbose(cfg.Verbose)
+
+	if len(args) == 0 {
+		logger.Error("Missing cache command (alias, unalias, list, check)")
+	}
+
+	switch args[0] {
+	case "alias":
+		if len(args) != 3 {
+			logger.Error("Usage: renderctl cache alias <IP|name|index> <alias>")
+		}
+		ip, err := cache.SetAlias(args[1], args[2])
+		if err != nil {
+			logger.Error("%v", err)
+		}
+		logger.Success("%s is now %q (use --device %s)", ip, args[2], args[2])
+
+	case "unalias":
+		if len(args) != 2 {
+			logger.Error("Usage: renderctl cache unalias <alias>")
+		}
+		ip, err := cache.RemoveAlias(args[1])
+		if err != nil {
+			logger.Error("%v", err)
+		}
+		logger.Success("Removed alias %q from %s", args[1], ip)
+
+	case "check":
+		if len(args) != 1 {
+			logger.Error("Usage: renderctl cache check [--refresh] [--cache-ttl 720h]")
+		}
+		if cfg.CacheTTL < 0 {
+			logger.Error("flag --cache-ttl cannot be negative")
+		}
+		if err := avtransport.CheckCache(cfg.CacheRefresh, cfg.CacheTTL); err != nil {
+			logger.Error("%v", err)
+		}
+
+	case "list":
+		list := cfg
+		list.ListCache = true
+		cache.HandleCacheCommands(list)
+
+	default:
+		logger.Error("Unknown cache command %q (alias, unalias, list, check)", args[0])
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// command is the optional leading subcommand (e.g. "inspect", "slideshow").
+var command string
+
+// popCommand strips a leading non-flag argument from os.Args
+// so pflag only ever sees flags.
+func popCommand() {
+	if len(os.Args) < 2 {
+		return
+	}
+	first := os.Args[1]
+	if first == "" || strings.HasPrefix(first, "-") {
+		return
+	}
+
+	command = strings.ToLower(first)
+	os.Args = append(os.Args[:1], os.Args[2:]...)
+}
+
+func handleCommand() {
+	switch command {
+	case "":
+		return
+	case "inspect":
+		runInspect()
+	case "slideshow":
+		// runs through the normal lifecycle (cache, server, shutdown)
+		cfg.Mode = "slideshow"
+		return
+	case "daemon":
+		// media server + control API until Ctrl+C
+		cfg.Mode = "daemon"
+		return
+	case "cache":
+		runCache(pflag.Args())
+	default:
+		printHelp()
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
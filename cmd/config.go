@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"renderctl/internal/config"
+	"renderctl/logger"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	configPath  string
+	profileName string
+)
+
+// loadConfigFile applies the config file (defaults, then --profile) to
+// cfg before the real flags are registered, so flags always win.
+func loadConfigFile() {
+	configPath = argValue("config")
+	profileName = argValue("profile")
+
+	path := configPath
+	if path == "" {
+		p, err := config.Path()
+		if err != nil {
+			return
+		}
+		path = p
+	}
+
+	f, err := config.Load(path)
+	if err != nil {
+		if configPath == "" && errors.Is(err, fs.ErrNotExist) {
+			if profileName != "" {
+				logger.Error("--profile %s needs a config file (%s not found)", profileName, path)
+			}
+			return
+		}
+		logger.Error("Config %s: %v", path, err)
+	}
+
+	values, err := f.Values(profileName)
+	if err != nil {
+		logger.Error("Config %s: %v", path, err)
+	}
+
+	// same flag definitions, parsed from the file into cfg
+	set := pflag.NewFlagSet("config", pflag.ContinueOnError)
+	defineFlags(set)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if set.Lookup(k) == nil {
+			logger.Error("Config %s: unknown setting %q (keys are flag names, e.g. Lip, ssdp-timeout)", path, k)
+		}
+		if err := set.Set(k, values[k]); err != nil {
+			logger.Error("Config %s: %s: %v", path, k, err)
+		}
+	}
+}
+
+// argValue pre-reads --name value / --name=value, before flag parsing.
+func argValue(name string) string {
+	args := os.Args[1:]
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(a, "--"+name+"="); ok {
+			return v
+		}
+		if a == "--"+name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
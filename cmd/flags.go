@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"os"
+	"renderctl/internal/daemon"
 	"renderctl/internal/models"
+	"renderctl/internal/utils"
 	"renderctl/requirements"
+	"strings"
 
 	"github.com/spf13/pflag"
 )
@@ -12,32 +15,64 @@ func parseFlags() {
 	pflag.Usage = func() {
 		printHelp()
 	}
+	popCommand()
+
 	//installation
 	pflag.BoolVar(&requirements.Install, "install", false, "Run installer (build binary and optional dependencies)")
 	pflag.BoolVar(&requirements.DryRun, "dry-run", false, "Show installer actions without executing")
 
+	// config file / profile become the defaults flags override
+	loadConfigFile()
+	models.ResolvedConfig = cfg
+	models.ResolvedConfig.UseCache = !noCache
+
+	defineFlags(pflag.CommandLine)
+	pflag.StringVar(&configPath, "config", configPath, "Config file (default ~/.renderctl/config.toml or config.json)")
+	pflag.StringVar(&profileName, "profile", profileName, "Apply a named profile from the config file")
+
+	// meta
+	version := pflag.BoolP("version", "V", false, "Show version")
+	help := pflag.BoolP("help", "h", false, "Show help")
+
+	pflag.Parse()
+
+	if *help {
+		printHelp()
+		os.Exit(0)
+	}
+	if *version {
+		printVersionAndExit()
+	}
+}
+
+// defineFlags registers every flag that may also come from the config
+// file; the current cfg values are the defaults.
+func defineFlags(fs *pflag.FlagSet) {
 	//tui startup
-	pflag.BoolVar(&cfg.Interactive, "tui", cfg.Interactive, "Start program as TUI")
+	fs.BoolVar(&cfg.Interactive, "tui", cfg.Interactive, "Start program as TUI")
 
-	pflag.BoolVar(&cfg.ProbeOnly, "probe-only", cfg.ProbeOnly, "Probe AVTransport only when using mode: auto")
-	pflag.StringVar(&cfg.Mode, "mode", cfg.Mode, "Execution mode (auto/manual/stream/scan)")
+	fs.BoolVar(&cfg.ProbeOnly, "probe-only", cfg.ProbeOnly, "Probe AVTransport only when using mode: auto")
+	fs.StringVar(&cfg.Mode, "mode", cfg.Mode, "Execution mode (auto/manual/stream/scan)")
 
 	// cache
-	pflag.BoolVar(&cfg.AutoCache, "auto-cache", cfg.AutoCache, "Skip cache save confirmation")
-	pflag.BoolVar(&noCache, "no-cache", false, "Disable cache usage")
-	pflag.BoolVar(&cfg.ListCache, "list-cache", cfg.ListCache, "List cached AVTransport devices")
-	pflag.StringVar(&cfg.ForgetCache, "forget-cache", cfg.ForgetCache, "Forget cache (interactive | IP | all)")
-	pflag.IntVar(&cfg.SelectCache, "select-cache", -1, "Select cached device by index")
-	pflag.IntVar(&cfg.CacheDetails, "details-cache", -1, "List cached device with details")
-	pflag.StringVar(&cfg.ShowMedia, "show-media", cfg.ShowMedia, "Show media details (audio,video,image or comma-separated)")
-	pflag.BoolVar(&cfg.ShowMediaAll, "show-media-all", cfg.ShowMediaAll, "Show all media information from cached devices")
-	pflag.BoolVar(&cfg.Showactions, "show-actions", cfg.Showactions, "Show supported actions from cached devices")
+	fs.BoolVar(&cfg.AutoCache, "auto-cache", cfg.AutoCache, "Skip cache save confirmation")
+	fs.BoolVar(&noCache, "no-cache", noCache, "Disable cache usage")
+	fs.BoolVar(&cfg.ListCache, "list-cache", cfg.ListCache, "List cached AVTransport devices")
+	fs.StringVar(&cfg.ForgetCache, "forget-cache", cfg.ForgetCache, "Forget cache (interactive | IP | alias | all)")
+	fs.IntVar(&cfg.SelectCache, "select-cache", cfg.SelectCache, "Select cached device by index")
+	fs.StringVar(&cfg.Device, "device", cfg.Device, "Select cached device by alias, name, UDN or IP")
+	fs.IntVar(&cfg.CacheDetails, "details-cache", cfg.CacheDetails, "List cached device with details")
+	fs.StringVar(&cfg.ShowMedia, "show-media", cfg.ShowMedia, "Show media details (audio,video,image or comma-separated)")
+	fs.BoolVar(&cfg.ShowMediaAll, "show-media-all", cfg.ShowMediaAll, "Show all media information from cached devices")
+	fs.BoolVar(&cfg.Showactions, "show-actions", cfg.Showactions, "Show supported actions from cached devices")
+	fs.DurationVar(&cfg.CacheTTL, "cache-ttl", cfg.CacheTTL, "Cache check: prune devices not seen for this long (0 = keep all)")
+	fs.BoolVar(&cfg.CacheRefresh, "refresh", cfg.CacheRefresh, "Cache check: re-enrich online devices, search offline ones by UDN")
 
 	// scan
-	pflag.StringVar(&cfg.Subnet, "subnet", cfg.Subnet, "Subnet to scan (e.g. 192.168.1.0/24)")
-	pflag.BoolVar(&cfg.DeepSearch, "deep-search", cfg.DeepSearch, "Use a bigger list when probing for device endpoints")
-	pflag.BoolVar(&cfg.Discover, "ssdp", cfg.Discover, "Enable SSDP discovery")
-	pflag.DurationVar(
+	fs.StringVar(&cfg.Subnet, "subnet", cfg.Subnet, "Subnet to scan (e.g. 192.168.1.0/24)")
+	fs.BoolVar(&cfg.DeepSearch, "deep-search", cfg.DeepSearch, "Use a bigger list when probing for device endpoints")
+	fs.BoolVar(&cfg.Discover, "ssdp", cfg.Discover, "Enable SSDP discovery")
+	fs.DurationVar(
 		&cfg.SSDPTimeout,
 		"ssdp-timeout",
 		cfg.SSDPTimeout,
@@ -45,37 +80,53 @@ func parseFlags() {
 	)
 
 	// tv
-	pflag.StringVar(&cfg.TIP, "Tip", cfg.TIP, "TV IP address")
-	pflag.StringVar(&cfg.TPort, "Tport", cfg.TPort, "TV SOAP port")
-	pflag.StringVar(&cfg.TPath, "Tpath", cfg.TPath, "TV SOAP control path")
-	pflag.StringVar(&cfg.TVVendor, "vendor", cfg.TVVendor, "TV vendor")
+	fs.StringVar(&cfg.TIP, "Tip", cfg.TIP, "TV IP address")
+	fs.StringVar(&cfg.TPort, "Tport", cfg.TPort, "TV SOAP port")
+	fs.StringVar(&cfg.TPath, "Tpath", cfg.TPath, "TV SOAP control path")
+	fs.StringVar(&cfg.TVVendor, "vendor", cfg.TVVendor, "TV vendor")
 
 	// media
-	pflag.StringVar(&cfg.LFile, "Lf", cfg.LFile, "Local media file")
-	pflag.StringVar(&cfg.LIP, "Lip", cfg.LIP, "Local IP for serving media")
-	pflag.StringVar(&cfg.LDir, "Ldir", cfg.LDir, "Local directory to serve")
-	pflag.StringVar(&cfg.ServePort, "LPort", cfg.ServePort, "Local port to serve")
+	fs.StringVar(&cfg.LFile, "Lf", cfg.LFile, "Local media file")
+	fs.StringVar(&cfg.LIP, "Lip", cfg.LIP, "Local IP for serving media")
+	fs.StringVar(&cfg.LDir, "Ldir", cfg.LDir, "Slideshow photo directory")
+	fs.StringVar(&cfg.ServePort, "LPort", cfg.ServePort, "Local port to serve")
+	fs.StringSliceVar(&cfg.Allow, "allow", cfg.Allow, "Extra client IPs/CIDRs allowed to fetch media (renderer is always allowed)")
+
+	// stream
+	fs.DurationVar(
+		&cfg.StreamIdle,
+		"stream-idle",
+		cfg.StreamIdle,
+		"Stop live stream pipeline after no clients for this long (e.g. 30s)",
+	)
+	fs.StringVar(&cfg.Container, "container", cfg.Container, "Stream container (ts | mp4 | mkv | mp3 | ...), needed for -Lf - / fifo:")
+	fs.BoolVar(&cfg.Stats, "stats", cfg.Stats, "Print a client/source summary every 10s (see also /status)")
+	fs.DurationVar(&cfg.MimeTimeout, "mime-timeout", cfg.MimeTimeout, "Try the next mime when the TV has not fetched the stream after this long (0 = no fallback)")
+	fs.BoolVar(&cfg.Pace, "pace", cfg.Pace, "Pace MPEG-TS output at real-time rate using PCR")
+	fs.DurationVar(&cfg.PaceLead, "pace-lead", cfg.PaceLead, "How far paced output may run ahead of real time (e.g. 1s)")
+	fs.StringVar(&cfg.Resolver, "resolver", cfg.Resolver, "Force URL resolver (yt-dlp | streamlink | custom name)")
+	fs.StringVar(&cfg.ResolverFormat, "resolver-format", cfg.ResolverFormat, "Resolver format selector (e.g. best, 720p)")
+	fs.StringVar(&cfg.Quality, "quality", cfg.Quality, "HLS variant (best | worst | 720p | max bandwidth)")
+	fs.StringVar(&cfg.HLSOutput, "hls-output", cfg.HLSOutput, "Serve HLS playlist output (auto | on | off)")
+	fs.BoolVar(&cfg.RadioRefresh, "radio-refresh", cfg.RadioRefresh, "Re-send track metadata when the radio title changes")
+	fs.StringVar(&cfg.Transcode, "transcode", cfg.Transcode, "Transcoding (auto | off | h264-aac-ts | mpeg2-ts)")
+
+	// slideshow
+	fs.DurationVar(&cfg.SlideInterval, "interval", cfg.SlideInterval, "Slideshow: time per photo (e.g. 8s)")
+
+	// daemon
+	fs.StringVar(&cfg.APIAddr, "api", cfg.APIAddr, "Daemon: control API listen address (localhost only)")
+	fs.StringVar(&cfg.Remote, "remote", cfg.Remote, "Forward this command to a running daemon (default "+cfg.APIAddr+")")
+	fs.Lookup("remote").NoOptDefVal = cfg.APIAddr
 
 	// output
-	pflag.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose, "Enables verbose output")
-	pflag.StringVar(&cfg.ReportFileName, "report-file", "", "Report output file name")
-	// meta
-	version := pflag.BoolP("version", "V", false, "Show version")
-	help := pflag.BoolP("help", "h", false, "Show help")
-
-	pflag.Parse()
-
-	if *help {
-		printHelp()
-		os.Exit(0)
-	}
-	if *version {
-		printVersionAndExit()
-	}
+	fs.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose, "Enables verbose output")
+	fs.StringVar(&cfg.ReportFileName, "report-file", cfg.ReportFileName, "Report output file name")
 }
 
 func badFlagUse() (bool, string) {
-	def := models.DefaultConfig
+	// defaults include the config file, only flags are checked
+	def := models.ResolvedConfig
 
 	// scan mode restrictions
 	if cfg.Mode == "scan" && cfg.ProbeOnly {
@@ -90,8 +141,16 @@ func badFlagUse() (bool, string) {
 		return true, "flags --list-cache and --details-cache cannot be used together"
 	}
 
+	// cache check options (the cache command never gets here)
+	if cfg.CacheRefresh != def.CacheRefresh || cfg.CacheTTL != def.CacheTTL {
+		return true, "flags --refresh and --cache-ttl are only valid with the cache check command"
+	}
+
 	// cached target overrides
-	if cfg.SelectCache != def.SelectCache &&
+	if cfg.Device != def.Device && cfg.SelectCache != def.SelectCache {
+		return true, "use either --device or --select-cache, not both"
+	}
+	if (cfg.SelectCache != def.SelectCache || cfg.Device != def.Device) &&
 		(cfg.TIP != def.TIP ||
 			cfg.TPort != def.TPort ||
 			cfg.TPath != def.TPath ||
@@ -99,6 +158,60 @@ func badFlagUse() (bool, string) {
 		return true, "cannot override TV connection parameters when a cached target is selected"
 	}
 
+	// stdin / fifo inputs only make sense as a stream
+	if (cfg.LFile == "-" || strings.HasPrefix(cfg.LFile, "fifo:")) && cfg.Mode != "stream" {
+		return true, "-Lf - and -Lf fifo:<path> require --mode stream"
+	}
+	if cfg.Container != def.Container && cfg.Mode != "stream" {
+		return true, "flag --container is only valid in stream mode"
+	}
+
+	if _, err := utils.ParseAllowList(cfg.Allow); err != nil {
+		return true, err.Error()
+	}
+
+	if cfg.Stats && cfg.Mode != "stream" {
+		return true, "flag --stats is only valid in stream mode"
+	}
+
+	if cfg.MimeTimeout != def.MimeTimeout && cfg.Mode != "stream" {
+		return true, "flag --mime-timeout is only valid in stream mode"
+	}
+	if cfg.MimeTimeout < 0 {
+		return true, "flag --mime-timeout cannot be negative"
+	}
+
+	// pacing
+	if cfg.PaceLead != def.PaceLead && !cfg.Pace {
+		return true, "flag --pace-lead requires --pace"
+	}
+	if cfg.PaceLead < 0 {
+		return true, "flag --pace-lead cannot be negative"
+	}
+
+	// slideshow takes a directory, not a file
+	if cfg.Mode == "slideshow" && cfg.LFile != def.LFile {
+		return true, "slideshow uses --Ldir, not --Lf"
+	}
+	if cfg.Mode != "slideshow" && cfg.SlideInterval != def.SlideInterval {
+		return true, "flag --interval is only valid with the slideshow command"
+	}
+	if cfg.SlideInterval <= 0 {
+		return true, "flag --interval must be positive"
+	}
+
+	// daemon: one media server, devices picked per request
+	if cfg.Mode == "daemon" && (cfg.LFile != def.LFile || cfg.SelectCache != def.SelectCache || cfg.Device != def.Device) {
+		return true, "daemon takes media and devices per request (no --Lf / --select-cache / --device)"
+	}
+	if cfg.APIAddr != def.APIAddr && cfg.Mode != "daemon" {
+		return true, "flag --api is only valid with the daemon command"
+	}
+	// safety rules look at the final value, config file included
+	if cfg.Mode == "daemon" && !daemon.IsLoopback(cfg.APIAddr) {
+		return true, "flag --api must be a localhost address (e.g. 127.0.0.1:8765)"
+	}
+
 	// SSDP flag dependency
 	if !cfg.Discover && cfg.SSDPTimeout != def.SSDPTimeout {
 		return true, "flag --ssdp-timeout requires SSDP discovery to be enabled (--ssdp)"
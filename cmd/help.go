@@ -41,6 +41,11 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Println("  renderctl [flags]")
+	fmt.Println("  renderctl inspect -Lf <file|url> [--device NAME | --select-cache N | --Tip IP]")
+	fmt.Println("  renderctl slideshow --Ldir <dir> [--interval 8s] [--device NAME | --select-cache N | --Tip IP]")
+	fmt.Println("  renderctl daemon [--api 127.0.0.1:8765] [--ssdp]")
+	fmt.Println("  renderctl cache <alias <IP|name|index> <alias> | unalias <alias> | list | check [--refresh] [--cache-ttl 720h]>")
+	fmt.Println("  renderctl --remote[=addr] <devices|discover|play|pause|resume|stop|seek POS|volume [N]|queue [add|next|clear]|status> [--Lf <file|url>] [--device NAME | --select-cache N | --Tip IP]")
 	fmt.Println()
 
 	// ─── Execution ───────────────────────────────────────────
@@ -58,12 +63,15 @@ func printHelp() {
 		{"--auto-cache", "", "Skip cache save confirmation"},
 		{"--no-cache", "", "Disable cache usage"},
 		{"--list-cache", "", "List cached devices"},
-		{"--forget-cache", "string", "Forget cache (interactive | IP | all)"},
+		{"--forget-cache", "string", "Forget cache (interactive | IP | alias | all)"},
 		{"--select-cache", "int", "Select cached device by index"},
+		{"--device", "string", "Select cached device by alias, name, UDN or IP (fuzzy)"},
 		{"--details-cache", "int", "List cached device with details"},
 		{"--show-actions", "", "Show supported actions from cached devices"},
 		{"--show-media", "", "Show media information from cached devices"},
 		{"--show-media-all", "", "Show all media information from cached devices"},
+		{"--refresh", "", "Cache check: re-enrich online devices, search offline ones by UDN"},
+		{"--cache-ttl", "duration", "Cache check: prune devices not seen for this long (default 720h, 0 = keep)"},
 	})
 	fmt.Println()
 
@@ -92,8 +100,50 @@ func printHelp() {
 	printFlags([]helpFlag{
 		{"--Lf", "string", "Local media file or url (url is stream explicit)"},
 		{"--Lip", "string", "Local IP"},
-		{"--Ldir", "string", "Local directory"},
+		{"--Ldir", "string", "Slideshow photo directory"},
 		{"--LPort", "string", "Local port"},
+		{"--allow", "list", "Extra client IPs/CIDRs allowed to fetch media (e.g. 10.0.0.5,10.0.1.0/24)"},
+	})
+	fmt.Println()
+
+	// ─── Stream ──────────────────────────────────────────────
+	fmt.Println("Stream:")
+	printFlags([]helpFlag{
+		{"--stream-idle", "duration", "Stop live pipeline after no clients for this long"},
+		{"--container", "string", "Stream container (ts | mp4 | mkv | mp3 | ...), for -Lf - / fifo:"},
+		{"--stats", "", "Print a client/source summary every 10s (see also /status)"},
+		{"--mime-timeout", "duration", "Try the next mime if the TV has not fetched the stream (default 15s, 0 = off)"},
+		{"--pace", "", "Pace MPEG-TS output at real-time rate using PCR"},
+		{"--pace-lead", "duration", "How far paced output may run ahead of real time (default 1s)"},
+		{"--resolver", "string", "Force URL resolver (yt-dlp | streamlink | custom name)"},
+		{"--resolver-format", "string", "Resolver format selector (e.g. best, 720p)"},
+		{"--quality", "string", "HLS variant (best | worst | 720p | max bandwidth)"},
+		{"--hls-output", "string", "Serve HLS playlist output (auto | on | off)"},
+		{"--transcode", "string", "Transcoding (auto | off | h264-aac-ts | mpeg2-ts)"},
+		{"--radio-refresh", "", "Re-send track metadata when the radio title changes"},
+	})
+	fmt.Println()
+
+	// ─── Slideshow ───────────────────────────────────────────
+	fmt.Println("Slideshow:")
+	printFlags([]helpFlag{
+		{"--interval", "duration", "Time each photo stays on screen (default 8s)"},
+	})
+	fmt.Println()
+
+	// ─── Daemon ──────────────────────────────────────────────
+	fmt.Println("Daemon:")
+	printFlags([]helpFlag{
+		{"--api", "string", "Control API listen address, localhost only (default 127.0.0.1:8765)"},
+		{"--remote", "[addr]", "Forward the command to a running daemon"},
+	})
+	fmt.Println()
+
+	// ─── Config ──────────────────────────────────────────────
+	fmt.Println("Config:")
+	printFlags([]helpFlag{
+		{"--config", "path", "Config file (default ~/.renderctl/config.toml or config.json)"},
+		{"--profile", "string", "Apply a named profile from the config file"},
 	})
 	fmt.Println()
 
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"renderctl/internal/cache"
+	"renderctl/internal/transcode"
+	"renderctl/logger"
+)
+
+func runInspect() {
+	logger.SetVerbose(cfg.Verbose)
+
+	if cfg.LFile == "" {
+		logger.Error("Missing -Lf (media file or URL to inspect)")
+	}
+
+	info, err := transcode.Inspect(cfg.LFile)
+	if err != nil {
+		logger.Error("Inspection failed: %v", err)
+	}
+
+	key := resolveDevice()
+
+	var (
+		media map[string][]string
+		label string
+	)
+	switch {
+	case key != "":
+		ip, dev, ok := cache.Select(key)
+		if !ok {
+			logger.Error("Cached device %s has no playable endpoint", key)
+		}
+		media, label = dev.Media, ip
+	case cfg.TIP != "":
+		dev, ok := cache.Lookup(cfg.TIP)
+		if !ok {
+			logger.Notify("TV %s not in cache — no renderer verdict", cfg.TIP)
+		}
+		media, label = dev.Media, cfg.TIP
+	}
+
+	transcode.PrintReport(info, media, label)
+}
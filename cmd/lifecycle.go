@@ -3,6 +3,8 @@ package cmd
 import (
 	"os"
 	"os/signal"
+	"renderctl/internal"
+	"renderctl/internal/daemon"
 	"renderctl/internal/servers"
 	"renderctl/internal/stream"
 	"renderctl/internal/utils"
@@ -16,11 +18,26 @@ func preRun() (chan struct{}, bool) {
 
 	// ---- PRE-RUN LOGIC ----
 	mode := utils.NormalizeMode(cfg.Mode)
+	if mode == "daemon" {
+		if err := daemon.Run(&cfg, stop); err != nil {
+			logger.Error("Daemon failed to start: %v", err)
+		}
+		return stop, true
+	}
+	if mode == "slideshow" {
+		if _, err := os.Stat(cfg.LDir); err != nil {
+			logger.Error("Invalid --Ldir: %v", err)
+		}
+		servers.InitDefaultServer(cfg, stop)
+		time.Sleep(500 * time.Millisecond)
+		return stop, true
+	}
+
 	if mode != "scan" && !cfg.ProbeOnly {
 		inspectfile(mode)
 
 		if mode != "stream" {
-			servers.InitDefaultServer(cfg, stop)
+			internal.ServeMedia(cfg, stop)
 		} else {
 			stream.InitStreamServer(&cfg, stop)
 		}
@@ -52,4 +69,5 @@ func waitForShutdown(stop chan struct{}) {
 	<-sig
 
 	close(stop)
+	stream.FlushLearned()
 }
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"renderctl/internal/cache"
+	"renderctl/internal/daemon"
+	"renderctl/logger"
+
+	"github.com/spf13/pflag"
+)
+
+// handleRemote forwards the command to a running daemon (--remote) and exits.
+//
+//	renderctl --remote [devices|discover|play|pause|resume|stop|seek POS|volume [N]|queue [add|next|clear]|status]
+func handleRemote() {
+	if cfg.Remote == "" {
+		return
+	}
+	logger.SetVerbose(cfg.Verbose)
+
+	args := pflag.Args()
+	if command != "" {
+		args = append([]string{command}, args...)
+	}
+
+	if err := runRemote(daemon.NewClient(cfg.Remote), args); err != nil {
+		logger.Error("%v", err)
+	}
+	os.Exit(0)
+}
+
+func runRemote(c *daemon.Client, args []string) error {
+	req := daemon.Request{Device: remoteDevice()}
+	if cfg.LFile != "" {
+		req.Source = remoteSource(cfg.LFile)
+	}
+
+	action := "status"
+	if len(args) > 0 {
+		action, args = strings.ToLower(args[0]), args[1:]
+	} else if req.Source != "" {
+		action = "play"
+	}
+
+	var out json.RawMessage
+	var err error
+
+	switch action {
+	case "devices":
+		err = c.Do(http.MethodGet, "/devices", req, &out)
+	case "discover":
+		err = c.Do(http.MethodPost, "/devices/discover", req, &out)
+	case "status":
+		err = c.Do(http.MethodGet, "/status", req, &out)
+
+	case "play":
+		if req.Source == "" {
+			return errors.New("remote play needs --Lf <file|url>")
+		}
+		err = c.Do(http.MethodPost, "/play", req, &out)
+	case "pause", "resume", "stop":
+		err = c.Do(http.MethodPost, "/"+action, req, &out)
+	case "seek":
+		if len(args) == 0 {
+			return errors.New("remote seek needs a position (90s, 1m30s, 0:01:30)")
+		}
+		req.Position = args[0]
+		err = c.Do(http.MethodPost, "/seek", req, &out)
+
+	case "volume":
+		if len(args) == 0 {
+			err = c.Do(http.MethodGet, "/volume", req, &out)
+			break
+		}
+		level, convErr := strconv.Atoi(args[0])
+		if convErr != nil {
+			return fmt.Errorf("invalid volume %q (0-100)", args[0])
+		}
+		req.Level = &level
+		err = c.Do(http.MethodPost, "/volume", req, &out)
+
+	case "queue":
+		sub := "list"
+		if len(args) > 0 {
+			sub = strings.ToLower(args[0])
+		}
+		switch sub {
+		case "list":
+			err = c.Do(http.MethodGet, "/queue", req, &out)
+		case "add":
+			if req.Source == "" {
+				return errors.New("remote queue add needs --Lf <file|url>")
+			}
+			err = c.Do(http.MethodPost, "/queue", req, &out)
+		case "next":
+			err = c.Do(http.MethodPost, "/queue/next", req, &out)
+		case "clear":
+			err = c.Do(http.MethodDelete, "/queue", req, &out)
+		default:
+			return fmt.Errorf("unknown queue command %q (list | add | next | clear)", sub)
+		}
+
+	default:
+		return fmt.Errorf("unknown remote command %q", action)
+	}
+
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// remoteDevice maps --device / --select-cache / --Tip onto the API's
+// device field; the daemon resolves names itself. An index is pinned
+// to the device's UDN or IP here, as the daemon's list may have moved.
+func remoteDevice() string {
+	if cfg.Device != "" {
+		return cfg.Device
+	}
+	if cfg.SelectCache >= 0 {
+		key, err := cache.Resolve(strconv.Itoa(cfg.SelectCache))
+		if err != nil {
+			logger.Error("--select-cache: %v", err)
+		}
+		ip, dev, ok := cache.Select(key)
+		if !ok {
+			logger.Error("Cached device %s has no playable endpoint", key)
+		}
+		if dev.UDN != "" {
+			return dev.UDN
+		}
+		return ip
+	}
+	return cfg.TIP
+}
+
+// remoteSource makes local paths absolute: the daemon has its own cwd.
+func remoteSource(src string) string {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		return src
+	}
+	if abs, err := filepath.Abs(src); err == nil {
+		return abs
+	}
+	return src
+}
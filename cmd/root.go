@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"strconv"
 
 	"renderctl/internal"
 	"renderctl/internal/cache"
@@ -18,6 +19,8 @@ var noCache bool
 func Execute() {
 	parseFlags()
 	handleInstaller()
+	handleRemote()
+	handleCommand()
 	handleFlagsAndLogging()
 	handleInteraction()
 
@@ -58,14 +61,41 @@ func handleFlagsAndLogging() {
 	// FLAG INVERSION
 	cfg.UseCache = !noCache
 
+	// media on stdin: prompts must not consume it
+	utils.StdinIsMedia = cfg.LFile == "-"
+
 	// Cache commands exit early
 	if cache.HandleCacheCommands(cfg) {
 		os.Exit(0)
 	}
 
-	if cfg.SelectCache >= 0 {
-		cache.LoadCachedTV(&cfg)
+	if key := resolveDevice(); key != "" {
+		cache.LoadCachedTV(&cfg, key)
+	}
+}
+
+// resolveDevice turns --select-cache or --device into a cache key (""
+// when no cached target is picked). An explicit --select-cache or --Tip
+// on the command line beats a profile device.
+func resolveDevice() string {
+	def := models.ResolvedConfig
+	if cfg.Mode == "daemon" {
+		return ""
+	}
+
+	flag, ref := "--select-cache", strconv.Itoa(cfg.SelectCache)
+	if cfg.SelectCache < 0 {
+		if cfg.Device == "" || (cfg.Device == def.Device && cfg.TIP != def.TIP) {
+			return ""
+		}
+		flag, ref = "--device", cfg.Device
+	}
+
+	key, err := cache.Resolve(ref)
+	if err != nil {
+		logger.Error("%s: %v", flag, err)
 	}
+	return key
 }
 
 func handleInteraction() {
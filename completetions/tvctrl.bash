@@ -2,9 +2,9 @@ _renderctl() {
   local cur
   cur="${COMP_WORDS[COMP_CWORD]}"
 
-  opts="--probe-only --mode --auto-cache --no-cache --list-cache \
-        --forget-cache --select-cache --subnet --deep-search --ssdp \
-        --Tip --Tport --Tpath --type --Lf --Lip --Ldir --LPort --version"
+  opts="inspect slideshow daemon cache --probe-only --mode --auto-cache --no-cache --list-cache \
+        --forget-cache --select-cache --device --refresh --cache-ttl --subnet --deep-search --ssdp \
+        --Tip --Tport --Tpath --type --Lf --Lip --Ldir --LPort --allow --stream-idle --container --stats --mime-timeout --pace --pace-lead --transcode --resolver --resolver-format --quality --hls-output --radio-refresh --interval --api --remote --config --profile --version"
 
   COMPREPLY=( $(compgen -W "$opts" -- "$cur") )
 }
@@ -10,12 +10,55 @@ import (
 type Target struct {
 	ControlURL string
 	MediaURL   string
+
+	// Optional media details for DIDL-Lite metadata
+	Mime       string
+	Title      string
+	Duration   time.Duration
+	Resolution string // "WxH"
+
+	// Audio tracks
+	Class       string // upnp:class override (default musicTrack)
+	Artist      string
+	Album       string
+	AlbumArtURL string
 }
 
 func Run(t Target, meta string) {
 	controlURL := t.ControlURL
 
-	setAVTransportBody := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+	// 0) STOP (Samsung quirk)
+	_ = soapRequest(
+		controlURL,
+		stopBody,
+		`"urn:schemas-upnp-org:service:AVTransport:1#Stop"`,
+	)
+
+	time.Sleep(150 * time.Millisecond)
+	// 1) Set media URI
+	err := soapRequest(
+		controlURL,
+		setURIBody(t, meta),
+		`"urn:schemas-upnp-org:service:AVTransport:1#SetAVTransportURI"`,
+	)
+	if err != nil {
+		logger.Error("%v", err)
+		return
+	}
+
+	// 2) Play
+	err = soapRequest(
+		controlURL,
+		playBody,
+		`"urn:schemas-upnp-org:service:AVTransport:1#Play"`,
+	)
+	if err != nil {
+		logger.Error("%v", err)
+	}
+}
+
+func setURIBody(t Target, meta string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
 	<SOAP-ENV:Envelope 
 	    xmlns:SOAP-ENV="http://schemas.xmlsoap.org/soap/envelope/" 
 	    SOAP-ENV:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
@@ -27,8 +70,9 @@ func Run(t Target, meta string) {
 	    </u:SetAVTransportURI>
 	  </SOAP-ENV:Body>
 	</SOAP-ENV:Envelope>`, t.MediaURL, html.EscapeString(meta))
+}
 
-	playBody := `<?xml version="1.0" encoding="utf-8"?>
+const playBody = `<?xml version="1.0" encoding="utf-8"?>
 	<SOAP-ENV:Envelope 
 	    xmlns:SOAP-ENV="http://schemas.xmlsoap.org/soap/envelope/" 
 	    SOAP-ENV:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
@@ -40,7 +84,7 @@ func Run(t Target, meta string) {
 	  </SOAP-ENV:Body>
 	</SOAP-ENV:Envelope>`
 
-	stopBody := `<?xml version="1.0" encoding="utf-8"?>
+const stopBody = `<?xml version="1.0" encoding="utf-8"?>
 	<SOAP-ENV:Envelope 
 	  xmlns:SOAP-ENV="http://schemas.xmlsoap.org/soap/envelope/" 
 	  SOAP-ENV:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
@@ -50,33 +94,3 @@ func Run(t Target, meta string) {
 	</u:Stop>
 	</SOAP-ENV:Body>
 	</SOAP-ENV:Envelope>`
-
-	// 0) STOP (Samsung quirk)
-	_ = soapRequest(
-		controlURL,
-		stopBody,
-		`"urn:schemas-upnp-org:service:AVTransport:1#Stop"`,
-	)
-
-	time.Sleep(150 * time.Millisecond)
-	// 1) Set media URI
-	err := soapRequest(
-		controlURL,
-		setAVTransportBody,
-		`"urn:schemas-upnp-org:service:AVTransport:1#SetAVTransportURI"`,
-	)
-	if err != nil {
-		logger.Error("%v", err)
-		return
-	}
-
-	// 2) Play
-	err = soapRequest(
-		controlURL,
-		playBody,
-		`"urn:schemas-upnp-org:service:AVTransport:1#Play"`,
-	)
-	if err != nil {
-		logger.Error("%v", err)
-	}
-}
@@ -5,7 +5,7 @@ import (
 	"renderctl/internal/models"
 	"renderctl/internal/utils"
 	"renderctl/logger"
-	"sort"
+	"time"
 )
 
 func TryCache(cfg *models.Config) bool {
@@ -14,30 +14,38 @@ func TryCache(cfg *models.Config) bool {
 	}
 
 	store, _ := cache.Load()
-	cd, ok := store[cfg.TIP]
-	if !ok || len(cd.Endpoints) == 0 {
+	key, cd, ok := store.ByIP(cfg.TIP)
+	if !ok {
 		return false
 	}
-
-	// pick primary endpoint deterministically
-	var urls []string
-	for u, ep := range cd.Endpoints {
-		if len(ep.Actions) > 0 {
-			urls = append(urls, u)
-		}
-	}
-	sort.Strings(urls)
-
-	if len(urls) == 0 {
+	ep := cd.Primary()
+	if ep == nil {
 		return false
 	}
 
-	ep := cd.Endpoints[urls[0]]
+	// a dead endpoint is no shortcut: look for the device at a new
+	// address (DHCP), else fall through to SSDP / probing. From here on
+	// the key names it: another device may take over the old IP.
+	if !probeSOAPEndpoint(ep.ControlURL, "") {
+		if _, ok := cache.RelocateKey(key); !ok {
+			logger.Notify("Cached endpoint %s is not responding, skipping cache", ep.ControlURL)
+			return false
+		}
+		store, _ = cache.Load()
+		if cd, ok = store[key]; !ok {
+			return false
+		}
+		if ep = cd.Primary(); ep == nil {
+			return false
+		}
+		cfg.TIP = cd.IP
+	}
 
 	logger.Notify("\nCached device found:")
 	logger.Status(" IP        : %s", cfg.TIP)
 	logger.Status(" Vendor    : %s", cd.Vendor)
 	logger.Status(" ControlURL: %s", ep.ControlURL)
+	logger.Status(" Last seen : %s ago", cache.FormatAge(cd.Age(time.Now())))
 
 	if !utils.Confirm("Use cached AVTransport endpoint?") {
 		return false
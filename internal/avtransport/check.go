@@ -0,0 +1,211 @@
+package avtransport
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"renderctl/internal/cache"
+	"renderctl/internal/identity"
+	"renderctl/logger"
+)
+
+// checkWorkers bounds how many devices are probed at once.
+const checkWorkers = 8
+
+// checked is what probing one cached device found. Nothing is written to
+// the store until every probe is back.
+type checked struct {
+	key   string
+	alive map[string]bool // ControlURL -> answered
+	caps  map[string]*Capabilities
+	info  *identity.Info
+}
+
+// CheckCache re-validates every cached device concurrently, records it
+// online or offline, and prunes devices not seen for longer than ttl
+// (0 keeps them). With refresh, online devices are re-enriched and
+// offline ones searched for by UDN in case their address changed.
+func CheckCache(refresh bool, ttl time.Duration) error {
+	store, err := cache.Load()
+	if err != nil {
+		return err
+	}
+	if len(store) == 0 {
+		logger.Status("Cache is empty.")
+		return nil
+	}
+
+	keys := make([]string, 0, len(store))
+	for k := range store {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return store[keys[i]].IP < store[keys[j]].IP })
+
+	logger.Notify("Checking %d cached device(s)", len(keys))
+
+	results := make([]checked, len(keys))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(checkWorkers, len(keys)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = checkDevice(keys[i], store[keys[i]], refresh)
+			}
+		}()
+	}
+	for i := range keys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// ---- RECORD ----
+	now := time.Now()
+	var online, offline int
+	var lost []string
+	// probes took a while: record into a fresh read under the lock
+	err = cache.Update(func(fresh cache.Store) bool {
+		for _, r := range results {
+			cd, ok := fresh[r.key]
+			if !ok {
+				continue // forgotten meanwhile
+			}
+			up := false
+			for _, ok := range r.alive {
+				up = up || ok
+			}
+
+			if r.info != nil {
+				udn := cd.UDN()
+				if udn != "" && r.info.UDN != "" && !strings.EqualFold(udn, r.info.UDN) {
+					// the address now belongs to another renderer
+					logger.Notify("%s now answers as %s, not %s", cd.IP, r.info.UDN, udn)
+					up = false
+				} else {
+					cd.Identity = identityFields(r.info)
+					if r.info.UDN == "" && udn != "" {
+						cd.Identity["udn"] = udn
+					}
+				}
+			}
+
+			for u, ok := range r.alive {
+				if !ok || !up {
+					continue
+				}
+				ep, ok := cd.Endpoints[u]
+				if !ok {
+					continue
+				}
+				ep.SeenAt = now
+				if c := r.caps[u]; c != nil {
+					if ep.Actions == nil {
+						ep.Actions = map[string]bool{}
+					}
+					for a, v := range c.Actions {
+						ep.Actions[a] = v
+					}
+					if len(c.Media) > 0 {
+						ep.Media = c.Media
+					}
+				}
+			}
+			cd.Mark(up, now)
+
+			name, _ := cd.Identity["friendly_name"].(string)
+			if up {
+				online++
+				logger.Success("online   %-15s %s", cd.IP, name)
+				continue
+			}
+			offline++
+			last := "never verified"
+			if !cd.VerifiedAt.IsZero() {
+				last = "last online " + cache.FormatAge(cd.Age(now)) + " ago"
+			}
+			logger.Notify("offline  %-15s %s (%s)", cd.IP, name, last)
+			if cd.UDN() != "" {
+				lost = append(lost, r.key)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// DHCP may have moved them; RelocateKey saves on its own. By key:
+	// another device may hold the old address by now.
+	if refresh {
+		for _, key := range lost {
+			if _, moved := cache.RelocateKey(key); moved {
+				online++
+				offline--
+			}
+		}
+	}
+
+	// ---- EXPIRE ----
+	pruned := 0
+	if ttl > 0 {
+		var gone []string
+		err := cache.Update(func(s cache.Store) bool {
+			gone = s.Prune(ttl, time.Now())
+			return len(gone) > 0
+		})
+		if err != nil {
+			return err
+		}
+		for _, g := range gone {
+			logger.Notify("Pruned %s", g)
+		}
+		pruned = len(gone)
+	}
+
+	logger.Done("Cache check: %d online, %d offline, %d pruned", online, offline, pruned)
+	return nil
+}
+
+// checkDevice probes every endpoint of cd and, with refresh, re-reads
+// capabilities and identity from the ones that answer. Read-only on cd.
+func checkDevice(key string, cd *cache.CachedDevice, refresh bool) checked {
+	r := checked{
+		key:   key,
+		alive: map[string]bool{},
+		caps:  map[string]*Capabilities{},
+	}
+
+	var base string
+	for u, ep := range cd.Endpoints {
+		ok := probeSOAPEndpoint(ep.ControlURL, "")
+		r.alive[u] = ok
+		logger.Info("Check %s: %v", ep.ControlURL, ok)
+		if !ok || !refresh {
+			continue
+		}
+
+		c := &Capabilities{Actions: ValidateActions(Target{ControlURL: ep.ControlURL})}
+		if ep.ConnMgrURL != "" {
+			if media, err := FetchMediaProtocols(ep.ConnMgrURL); err == nil {
+				c.Media = media
+			}
+		}
+		r.caps[u] = c
+
+		if p, err := url.Parse(ep.ControlURL); err == nil && base == "" {
+			base = p.Scheme + "://" + p.Host
+		}
+	}
+
+	if base != "" {
+		if info, err := identity.Enrich(base, 3*time.Second); err == nil {
+			r.info = info
+		}
+	}
+	return r
+}
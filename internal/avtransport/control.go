@@ -0,0 +1,228 @@
+package avtransport
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"renderctl/logger"
+)
+
+// ---- TRANSPORT CONTROL (non-fatal, for long-running callers) ----
+
+const (
+	avtService = "urn:schemas-upnp-org:service:AVTransport:1"
+	rcService  = "urn:schemas-upnp-org:service:RenderingControl:1"
+)
+
+func actionBody(service, action, args string) string {
+	return `<?xml version="1.0" encoding="utf-8"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"
+            s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+  <s:Body>
+    <u:` + action + ` xmlns:u="` + service + `">
+      <InstanceID>0</InstanceID>` + args + `
+    </u:` + action + `>
+  </s:Body>
+</s:Envelope>`
+}
+
+// Log routes the SOAP traces of the control calls. The package functions
+// log through the CLI logger; a library caller supplies its own, and a
+// nil Info drops them.
+type Log struct {
+	Info func(format string, a ...any)
+}
+
+// CLI is the Log used by the package-level functions.
+var CLI = Log{Info: logger.Info}
+
+func (l Log) info(format string, a ...any) {
+	if l.Info != nil {
+		l.Info(format, a...)
+	}
+}
+
+// Cast is Run for callers that must not exit: Stop, SetAVTransportURI,
+// Play, with SOAP faults returned as *UPnPError.
+func Cast(t Target, meta string) error {
+	return CLI.Cast(t, meta)
+}
+
+func (l Log) Cast(t Target, meta string) error {
+	_, _ = l.soapCall(t.ControlURL, stopBody, "Stop")
+	time.Sleep(150 * time.Millisecond)
+
+	if _, err := l.soapCall(t.ControlURL, setURIBody(t, meta), "SetAVTransportURI"); err != nil {
+		return err
+	}
+	_, err := l.soapCall(t.ControlURL, playBody, "Play")
+	return err
+}
+
+func Play(controlURL string) error {
+	return CLI.Play(controlURL)
+}
+
+func (l Log) Play(controlURL string) error {
+	_, err := l.soapCall(controlURL, playBody, "Play")
+	return err
+}
+
+func Pause(controlURL string) error {
+	return CLI.Pause(controlURL)
+}
+
+func (l Log) Pause(controlURL string) error {
+	_, err := l.soapCall(controlURL, actionBody(avtService, "Pause", ""), "Pause")
+	return err
+}
+
+func Stop(controlURL string) error {
+	return CLI.Stop(controlURL)
+}
+
+func (l Log) Stop(controlURL string) error {
+	_, err := l.soapCall(controlURL, stopBody, "Stop")
+	return err
+}
+
+// Seek jumps to an absolute position in the current track.
+func Seek(controlURL string, pos time.Duration) error {
+	return CLI.Seek(controlURL, pos)
+}
+
+func (l Log) Seek(controlURL string, pos time.Duration) error {
+	args := `
+      <Unit>REL_TIME</Unit>
+      <Target>` + hms(pos) + `</Target>`
+	_, err := l.soapCall(controlURL, actionBody(avtService, "Seek", args), "Seek")
+	return err
+}
+
+// Position is the GetPositionInfo answer.
+type Position struct {
+	Track    string        `json:"track_uri,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Elapsed  time.Duration `json:"elapsed_ns"`
+}
+
+func PositionInfo(controlURL string) (Position, error) {
+	return CLI.PositionInfo(controlURL)
+}
+
+func (l Log) PositionInfo(controlURL string) (Position, error) {
+	body, err := l.soapCall(controlURL, actionBody(avtService, "GetPositionInfo", ""), "GetPositionInfo")
+	if err != nil {
+		return Position{}, err
+	}
+
+	var env struct {
+		Duration string `xml:"Body>GetPositionInfoResponse>TrackDuration"`
+		RelTime  string `xml:"Body>GetPositionInfoResponse>RelTime"`
+		URI      string `xml:"Body>GetPositionInfoResponse>TrackURI"`
+	}
+	if err := xml.Unmarshal(body, &env); err != nil {
+		return Position{}, err
+	}
+	return Position{
+		Track:    strings.TrimSpace(env.URI),
+		Duration: parseHMS(env.Duration),
+		Elapsed:  parseHMS(env.RelTime),
+	}, nil
+}
+
+// RenderingControlURL guesses the RenderingControl control URL from the
+// AVTransport one; renderers name both alike (/upnp/control/AVTransport1
+// -> /upnp/control/RenderingControl1).
+func RenderingControlURL(controlURL string) (string, error) {
+	if !strings.Contains(controlURL, "AVTransport") {
+		return "", errors.New("cannot derive RenderingControl from " + controlURL)
+	}
+	return strings.Replace(controlURL, "AVTransport", "RenderingControl", 1), nil
+}
+
+func Volume(controlURL string) (int, error) {
+	return CLI.Volume(controlURL)
+}
+
+func (l Log) Volume(controlURL string) (int, error) {
+	rc, err := RenderingControlURL(controlURL)
+	if err != nil {
+		return 0, err
+	}
+	args := `
+      <Channel>Master</Channel>`
+	body, err := l.serviceCall(rc, rcService, actionBody(rcService, "GetVolume", args), "GetVolume")
+	if err != nil {
+		return 0, err
+	}
+
+	var env struct {
+		Volume string `xml:"Body>GetVolumeResponse>CurrentVolume"`
+	}
+	if err := xml.Unmarshal(body, &env); err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(env.Volume))
+}
+
+func SetVolume(controlURL string, level int) error {
+	return CLI.SetVolume(controlURL, level)
+}
+
+func (l Log) SetVolume(controlURL string, level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("volume %d out of range (0-100)", level)
+	}
+	rc, err := RenderingControlURL(controlURL)
+	if err != nil {
+		return err
+	}
+	args := `
+      <Channel>Master</Channel>
+      <DesiredVolume>` + strconv.Itoa(level) + `</DesiredVolume>`
+	_, err = l.serviceCall(rc, rcService, actionBody(rcService, "SetVolume", args), "SetVolume")
+	return err
+}
+
+// hms formats H:MM:SS for Seek targets.
+func hms(d time.Duration) string {
+	s := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// parseHMS reads H+:MM:SS[.F] (NOT_IMPLEMENTED and garbage give 0).
+func parseHMS(v string) time.Duration {
+	d, _ := hmsValue(v)
+	return d
+}
+
+func hmsValue(v string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(v), ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	sec, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, false
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(sec*float64(time.Second)), true
+}
+
+// ParsePosition accepts "90s", "1m30s" or "0:01:30".
+func ParsePosition(v string) (time.Duration, error) {
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return d, nil
+	}
+	if d, ok := hmsValue(v); ok {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid position %q (use 90s, 1m30s or 0:01:30)", v)
+}
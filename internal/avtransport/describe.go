@@ -0,0 +1,63 @@
+package avtransport
+
+import (
+	"path/filepath"
+	"strings"
+
+	"renderctl/internal/mediainfo"
+)
+
+// DescribeFile fills DIDL details from the file headers and tags (best-effort).
+// media is the renderer sink list used to pick the mime spelling (may be nil).
+// The returned tags carry any embedded cover art.
+func DescribeFile(t *Target, path string, media map[string][]string) *mediainfo.Tags {
+	return CLI.DescribeFile(t, path, media)
+}
+
+func (l Log) DescribeFile(t *Target, path string, media map[string][]string) *mediainfo.Tags {
+	t.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	mi, err := mediainfo.ParseFile(path)
+	if err != nil {
+		l.info("Media headers not parsed: %v", err)
+		return nil
+	}
+
+	t.Mime = PickMime(mi.MimeCandidates(), media)
+	t.Duration = mi.Duration
+	t.Resolution = mi.Resolution()
+
+	if !mi.IsAudio() {
+		return nil
+	}
+
+	tags, err := mediainfo.ReadTags(path)
+	if err != nil {
+		l.info("Audio tags not read: %v", err)
+		return nil
+	}
+	if tags.Title != "" {
+		t.Title = tags.Title
+	}
+	t.Artist = tags.Artist
+	t.Album = tags.Album
+
+	l.info("Track: %q by %q (%s)", t.Title, t.Artist, t.Album)
+	return tags
+}
+
+// PickMime returns the first candidate the renderer lists in its sink
+// protocol info, or the first candidate when nothing matches.
+func PickMime(candidates []string, media map[string][]string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	for _, c := range candidates {
+		for m := range media {
+			if strings.EqualFold(m, c) {
+				return m
+			}
+		}
+	}
+	return candidates[0]
+}
@@ -1,8 +1,24 @@
 package avtransport
 
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
 // MetadataForVendor returns CurrentURIMetaData for a given vendor.
 // Empty string means "no metadata".
 func MetadataForVendor(vendor string, t Target) string {
+	// Audio renderers need DIDL to show track info, whatever the vendor
+	if strings.HasPrefix(t.Mime, "audio/") {
+		return musicTrackMetadata(t)
+	}
+	// Photos likewise: without a class TVs try to play them as video
+	if strings.HasPrefix(t.Mime, "image/") {
+		return photoMetadata(t)
+	}
+
 	switch vendor {
 	case "samsung":
 		return ""
@@ -19,6 +35,15 @@ func MetadataForVendor(vendor string, t Target) string {
 }
 
 func lgMetadata(t Target) string {
+	mime := t.Mime
+	if mime == "" {
+		mime = "video/mp4"
+	}
+	title := t.Title
+	if title == "" {
+		title = "Video"
+	}
+
 	return `<?xml version="1.0" encoding="utf-8"?>
 <DIDL-Lite 
  xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/"
@@ -26,14 +51,100 @@ func lgMetadata(t Target) string {
  xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">
 
   <item id="0" parentID="0" restricted="1">
-    <dc:title>Video</dc:title>
+    <dc:title>` + html.EscapeString(title) + `</dc:title>
     <upnp:class>object.item.videoItem.movie</upnp:class>
-    <res protocolInfo="http-get:*:video/mp4:*">` + t.MediaURL + `</res>
+    <res protocolInfo="http-get:*:` + mime + `:*"` + resAttrs(t) + `>` + html.EscapeString(t.MediaURL) + `</res>
   </item>
 
 </DIDL-Lite>`
 }
 
+func musicTrackMetadata(t Target) string {
+	title := t.Title
+	if title == "" {
+		title = "Track"
+	}
+	class := t.Class
+	if class == "" {
+		class = "object.item.audioItem.musicTrack"
+	}
+
+	var extra string
+	if t.Artist != "" {
+		extra += `
+    <upnp:artist>` + html.EscapeString(t.Artist) + `</upnp:artist>
+    <dc:creator>` + html.EscapeString(t.Artist) + `</dc:creator>`
+	}
+	if t.Album != "" {
+		extra += `
+    <upnp:album>` + html.EscapeString(t.Album) + `</upnp:album>`
+	}
+	if t.AlbumArtURL != "" {
+		extra += `
+    <upnp:albumArtURI dlna:profileID="JPEG_TN">` + html.EscapeString(t.AlbumArtURL) + `</upnp:albumArtURI>`
+	}
+
+	return `<?xml version="1.0" encoding="utf-8"?>
+<DIDL-Lite 
+ xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/"
+ xmlns:dc="http://purl.org/dc/elements/1.1/"
+ xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/"
+ xmlns:dlna="urn:schemas-dlna-org:metadata-1-0/">
+
+  <item id="0" parentID="0" restricted="1">
+    <dc:title>` + html.EscapeString(title) + `</dc:title>` + extra + `
+    <upnp:class>` + class + `</upnp:class>
+    <res protocolInfo="http-get:*:` + t.Mime + `:*"` + resAttrs(t) + `>` + html.EscapeString(t.MediaURL) + `</res>
+  </item>
+
+</DIDL-Lite>`
+}
+
+func photoMetadata(t Target) string {
+	title := t.Title
+	if title == "" {
+		title = "Photo"
+	}
+
+	return `<?xml version="1.0" encoding="utf-8"?>
+<DIDL-Lite 
+ xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/"
+ xmlns:dc="http://purl.org/dc/elements/1.1/"
+ xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">
+
+  <item id="0" parentID="0" restricted="1">
+    <dc:title>` + html.EscapeString(title) + `</dc:title>
+    <upnp:class>object.item.imageItem.photo</upnp:class>
+    <res protocolInfo="http-get:*:` + t.Mime + `:*"` + resAttrs(t) + `>` + html.EscapeString(t.MediaURL) + `</res>
+  </item>
+
+</DIDL-Lite>`
+}
+
+// resAttrs renders optional <res> duration/resolution attributes.
+func resAttrs(t Target) string {
+	var a string
+	if t.Duration > 0 {
+		a += ` duration="` + didlDuration(t.Duration) + `"`
+	}
+	if t.Resolution != "" {
+		a += ` resolution="` + t.Resolution + `"`
+	}
+	return a
+}
+
+// didlDuration formats H+:MM:SS.FFF as required by DIDL-Lite.
+func didlDuration(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf(
+		"%d:%02d:%02d.%03d",
+		ms/3600000,
+		(ms/60000)%60,
+		(ms/1000)%60,
+		ms%1000,
+	)
+}
+
 func sonyMetadata(t Target) string {
 	// TODO: stricter DIDL-Lite
 	return ""
@@ -0,0 +1,195 @@
+package avtransport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"renderctl/logger"
+)
+
+// ---- MIME NEGOTIATION ----
+
+// errIllegalMime is the UPnP AVTransport "Illegal MIME-type" fault.
+const errIllegalMime = 714
+
+const (
+	statePoll  = 500 * time.Millisecond
+	stateGrace = time.Second // renderers report NO_MEDIA_PRESENT briefly after Play
+)
+
+// UPnPError is a SOAP fault returned by the renderer.
+type UPnPError struct {
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	return fmt.Sprintf("UPnP error %d: %s", e.Code, e.Description)
+}
+
+// MimeAttempt is one SetAVTransportURI try during negotiation.
+type MimeAttempt struct {
+	Mime   string
+	Result string // "accepted" or why the renderer rejected it
+}
+
+// Negotiate casts t with each candidate mime in order until the renderer
+// accepts one. A candidate is rejected on a 714 fault, a transport
+// ERROR / NO_MEDIA_PRESENT state, or when fetched never reports a client
+// GET within timeout. prepare runs before each attempt (switch the served
+// Content-Type) and returns the DIDL for that candidate.
+func Negotiate(
+	t Target,
+	candidates []string,
+	prepare func(Target) string,
+	fetched func(since time.Time) bool,
+	timeout time.Duration,
+) (string, []MimeAttempt, error) {
+	var attempts []MimeAttempt
+
+	for i, mime := range candidates {
+		t.Mime = mime
+		logger.Notify("Casting as %s (%d/%d)", mime, i+1, len(candidates))
+
+		reason, err := tryMime(t, prepare(t), fetched, timeout)
+		if err != nil {
+			return "", attempts, err
+		}
+		if reason == "" {
+			attempts = append(attempts, MimeAttempt{Mime: mime, Result: "accepted"})
+			return mime, attempts, nil
+		}
+
+		logger.Notify("Renderer rejected %s: %s", mime, reason)
+		attempts = append(attempts, MimeAttempt{Mime: mime, Result: reason})
+	}
+
+	return "", attempts, errors.New("renderer rejected every mime candidate")
+}
+
+// tryMime returns "" when the renderer accepted the cast, a rejection
+// reason otherwise. err is set only for failures no mime would fix.
+func tryMime(
+	t Target,
+	meta string,
+	fetched func(since time.Time) bool,
+	timeout time.Duration,
+) (string, error) {
+	_, _ = CLI.soapCall(t.ControlURL, stopBody, "Stop")
+	time.Sleep(150 * time.Millisecond)
+
+	since := time.Now()
+
+	if _, err := CLI.soapCall(t.ControlURL, setURIBody(t, meta), "SetAVTransportURI"); err != nil {
+		var ue *UPnPError
+		if errors.As(err, &ue) {
+			if ue.Code == errIllegalMime {
+				return fmt.Sprintf("%d Illegal MIME-type", ue.Code), nil
+			}
+			return ue.Error(), nil
+		}
+		return "", err
+	}
+
+	if _, err := CLI.soapCall(t.ControlURL, playBody, "Play"); err != nil {
+		var ue *UPnPError
+		if errors.As(err, &ue) {
+			return "Play: " + ue.Error(), nil
+		}
+		return "", err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if fetched(since) {
+			return "", nil
+		}
+
+		if time.Since(since) < stateGrace {
+			time.Sleep(statePoll)
+			continue
+		}
+
+		state, status := TransportInfo(t.ControlURL)
+		switch {
+		case status == "ERROR_OCCURRED":
+			return "transport status ERROR_OCCURRED", nil
+		case state == "NO_MEDIA_PRESENT", state == "ERROR":
+			return "transport state " + state, nil
+		}
+
+		time.Sleep(statePoll)
+	}
+
+	return fmt.Sprintf("no client connection within %v", timeout), nil
+}
+
+// TransportInfo returns CurrentTransportState and CurrentTransportStatus,
+// empty when the renderer does not answer.
+func TransportInfo(controlURL string) (state, status string) {
+	return CLI.TransportInfo(controlURL)
+}
+
+func (l Log) TransportInfo(controlURL string) (state, status string) {
+	body, err := l.soapCall(controlURL, probeSOAP, "GetTransportInfo")
+	if err != nil {
+		return "", ""
+	}
+
+	var env struct {
+		State  string `xml:"Body>GetTransportInfoResponse>CurrentTransportState"`
+		Status string `xml:"Body>GetTransportInfoResponse>CurrentTransportStatus"`
+	}
+	if xml.Unmarshal(body, &env) != nil {
+		return "", ""
+	}
+	return strings.TrimSpace(env.State), strings.TrimSpace(env.Status)
+}
+
+// soapCall posts an AVTransport action and turns SOAP faults into *UPnPError.
+func (l Log) soapCall(controlURL, body, action string) ([]byte, error) {
+	return l.serviceCall(controlURL, avtService, body, action)
+}
+
+// serviceCall is soapCall for services other than AVTransport.
+func (l Log) serviceCall(controlURL, service, body, action string) ([]byte, error) {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+
+	req, err := http.NewRequest("POST", controlURL, bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", `"`+service+`#`+action+`"`)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	l.info("%s: %d", action, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		var fault struct {
+			Code int    `xml:"Body>Fault>detail>UPnPError>errorCode"`
+			Desc string `xml:"Body>Fault>detail>UPnPError>errorDescription"`
+		}
+		if xml.Unmarshal(respBody, &fault) == nil && fault.Code != 0 {
+			return nil, &UPnPError{Code: fault.Code, Description: fault.Desc}
+		}
+		return nil, &UPnPError{Description: resp.Status}
+	}
+
+	return respBody, nil
+}
@@ -1,80 +1,40 @@
 package avtransport
 
 import (
+	"net/url"
 	"renderctl/internal/cache"
 	"renderctl/internal/identity"
 	"renderctl/internal/models"
 	myidentity "renderctl/internal/servers/identity"
-	"renderctl/internal/ssdp"
 	"renderctl/internal/utils"
 	"renderctl/logger"
-	"strings"
 	"time"
 )
 
-func TrySSDP(cfg *models.Config) bool {
-	logger.Notify("Running SSDP discovery scan")
-
-	devices, _ := ssdp.ListenNotify(cfg.SSDPTimeout, cfg.LIP)
-
-	// filter + detect
-	found := make([]*ssdp.DetectedTV, 0)
-	for _, d := range devices {
-		if d.Location == "" {
-			continue
-		}
-		if !ssdp.LooksLikeTV(d) {
-			continue
-		}
-		if strings.Contains(d.Location, "nservice") {
-			continue
-		}
-		t, err := ssdp.FetchAndDetect(d.Location)
-		if err != nil {
-			continue
-		}
-		found = append(found, t)
-	}
-
-	// fallback even if NOTIFY had packets, but none were TVs
-	if len(found) == 0 {
-		logger.Notify("No TV-like NOTIFY devices, trying SSDP active discovery")
-		devices, _ = ssdp.Discover(3 * time.Second)
-
-		for _, d := range devices {
-			if d.Location == "" {
-				continue
-			}
-			if !ssdp.LooksLikeTV(d) {
-				logger.Info("SSDP rejected: USN=%s SERVER=%s", d.USN, d.Server)
-				continue
-			}
-			logger.Info(
-				"SSDP candidate accepted: USN=%s SERVER=%s LOCATION=%s",
-				d.USN,
-				d.Server,
-				d.Location,
-			)
-			if strings.Contains(d.Location, "nservice") {
-				continue
-			}
-			t, err := ssdp.FetchAndDetect(d.Location)
-			if err != nil {
-				continue
-			}
-			found = append(found, t)
-		}
-	}
+// Found is a renderer reported by SSDP discovery, with what the cache
+// needs to store it.
+type Found struct {
+	UDN        string
+	IP         string
+	Location   string // device description
+	ControlURL string
+	ConnMgrURL string
+	SCPDURL    string // AVTransport service description
+	Vendor     string
+}
 
+// CacheFound enriches each discovered renderer and stores it in the
+// cache. It reports whether there was anything to store.
+func CacheFound(cfg *models.Config, found []Found) bool {
 	if len(found) == 0 {
 		logger.Notify("SSDP yielded no cacheable AVTransport targets")
 		return false
-	} else {
-		logger.Result(
-			"SSDP discovery found %d cacheable AVTransport device(s)",
-			len(found),
-		)
 	}
+	logger.Result(
+		"SSDP discovery found %d cacheable AVTransport device(s)",
+		len(found),
+	)
+
 	selfUUID, _ := myidentity.FetchUUID()
 
 	for _, tv := range found {
@@ -90,8 +50,8 @@ func TrySSDP(cfg *models.Config) bool {
 		if tv.IP != "" {
 			local.TIP = tv.IP
 		}
-		if tv.Port != "" {
-			local.TPort = tv.Port
+		if u, err := url.Parse(tv.Location); err == nil && u.Port() != "" {
+			local.TPort = u.Port()
 		}
 		if tv.ControlURL != "" {
 			local.TPath = tv.ControlURL
@@ -99,13 +59,13 @@ func TrySSDP(cfg *models.Config) bool {
 		if tv.Vendor != "" {
 			local.TVVendor = tv.Vendor
 		}
-		if tv.ConnectionManagerCtrl != "" {
-			local.CachedConnMgrURL = tv.ConnectionManagerCtrl
+		if tv.ConnMgrURL != "" {
+			local.CachedConnMgrURL = tv.ConnMgrURL
 		}
 
 		caps, err := EnrichCapabilities(
-			tv.AVTransportSCPD,
-			tv.ConnectionManagerCtrl,
+			tv.SCPDURL,
+			tv.ConnMgrURL,
 			Target{
 				ControlURL: utils.ControlURL(&local),
 			},
@@ -117,20 +77,14 @@ func TrySSDP(cfg *models.Config) bool {
 		)
 
 		update := cache.Device{
+			UDN:        tv.UDN,
 			ControlURL: utils.ControlURL(&local),
 			Vendor:     tv.Vendor,
-			ConnMgrURL: tv.ConnectionManagerCtrl,
+			ConnMgrURL: tv.ConnMgrURL,
 		}
 
 		if infoErr == nil {
-			update.Identity = map[string]any{
-				"friendly_name": info.FriendlyName,
-				"manufacturer":  info.Manufacturer,
-				"model_name":    info.ModelName,
-				"model_number":  info.ModelNumber,
-				"udn":           info.UDN,
-				"presentation":  info.Presentation,
-			}
+			update.Identity = identityFields(info)
 		}
 
 		if err == nil && caps != nil {
@@ -145,3 +99,15 @@ func TrySSDP(cfg *models.Config) bool {
 
 	return true
 }
+
+// identityFields is the cache's identity record for a device description.
+func identityFields(info *identity.Info) map[string]any {
+	return map[string]any{
+		"friendly_name": info.FriendlyName,
+		"manufacturer":  info.Manufacturer,
+		"model_name":    info.ModelName,
+		"model_number":  info.ModelNumber,
+		"udn":           info.UDN,
+		"presentation":  info.Presentation,
+	}
+}
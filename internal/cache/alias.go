@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+======== ALIASES AND DEVICE LOOKUP ========
+*/
+
+// Resolve finds a cached device by list index, alias, IP, UDN or
+// friendly name, then by fuzzy match, and returns its store key, which
+// unlike an index stays put when other entries come and go.
+// More than one candidate at the first matching step is an error.
+func Resolve(ref string) (string, error) {
+	store, err := Load()
+	if err != nil {
+		return "", err
+	}
+	return resolveIn(store, ref)
+}
+
+// resolveIn is Resolve against an already loaded store.
+func resolveIn(store Store, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("empty device reference")
+	}
+
+	keys := sortedCache(store)
+	if len(keys) == 0 {
+		return "", fmt.Errorf("cache is empty (run a discovery first, e.g. --ssdp)")
+	}
+
+	if i, err := strconv.Atoi(ref); err == nil {
+		if i < 0 || i >= len(keys) {
+			return "", fmt.Errorf("no cached device at index %d", i)
+		}
+		return keys[i], nil
+	}
+
+	steps := []func(cd *CachedDevice) bool{
+		// aliases are unique, they always win
+		func(cd *CachedDevice) bool { return cd.hasAlias(ref) },
+		func(cd *CachedDevice) bool {
+			return cd.IP == ref || sameUDN(cd.UDN(), ref)
+		},
+		func(cd *CachedDevice) bool {
+			return strings.EqualFold(identityString(cd, "friendly_name"), ref)
+		},
+		// fuzzy: part of a name, then a near miss (typo)
+		func(cd *CachedDevice) bool {
+			q := fold(ref)
+			for _, n := range cd.names() {
+				if q != "" && strings.Contains(fold(n), q) {
+					return true
+				}
+			}
+			return false
+		},
+		func(cd *CachedDevice) bool {
+			q := fold(ref)
+			if len(q) < 4 {
+				return false
+			}
+			for _, n := range cd.names() {
+				// whole name or any word of it ("kichen" -> "Kitchen Speaker")
+				for _, w := range append(strings.Fields(n), n) {
+					if editDistance(fold(w), q) <= len(q)/4+1 {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	}
+
+	for _, match := range steps {
+		var hits []int
+		for i, key := range keys {
+			if match(store[key]) {
+				hits = append(hits, i)
+			}
+		}
+		switch {
+		case len(hits) == 1:
+			return keys[hits[0]], nil
+		case len(hits) > 1:
+			var b strings.Builder
+			fmt.Fprintf(&b, "%q matches %d cached devices:", ref, len(hits))
+			for _, i := range hits {
+				fmt.Fprintf(&b, "\n    [%d] %s", i, describe(store[keys[i]]))
+			}
+			b.WriteString("\n  pick one by alias, IP or index (renderctl cache alias <IP> <name>)")
+			return "", fmt.Errorf("%s", b.String())
+		}
+	}
+
+	return "", fmt.Errorf("no cached device matches %q (see renderctl --list-cache)", ref)
+}
+
+// SetAlias adds alias to the device ref resolves to and returns its IP.
+func SetAlias(ref, alias string) (string, error) {
+	if err := validAlias(alias); err != nil {
+		return "", err
+	}
+
+	var ip string
+	var failed error
+	err := Update(func(store Store) bool {
+		key, err := resolveIn(store, ref)
+		if err != nil {
+			failed = err
+			return false
+		}
+		target := store[key]
+		ip = target.IP
+
+		for _, cd := range store {
+			if cd.hasAlias(alias) {
+				if cd != target {
+					failed = fmt.Errorf("alias %q already names %s (renderctl cache unalias %s)", alias, cd.IP, alias)
+				}
+				return false
+			}
+		}
+
+		target.Aliases = append(target.Aliases, alias)
+		return true
+	})
+	if failed != nil {
+		return "", failed
+	}
+	return ip, err
+}
+
+// RemoveAlias drops alias from whichever device has it and returns its IP.
+func RemoveAlias(alias string) (string, error) {
+	ip := ""
+	err := Update(func(store Store) bool {
+		for _, cd := range store {
+			if !cd.hasAlias(alias) {
+				continue
+			}
+			kept := cd.Aliases[:0]
+			for _, a := range cd.Aliases {
+				if !strings.EqualFold(a, alias) {
+					kept = append(kept, a)
+				}
+			}
+			cd.Aliases = kept
+			ip = cd.IP
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return "", err
+	}
+	if ip == "" {
+		return "", fmt.Errorf("no cached device has alias %q", alias)
+	}
+	return ip, nil
+}
+
+// validAlias keeps aliases apart from indexes and IPs.
+func validAlias(a string) error {
+	if a == "" {
+		return fmt.Errorf("empty alias")
+	}
+	for i, r := range a {
+		letter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if i == 0 && !letter {
+			return fmt.Errorf("alias %q must start with a letter", a)
+		}
+		if !letter && !(r >= '0' && r <= '9') && r != '-' && r != '_' && r != '.' {
+			return fmt.Errorf("alias %q may only use letters, digits, '-', '_' and '.'", a)
+		}
+	}
+	return nil
+}
+
+func (cd *CachedDevice) hasAlias(a string) bool {
+	for _, x := range cd.Aliases {
+		if strings.EqualFold(x, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// names are what fuzzy matching looks at.
+func (cd *CachedDevice) names() []string {
+	n := append([]string{}, cd.Aliases...)
+	if fn := identityString(cd, "friendly_name"); fn != "" {
+		n = append(n, fn)
+	}
+	if m := identityString(cd, "model_name"); m != "" {
+		n = append(n, m)
+	}
+	return n
+}
+
+func identityString(cd *CachedDevice, key string) string {
+	s, _ := cd.Identity[key].(string)
+	return s
+}
+
+func sameUDN(udn, ref string) bool {
+	if udn == "" {
+		return false
+	}
+	trim := func(s string) string { return strings.TrimPrefix(strings.ToLower(s), "uuid:") }
+	return trim(udn) == trim(ref)
+}
+
+// describe is one line for ambiguity errors.
+func describe(cd *CachedDevice) string {
+	s := cd.IP
+	if fn := identityString(cd, "friendly_name"); fn != "" {
+		s += fmt.Sprintf(" %q", fn)
+	}
+	if len(cd.Aliases) > 0 {
+		s += " (" + strings.Join(cd.Aliases, ", ") + ")"
+	}
+	return s
+}
+
+// fold lowercases and drops everything but letters and digits,
+// so "Living-Room TV" and "livingroomtv" compare equal.
+func fold(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
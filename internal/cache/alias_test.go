@@ -0,0 +1,63 @@
+package cache
+
+import "testing"
+
+func testStore() Store {
+	dev := func(udn, ip, name string, aliases ...string) *CachedDevice {
+		return &CachedDevice{IP: ip, Aliases: aliases, Identity: map[string]any{"udn": udn, "friendly_name": name}}
+	}
+	return Store{
+		"uuid:kitchen": dev("uuid:kitchen", "192.168.1.30", "Kitchen Speaker", "kitchen"),
+		"uuid:living":  dev("uuid:living", "192.168.1.20", "Living Room TV"),
+		"ip:10.0.0.5":  dev("", "10.0.0.5", "Bedroom TV"),
+	}
+}
+
+func TestResolveIn(t *testing.T) {
+	cases := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "0", want: "ip:10.0.0.5"},
+		{ref: "2", want: "uuid:kitchen"},
+		{ref: "3", wantErr: true},
+		{ref: "kitchen", want: "uuid:kitchen"},
+		{ref: "192.168.1.20", want: "uuid:living"},
+		{ref: "uuid:living", want: "uuid:living"},
+		{ref: "living", want: "uuid:living"},
+		{ref: "bedrom", want: "ip:10.0.0.5"},
+		{ref: "tv", wantErr: true}, // two TVs
+		{ref: "garage", wantErr: true},
+		{ref: " ", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.ref, func(t *testing.T) {
+			got, err := resolveIn(testStore(), tc.ref)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// A key keeps naming the same device when entries sorting before it
+// are added or removed, which an index does not.
+func TestResolvedKeySurvivesReorder(t *testing.T) {
+	store := testStore()
+	key, err := resolveIn(store, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store["uuid:attic"] = &CachedDevice{IP: "10.0.0.1"}
+	if got := store[key].IP; got != "192.168.1.20" {
+		t.Fatalf("key %s now names %s", key, got)
+	}
+	if again, _ := resolveIn(store, "1"); again == key {
+		t.Fatalf("index 1 should have shifted")
+	}
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+======== LEARNED CLIENT BEHAVIOUR ========
+*/
+
+// behaviorMu serialises updates from concurrent stream requests.
+var behaviorMu sync.Mutex
+
+// LoadBehavior returns what earlier sessions learned about the TV at ip.
+func LoadBehavior(ip string) (Behavior, bool) {
+	store, _ := Load()
+	_, cd, ok := store.ByIP(ip)
+	if !ok || cd.Behavior == nil {
+		return Behavior{}, false
+	}
+	return *cd.Behavior, true
+}
+
+// UpdateBehavior applies fn to the TV's behaviour record and saves it when
+// fn reports a change. Only devices already in the cache are updated.
+func UpdateBehavior(ip string, fn func(b *Behavior) bool) {
+	behaviorMu.Lock()
+	defer behaviorMu.Unlock()
+
+	_ = Update(func(store Store) bool {
+		_, cd, ok := store.ByIP(ip)
+		if !ok {
+			return false
+		}
+		if cd.Behavior == nil {
+			cd.Behavior = &Behavior{}
+		}
+		if !fn(cd.Behavior) {
+			return false
+		}
+		cd.Behavior.UpdatedAt = time.Now()
+		return true
+	})
+}
+
+// Merge folds another session's observations into b and reports whether
+// anything changed. Flags only ever turn on; o's user agent and mimes win.
+func (b *Behavior) Merge(o Behavior) bool {
+	changed := false
+	flag := func(dst *bool, v bool) {
+		if v && !*dst {
+			*dst = true
+			changed = true
+		}
+	}
+
+	if o.UserAgent != "" && o.UserAgent != b.UserAgent {
+		b.UserAgent = o.UserAgent
+		changed = true
+	}
+	flag(&b.SendsHEAD, o.SendsHEAD)
+	flag(&b.SendsRange, o.SendsRange)
+	flag(&b.NeedsLength, o.NeedsLength)
+	flag(&b.SlowStart, o.SlowStart)
+
+	for k, v := range o.Mimes {
+		if b.Mimes[k] == v {
+			continue
+		}
+		if b.Mimes == nil {
+			b.Mimes = map[string]string{}
+		}
+		b.Mimes[k] = v
+		changed = true
+	}
+	return changed
+}
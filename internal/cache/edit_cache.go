@@ -5,6 +5,7 @@ import (
 	"renderctl/internal/models"
 	"renderctl/internal/utils"
 	"renderctl/logger"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -42,63 +43,109 @@ func StoreInCache(cfg *models.Config, update Device) {
 
 	logger.Status("===============================================")
 
-	store, _ := Load()
-
 	// ---- DEVICE LEVEL ----
-	cd, ok := store[cfg.TIP]
-	if !ok {
-		cd = &CachedDevice{
-			Vendor:    update.Vendor,
-			Identity:  update.Identity,
-			Endpoints: map[string]*Endpoint{},
+	ip := cfg.TIP
+	udn := update.UDN
+	if udn == "" {
+		udn, _ = update.Identity["udn"].(string)
+	}
+	key := keyFor(udn, ip)
+
+	_ = Update(func(store Store) bool {
+		cd, ok := store[key]
+
+		// first seen by IP only: adopt that entry now the UDN is known
+		if oldKey, old, found := store.ByIP(ip); found && oldKey != key && isIPKey(oldKey) {
+			delete(store, oldKey)
+			if ok {
+				mergeInto(cd, old)
+			} else {
+				cd, ok = old, true
+				store[key] = cd
+			}
 		}
-		store[cfg.TIP] = cd
-	}
-
-	if cd.Vendor == "" && update.Vendor != "" {
-		cd.Vendor = update.Vendor
-	}
-
-	if update.Identity != nil {
-		cd.Identity = update.Identity
-	}
 
-	// ---- ENDPOINT LEVEL ----
-	if update.ControlURL != "" {
-		ep, ok := cd.Endpoints[update.ControlURL]
 		if !ok {
-			ep = &Endpoint{
-				ControlURL: update.ControlURL,
-				SeenAt:     time.Now(),
+			cd = &CachedDevice{
+				IP:        ip,
+				Vendor:    update.Vendor,
+				Identity:  update.Identity,
+				Endpoints: map[string]*Endpoint{},
 			}
-			cd.Endpoints[update.ControlURL] = ep
+			store[key] = cd
 		}
 
-		ep.SeenAt = time.Now()
+		if cd.IP != ip {
+			logger.Notify("Device %s moved: %s -> %s", key, cd.IP, ip)
+			cd.moveTo(ip)
+		}
 
-		if update.ConnMgrURL != "" {
-			ep.ConnMgrURL = update.ConnMgrURL
+		if cd.Vendor == "" && update.Vendor != "" {
+			cd.Vendor = update.Vendor
 		}
-		if update.Actions != nil {
-			ep.Actions = update.Actions
+
+		if update.Identity != nil {
+			cd.Identity = update.Identity
 		}
-		if update.Media != nil {
-			ep.Media = update.Media
+		if udn != "" && cd.UDN() == "" {
+			if cd.Identity == nil {
+				cd.Identity = map[string]any{}
+			}
+			cd.Identity["udn"] = udn
+		}
+
+		// ---- ENDPOINT LEVEL ----
+		if update.ControlURL != "" {
+			ep, ok := cd.Endpoints[update.ControlURL]
+			if !ok {
+				ep = &Endpoint{
+					ControlURL: update.ControlURL,
+					SeenAt:     time.Now(),
+				}
+				cd.Endpoints[update.ControlURL] = ep
+			}
+
+			ep.SeenAt = time.Now()
+
+			if update.ConnMgrURL != "" {
+				ep.ConnMgrURL = update.ConnMgrURL
+			}
+			if update.Actions != nil {
+				ep.Actions = update.Actions
+			}
+			if update.Media != nil {
+				ep.Media = update.Media
+			}
 		}
-	}
 
-	_ = Save(store)
+		return true
+	})
 }
 
 /*
 ======== LEGACY READ PATH ========
 */
 
-func LoadCachedTV(cfg *models.Config) {
-	ip, dev, ok := selectFromCache(cfg.SelectCache)
+// LoadCachedTV points cfg at the cached device stored under key (see
+// Resolve) and marks it as the selected cache entry.
+func LoadCachedTV(cfg *models.Config, key string) {
+	store, _ := Load()
+	ip, dev, ok := store.device(key)
 	if !ok {
-		logger.Error("Invalid cache index: %d", cfg.SelectCache)
+		logger.Error("Cached device %s is gone or has no playable endpoint", key)
+	}
+
+	// DHCP may have handed the device a new address since
+	if !Responds(dev.ControlURL) {
+		if _, ok := RelocateKey(key); ok {
+			store, _ = Load()
+			if moved, d, found := store.device(key); found {
+				ip, dev = moved, d
+			}
+		}
 	}
+	// after any move: the list is sorted by IP
+	cfg.SelectCache = slices.Index(sortedCache(store), key)
 
 	cfg.TIP = ip
 	cfg.TVVendor = dev.Vendor
@@ -112,33 +159,70 @@ func LoadCachedTV(cfg *models.Config) {
 	)
 }
 
-func selectFromCache(index int) (string, Device, bool) {
+// Select returns the cached device stored under key (see Resolve).
+func Select(key string) (string, Device, bool) {
 	store, _ := Load()
-	keys := sortedCache(store)
+	return store.device(key)
+}
 
+// SelectIndex returns the cached device at the given list index.
+func SelectIndex(index int) (string, Device, bool) {
+	store, _ := Load()
+	keys := sortedCache(store)
 	if index < 0 || index >= len(keys) {
 		return "", Device{}, false
 	}
+	return store.device(keys[index])
+}
 
-	ip := keys[index]
-	cd := store[ip]
+// Entry is one playable device of the cache list.
+type Entry struct {
+	Index  int // position in --list-cache
+	Key    string
+	IP     string
+	Device Device
+}
 
-	// derive primary endpoint deterministically
-	var urls []string
-	for u, ep := range cd.Endpoints {
-		if len(ep.Actions) > 0 {
-			urls = append(urls, u)
+// Entries lists the playable cached devices from a single read of the
+// store, so indexes and devices always agree.
+func Entries() ([]Entry, error) {
+	store, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for i, key := range sortedCache(store) {
+		if ip, dev, ok := store.device(key); ok {
+			out = append(out, Entry{Index: i, Key: key, IP: ip, Device: dev})
 		}
 	}
-	sort.Strings(urls)
+	return out, nil
+}
+
+// Lookup returns the cached device currently at an IP.
+func Lookup(ip string) (Device, bool) {
+	store, _ := Load()
+	key, _, found := store.ByIP(ip)
+	if !found {
+		return Device{}, false
+	}
+	_, dev, ok := store.device(key)
+	return dev, ok
+}
 
-	if len(urls) == 0 {
+func (s Store) device(key string) (string, Device, bool) {
+	cd, ok := s[key]
+	if !ok {
 		return "", Device{}, false
 	}
 
-	primary := cd.Endpoints[urls[0]]
+	primary := cd.Primary()
+	if primary == nil {
+		return "", Device{}, false
+	}
 
-	return ip, Device{
+	return cd.IP, Device{
+		UDN:        cd.UDN(),
 		Vendor:     cd.Vendor,
 		ControlURL: pick(primary, func(e *Endpoint) string { return e.ControlURL }),
 		ConnMgrURL: pick(primary, func(e *Endpoint) string { return e.ConnMgrURL }),
@@ -175,23 +259,33 @@ func handleCacheDetails(cfg models.Config) {
 	}
 
 	// deterministic IP order
-	keys := make([]string, 0, len(store))
-	for ip := range store {
-		keys = append(keys, ip)
-	}
-	sort.Strings(keys)
+	keys := sortedCache(store)
 
 	if index < 0 || index >= len(keys) {
 		logger.Error("Invalid cache index: %d", index)
 	}
 
-	ip := keys[index]
-	cd := store[ip]
+	cd := store[keys[index]]
+	ip := cd.IP
 
 	mediaFilter := mediaFilter(cfg)
 
 	// ---- ROOT ----
-	fmt.Printf("\n%s (%s)\n", ip, orNA(cd.Vendor))
+	fmt.Printf("\n%s (%s)", ip, orNA(cd.Vendor))
+	if len(cd.Aliases) > 0 {
+		fmt.Printf(" aka %s", strings.Join(cd.Aliases, ", "))
+	}
+	fmt.Println()
+	if udn := cd.UDN(); udn != "" {
+		fmt.Printf("├── udn: %s\n", udn)
+	}
+	if !cd.CheckedAt.IsZero() {
+		fmt.Printf("├── state: %s (checked %s", cd.State(), cd.CheckedAt.Format("2006-01-02 15:04"))
+		if !cd.Online && !cd.VerifiedAt.IsZero() {
+			fmt.Printf(", last online %s", cd.VerifiedAt.Format("2006-01-02 15:04"))
+		}
+		fmt.Println(")")
+	}
 	fmt.Println("├── AVTransport")
 
 	// ---- ENDPOINTS ----
@@ -301,6 +395,24 @@ func handleCacheDetails(cfg models.Config) {
 		)
 	}
 
+	// ---- LEARNED CLIENT BEHAVIOUR ----
+	if b := cd.Behavior; b != nil {
+		fmt.Println("└── client")
+		fmt.Printf("    ├── user-agent: %s\n", orNA(b.UserAgent))
+		fmt.Printf("    ├── head: %v  range: %v  needs-length: %v  slow-start: %v\n",
+			b.SendsHEAD, b.SendsRange, b.NeedsLength, b.SlowStart)
+
+		var keys []string
+		for k := range b.Mimes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("    ├── %s: %s\n", k, b.Mimes[k])
+		}
+		fmt.Printf("    └── learned: %s\n", b.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+
 	fmt.Println()
 }
 
@@ -326,12 +438,20 @@ func mediaFilter(cfg models.Config) map[string]bool {
 ======== CACHE COMMANDS ========
 */
 
+// sortedCache lists store keys by device IP, the order behind cache
+// indexes (the key breaks ties between stale duplicates).
 func sortedCache(store Store) []string {
 	keys := make([]string, 0, len(store))
-	for ip := range store {
-		keys = append(keys, ip)
+	for k := range store {
+		keys = append(keys, k)
 	}
-	sort.Strings(keys)
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := store[keys[i]].IP, store[keys[j]].IP
+		if a != b {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
 	return keys
 }
 
@@ -367,15 +487,16 @@ func handleListCache() {
 
 	logger.Status("\n\nCached AVTransport devices:\n\n")
 	fmt.Printf(
-		" %-3s %-15s %-12s %-60s %-60s\n",
-		"#", "IP", "Vendor", "ControlURL", "ConnMgrURL",
+		" %-3s %-15s %-16s %-24s %-10s %-9s %-5s %-60s\n",
+		"#", "IP", "Alias", "Name", "Vendor", "State", "Age", "ControlURL",
 	)
-	fmt.Println(strings.Repeat("-", 160))
+	fmt.Println(strings.Repeat("-", 151))
 
 	keys := sortedCache(store)
+	now := time.Now()
 
-	for i, ip := range keys {
-		cd := store[ip]
+	for i, key := range keys {
+		cd := store[key]
 
 		var urls []string
 		for u, ep := range cd.Endpoints {
@@ -392,12 +513,15 @@ func handleListCache() {
 		}
 
 		fmt.Printf(
-			"[%d] %-15s %-12s %-60s %-60s\n",
+			"[%d] %-15s %-16s %-24s %-10s %-9s %-5s %-60s\n",
 			i,
-			ip,
-			col(cd.Vendor, 12),
+			cd.IP,
+			col(strings.Join(cd.Aliases, ","), 16),
+			col(identityString(cd, "friendly_name"), 24),
+			col(cd.Vendor, 10),
+			cd.State(),
+			FormatAge(cd.Age(now)),
 			col(pick(ep, func(e *Endpoint) string { return e.ControlURL }), 60),
-			col(pick(ep, func(e *Endpoint) string { return e.ConnMgrURL }), 60),
 		)
 	}
 }
@@ -419,23 +543,30 @@ func handleForgetCache(cfg models.Config) {
 		if !utils.Confirm("Delete ALL cached devices?") {
 			return
 		}
-		_ = Save(Store{})
+		if err := Update(func(s Store) bool { clear(s); return true }); err != nil {
+			logger.Error("%v", err)
+		}
 		logger.Success("Cache cleared.")
 		return
 
 	default:
-		if _, ok := store[cfg.ForgetCache]; !ok {
-			logger.Notify("IP not found in cache.")
+		// an IP, alias, name or UDN
+		key, err := resolveIn(store, cfg.ForgetCache)
+		if err != nil {
+			logger.Notify("%v", err)
 			return
 		}
+		ip := store[key].IP
 
-		if !utils.Confirm("Delete cached entry for " + cfg.ForgetCache + "?") {
+		if !utils.Confirm("Delete cached entry for " + ip + "?") {
 			return
 		}
 
-		delete(store, cfg.ForgetCache)
-		_ = Save(store)
-		logger.Success("Deleted %s", cfg.ForgetCache)
+		// re-read: another process may have written since the prompt
+		if err := Update(func(s Store) bool { delete(s, key); return true }); err != nil {
+			logger.Error("%v", err)
+		}
+		logger.Success("Deleted %s", ip)
 	}
 }
 
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+/*
+======== HEALTH AND EXPIRY ========
+*/
+
+// Mark records the outcome of a health check.
+func (cd *CachedDevice) Mark(online bool, at time.Time) {
+	cd.Online = online
+	cd.CheckedAt = at
+	if online {
+		cd.VerifiedAt = at
+	}
+}
+
+// State is "online" or "offline" as of the last check, "unchecked" before one.
+func (cd *CachedDevice) State() string {
+	switch {
+	case cd.CheckedAt.IsZero():
+		return "unchecked"
+	case cd.Online:
+		return "online"
+	default:
+		return "offline"
+	}
+}
+
+// Age is the time since the device was last known to be alive: verified
+// by a check or seen by discovery, whichever is later.
+func (cd *CachedDevice) Age(now time.Time) time.Duration {
+	last := cd.lastSeen()
+	if cd.VerifiedAt.After(last) {
+		last = cd.VerifiedAt
+	}
+	if last.IsZero() {
+		return 0
+	}
+	return now.Sub(last)
+}
+
+// Prune removes devices older than ttl and returns their descriptions.
+// A ttl of 0 keeps everything.
+func (s Store) Prune(ttl time.Duration, now time.Time) []string {
+	if ttl <= 0 {
+		return nil
+	}
+	var gone []string
+	for k, cd := range s {
+		if cd.Age(now) > ttl {
+			gone = append(gone, describe(cd)+" (last seen "+FormatAge(cd.Age(now))+" ago)")
+			delete(s, k)
+		}
+	}
+	sort.Strings(gone)
+	return gone
+}
+
+// FormatAge is a short duration for listings: 45s, 12m, 5h, 3d.
+func FormatAge(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "n/a"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -1,29 +1,145 @@
 package cache
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"strconv"
+	"time"
+
+	"renderctl/logger"
 )
 
+// errCorrupt marks a devices.json that could not be decoded at all.
+var errCorrupt = errors.New("corrupt cache file")
+
+// Load reads the cache under a shared lock. A file in an older layout is
+// upgraded and written back (the original kept, see keepOldLayout); an
+// unreadable one is set aside (see recoverCorrupt) and the cache starts
+// empty.
 func Load() (Store, error) {
 	path, err := Path()
 	if err != nil {
 		return nil, err
 	}
 
-	f, err := os.Open(path)
+	unlock, err := lockFile(path, false)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return Store{}, nil // empty cache
-		}
 		return nil, err
 	}
-	defer f.Close()
+	store, version, err := read(path)
+	unlock()
 
-	var store Store
-	if err := json.NewDecoder(f).Decode(&store); err != nil {
+	if err == nil && version == schemaVersion {
+		return store, nil
+	}
+	if err != nil && !errors.Is(err, errCorrupt) {
 		return nil, err
 	}
 
-	return store, nil
+	// migrating or recovering writes: redo it under the exclusive lock
+	err = Update(func(s Store) bool {
+		store = s
+		return false
+	})
+	return store, err
+}
+
+// Update runs fn on the cache under an exclusive lock and saves the
+// result when fn reports a change, so concurrent renderctl processes do
+// not overwrite each other. fn must not call Load or Update.
+func Update(fn func(Store) bool) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(path, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	store, version, err := read(path)
+	if errors.Is(err, errCorrupt) {
+		store, version, err = recoverCorrupt(path, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	rewrite := version < schemaVersion
+	if rewrite {
+		if err := keepOldLayout(path, version); err != nil {
+			return err
+		}
+	}
+	if !fn(store) && !rewrite {
+		return nil
+	}
+	return write(path, store)
+}
+
+// read decodes devices.json and returns the layout version it found,
+// upgraded in memory when older. A missing file is an empty cache.
+func read(path string) (store Store, version int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Store{}, schemaVersion, nil
+		}
+		return nil, 0, err
+	}
+	if len(data) == 0 {
+		return Store{}, schemaVersion, nil
+	}
+
+	seen := time.Now()
+	if info, err := os.Stat(path); err == nil {
+		seen = info.ModTime()
+	}
+
+	store, version, err = decodeStore(data, seen)
+	if err != nil {
+		// a newer layout is not ours to back up
+		if errors.Is(err, errNewerVersion) {
+			return nil, 0, err
+		}
+		return nil, 0, fmt.Errorf("%w: %v", errCorrupt, err)
+	}
+	return store, version, nil
+}
+
+// recoverCorrupt moves an undecodable devices.json aside (nothing is
+// deleted) so discovery can start over. Caller holds the exclusive lock.
+func recoverCorrupt(path string, cause error) (Store, int, error) {
+	backup := path + ".corrupt-" + time.Now().Format("20060102-150405")
+	if err := os.Rename(path, backup); err != nil {
+		return nil, 0, err
+	}
+	logger.Notify("%s: %v, moved to %s", path, cause, backup)
+	return Store{}, schemaVersion, nil
+}
+
+// keepOldLayout copies a file about to be upgraded to devices.json.v<N>,
+// so an older renderctl can still be pointed at it. A backup from an
+// earlier upgrade is left alone. Caller holds the exclusive lock.
+func keepOldLayout(path string, version int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path+".v"+strconv.Itoa(version), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
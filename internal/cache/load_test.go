@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cacheFiles lists ~/.renderctl without the lock file.
+func cacheFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Name() != "devices.json.lock" {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+func TestLoadUpgradesAndKeepsBackups(t *testing.T) {
+	cases := []struct {
+		fixture string
+		backup  string // "" = none; "corrupt" = devices.json.corrupt-<time>
+		entries int
+		wantErr error
+	}{
+		{fixture: "v1_legacy.json", backup: "devices.json.v1", entries: 2},
+		{fixture: "v2_ip_keyed.json", backup: "devices.json.v2", entries: 2},
+		{fixture: "mixed_ip_keys.json", backup: "devices.json.v2", entries: 2},
+		{fixture: "v3.json", entries: 2},
+		{fixture: "newer_version.json", wantErr: errNewerVersion},
+		{fixture: "truncated.json", backup: "corrupt"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			path, err := Path()
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := filepath.Dir(path)
+			orig := fixture(t, tc.fixture)
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, orig, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			store, err := Load()
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tc.wantErr)
+				}
+				// left exactly as found
+				if now, _ := os.ReadFile(path); !bytes.Equal(now, orig) {
+					t.Fatal("devices.json was modified")
+				}
+				if files := cacheFiles(t, dir); !slices.Equal(files, []string{"devices.json"}) {
+					t.Fatalf("files %v, want devices.json only", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(store) != tc.entries {
+				t.Fatalf("%d entries, want %d", len(store), tc.entries)
+			}
+
+			files := cacheFiles(t, dir)
+			switch tc.backup {
+			case "":
+				if !slices.Equal(files, []string{"devices.json"}) {
+					t.Fatalf("files %v, want devices.json only", files)
+				}
+				if now, _ := os.ReadFile(path); !bytes.Equal(now, orig) {
+					t.Fatal("current layout was rewritten")
+				}
+				return
+
+			case "corrupt":
+				if len(files) != 1 || !strings.HasPrefix(files[0], "devices.json.corrupt-") {
+					t.Fatalf("files %v, want one devices.json.corrupt-<time>", files)
+				}
+				if moved, _ := os.ReadFile(filepath.Join(dir, files[0])); !bytes.Equal(moved, orig) {
+					t.Fatal("corrupt backup differs from the original file")
+				}
+				return
+			}
+
+			if !slices.Equal(files, []string{"devices.json", tc.backup}) {
+				t.Fatalf("files %v, want devices.json and %s", files, tc.backup)
+			}
+			if kept, _ := os.ReadFile(filepath.Join(dir, tc.backup)); !bytes.Equal(kept, orig) {
+				t.Fatal("backup differs from the original file")
+			}
+
+			// written back in the current layout, same devices
+			data, _ := os.ReadFile(path)
+			onDisk, version, err := decodeStore(data, time.Time{})
+			if err != nil || version != schemaVersion {
+				t.Fatalf("rewritten file: version %d, err %v", version, err)
+			}
+			// compared as JSON: times read back in a fixed zone, not Local
+			a, _ := json.Marshal(onDisk)
+			b, _ := json.Marshal(store)
+			if !bytes.Equal(a, b) {
+				t.Fatalf("rewritten file differs from the loaded store\n%s\n%s", a, b)
+			}
+
+			// the next upgrade must not replace the first backup
+			if err := os.WriteFile(path, []byte(`{"192.168.1.9": {"control_url": "http://192.168.1.9/ctl"}}`), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(); err != nil {
+				t.Fatal(err)
+			}
+			if kept, _ := os.ReadFile(filepath.Join(dir, tc.backup)); !bytes.Equal(kept, orig) {
+				t.Fatal("backup was overwritten by a later upgrade")
+			}
+		})
+	}
+}
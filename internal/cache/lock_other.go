@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cache
+
+// lockFile is a no-op where flock is not available; writes stay atomic
+// (temp file + rename) but concurrent processes may lose updates.
+func lockFile(string, bool) (func(), error) {
+	return func() {}, nil
+}
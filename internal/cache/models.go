@@ -8,6 +8,7 @@ import "time"
 
 // Legacy view model (still used by list + playback)
 type Device struct {
+	UDN        string `json:"udn,omitempty"`
 	Vendor     string `json:"vendor"`
 	ControlURL string `json:"control_url"`
 	ConnMgrURL string `json:"conn_mgr_url,omitempty"`
@@ -18,12 +19,20 @@ type Device struct {
 }
 
 // New internal storage model
-type Store map[string]*CachedDevice // keyed by IP
+type Store map[string]*CachedDevice // keyed by UDN ("ip:<addr>" until it is known)
 
 type CachedDevice struct {
+	IP        string               `json:"ip"` // last known address, follows DHCP changes
 	Vendor    string               `json:"vendor,omitempty"`
 	Identity  map[string]any       `json:"identity,omitempty"`
-	Endpoints map[string]*Endpoint `json:"endpoints"` // key = ControlURL
+	Aliases   []string             `json:"aliases,omitempty"` // user names for --device
+	Endpoints map[string]*Endpoint `json:"endpoints"`         // key = ControlURL
+	Behavior  *Behavior            `json:"behavior,omitempty"`
+
+	// health, from `renderctl cache check`
+	Online     bool      `json:"online,omitempty"`
+	CheckedAt  time.Time `json:"checked_at,omitzero"`  // last check, either way
+	VerifiedAt time.Time `json:"verified_at,omitzero"` // last check it answered
 }
 
 type Endpoint struct {
@@ -33,3 +42,14 @@ type Endpoint struct {
 	Media      map[string][]string `json:"media,omitempty"`
 	SeenAt     time.Time           `json:"seen_at"`
 }
+
+// Behavior is what the stream server learned about the TV's HTTP client.
+type Behavior struct {
+	UserAgent   string            `json:"user_agent,omitempty"`
+	SendsHEAD   bool              `json:"sends_head,omitempty"`
+	SendsRange  bool              `json:"sends_range,omitempty"`
+	NeedsLength bool              `json:"needs_length,omitempty"` // dropped chunked responses
+	SlowStart   bool              `json:"slow_start,omitempty"`   // gave up on a slow pipeline
+	Mimes       map[string]string `json:"mimes,omitempty"`        // container key -> mime that played
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
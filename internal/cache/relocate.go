@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"net"
+	"net/url"
+	"time"
+
+	"renderctl/internal/ssdp"
+	"renderctl/logger"
+)
+
+/*
+======== FOLLOWING DEVICES ACROSS IP CHANGES ========
+*/
+
+// relocateTimeout bounds the SSDP search for a device that moved.
+const relocateTimeout = 5 * time.Second
+
+// Responds reports whether anything accepts connections at controlURL.
+func Responds(controlURL string) bool {
+	u, err := url.Parse(controlURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", host, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// RelocateKey searches the network for the device stored under key by
+// its UDN and, when it answers from a new address, moves the entry
+// there. Returns the new IP and whether the device moved. Callers that
+// know the key use it: after a DHCP swap, the device at the old IP is
+// another one.
+func RelocateKey(key string) (string, bool) {
+	store, err := Load()
+	if err != nil {
+		return "", false
+	}
+	cd, ok := store[key]
+	if !ok {
+		return "", false
+	}
+	ip := cd.IP
+	udn := cd.UDN()
+	if udn == "" {
+		logger.Notify("%s is not responding and its UDN is unknown (run --ssdp to find it again)", ip)
+		return "", false
+	}
+
+	logger.Notify("%s is not responding, searching for %s", ip, udn)
+	location, err := ssdp.SearchUDN(udn, relocateTimeout)
+	if err != nil {
+		logger.Notify("%v", err)
+		return "", false
+	}
+	tv, err := ssdp.FetchAndDetect(location)
+	if err != nil {
+		logger.Notify("Device description: %v", err)
+		return "", false
+	}
+	if tv.IP == ip {
+		return ip, false
+	}
+
+	// the search took seconds: apply the move to a fresh read
+	moved := false
+	err = Update(func(store Store) bool {
+		cd, ok := store[key]
+		if !ok {
+			return false
+		}
+
+		primary := cd.Primary()
+		cd.moveTo(tv.IP)
+
+		// a new port or path replaces the old endpoints: only the one the
+		// device describes now is known to work (its capabilities carry over)
+		if tv.ControlURL != "" {
+			ep, ok := cd.Endpoints[tv.ControlURL]
+			if !ok {
+				ep = &Endpoint{ControlURL: tv.ControlURL}
+				if primary != nil {
+					ep.Actions = primary.Actions
+					ep.Media = primary.Media
+				}
+				cd.Endpoints = map[string]*Endpoint{tv.ControlURL: ep}
+			}
+			if tv.ConnectionManagerCtrl != "" {
+				ep.ConnMgrURL = tv.ConnectionManagerCtrl
+			}
+			ep.SeenAt = time.Now()
+		}
+		cd.Mark(true, time.Now())
+		moved = true
+		return true
+	})
+	if err != nil {
+		logger.Notify("Saving cache: %v", err)
+	}
+	if !moved {
+		return "", false
+	}
+	logger.Success("Found %s at %s (was %s)", udn, tv.IP, ip)
+	return tv.IP, true
+}
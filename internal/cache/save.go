@@ -6,12 +6,9 @@ import (
 	"path/filepath"
 )
 
-func Save(store Store) error {
-	path, err := Path()
-	if err != nil {
-		return err
-	}
-
+// write stores the current layout atomically (temp file + rename).
+// Caller holds the exclusive lock.
+func write(path string, store Store) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 		return err
 	}
@@ -24,11 +21,13 @@ func Save(store Store) error {
 
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
-	if err := enc.Encode(store); err != nil {
+	if err := enc.Encode(cacheFile{Version: schemaVersion, Devices: store}); err != nil {
 		f.Close()
 		return err
 	}
-	f.Close()
+	if err := f.Close(); err != nil {
+		return err
+	}
 
 	return os.Rename(tmp, path)
 }
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+======== FILE FORMAT AND MIGRATIONS ========
+*/
+
+// schemaVersion is the devices.json layout this build writes:
+//
+//	1  map of IP -> legacy Device view (control_url, actions, media)
+//	2  map of IP (later UDN) -> CachedDevice (endpoints, learned behaviour)
+//	3  {"version": 3, "devices": {UDN -> CachedDevice with its ip}}
+//
+// Versions 1 and 2 have no version field and are told apart by shape.
+const schemaVersion = 3
+
+var errNewerVersion = errors.New("cache file was written by a newer renderctl")
+
+// cacheFile is devices.json on disk.
+type cacheFile struct {
+	Version int   `json:"version"`
+	Devices Store `json:"devices"`
+}
+
+// decodeStore reads any known layout and upgrades it to the current one.
+// version is the layout found; below schemaVersion the file should be
+// written back. seen stands in for SeenAt on entries from layouts that
+// did not record it.
+func decodeStore(data []byte, seen time.Time) (store Store, version int, err error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, 0, err
+	}
+
+	if _, ok := top["version"]; ok {
+		var f cacheFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, 0, err
+		}
+		if f.Version > schemaVersion {
+			return nil, 0, fmt.Errorf("%w (version %d, this build reads up to %d)", errNewerVersion, f.Version, schemaVersion)
+		}
+		if f.Devices == nil {
+			f.Devices = Store{}
+		}
+		for _, cd := range f.Devices {
+			if cd.Endpoints == nil {
+				cd.Endpoints = map[string]*Endpoint{}
+			}
+		}
+		// no versioned layout before 3 yet: future upgrades go here
+		return f.Devices, f.Version, nil
+	}
+
+	// unversioned: 1 or 2, entry by entry (the oldest entry names the file)
+	store = Store{}
+	version = 2
+	for ip, raw := range top {
+		var probe struct {
+			Endpoints  json.RawMessage `json:"endpoints"`
+			ControlURL string          `json:"control_url"`
+		}
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			return nil, 0, fmt.Errorf("entry %s: %w", ip, err)
+		}
+
+		if probe.Endpoints == nil && probe.ControlURL != "" {
+			var d Device
+			if err := json.Unmarshal(raw, &d); err != nil {
+				return nil, 0, fmt.Errorf("entry %s: %w", ip, err)
+			}
+			store[ip] = fromLegacy(d, seen)
+			version = 1
+			continue
+		}
+
+		var cd CachedDevice
+		if err := json.Unmarshal(raw, &cd); err != nil {
+			return nil, 0, fmt.Errorf("entry %s: %w", ip, err)
+		}
+		if cd.Endpoints == nil {
+			cd.Endpoints = map[string]*Endpoint{}
+		}
+		store[ip] = &cd
+	}
+
+	// version 2 -> 3: IP keys become UDN keys
+	store.migrate()
+	return store, version, nil
+}
+
+// fromLegacy turns a version 1 entry into a device with one endpoint.
+func fromLegacy(d Device, seen time.Time) *CachedDevice {
+	return &CachedDevice{
+		Vendor:   d.Vendor,
+		Identity: d.Identity,
+		Endpoints: map[string]*Endpoint{
+			d.ControlURL: {
+				ControlURL: d.ControlURL,
+				ConnMgrURL: d.ConnMgrURL,
+				Actions:    d.Actions,
+				Media:      d.Media,
+				SeenAt:     seen,
+			},
+		},
+	}
+}
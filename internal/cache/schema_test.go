@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ---- devices.json fixtures (testdata/) ----
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func playable(url, seen string) map[string]*Endpoint {
+	return map[string]*Endpoint{url: {ControlURL: url, Actions: map[string]bool{"Play": true}, SeenAt: at(seen)}}
+}
+
+const (
+	livingURL20 = "http://192.168.1.20:9197/dmr/upnp/control/AVTransport1"
+	livingURL42 = "http://192.168.1.42:9197/dmr/upnp/control/AVTransport1"
+	kitchenURL  = "http://192.168.1.30:49152/upnp/control/AVTransport"
+)
+
+func livingIdentity() map[string]any {
+	return map[string]any{"udn": "uuid:living", "friendly_name": "Living Room TV"}
+}
+
+func TestDecodeStore(t *testing.T) {
+	seen := at("2024-06-01T08:00:00Z")
+	lgCtrl := "http://192.168.1.20:1400/AVTransport/ctrl"
+
+	cases := []struct {
+		fixture     string
+		wantVersion int
+		want        Store
+		wantErr     error // errNewerVersion, or errCorrupt for any other failure
+	}{
+		{
+			fixture:     "v1_legacy.json",
+			wantVersion: 1,
+			want: Store{
+				"uuid:lg-oled": {
+					IP:       "192.168.1.20",
+					Vendor:   "lg",
+					Identity: map[string]any{"udn": "uuid:lg-oled", "friendly_name": "LG OLED"},
+					Endpoints: map[string]*Endpoint{lgCtrl: {
+						ControlURL: lgCtrl,
+						ConnMgrURL: "http://192.168.1.20:1400/ConnectionManager/ctrl",
+						Actions:    map[string]bool{"SetAVTransportURI": true, "Play": true},
+						Media:      map[string][]string{"video/mp4": {"http-get:*:video/mp4:*"}},
+						SeenAt:     seen,
+					}},
+				},
+				"ip:192.168.1.30": {
+					IP:        "192.168.1.30",
+					Vendor:    "generic",
+					Endpoints: map[string]*Endpoint{kitchenURL: {ControlURL: kitchenURL, SeenAt: seen}},
+				},
+			},
+		},
+		{
+			// one TV under two IPs: the newer entry wins, the alias carries over
+			fixture:     "v2_ip_keyed.json",
+			wantVersion: 2,
+			want: Store{
+				"uuid:living": {
+					IP:        "192.168.1.42",
+					Vendor:    "samsung",
+					Identity:  livingIdentity(),
+					Aliases:   []string{"living"},
+					Endpoints: playable(livingURL42, "2025-03-01T10:00:00Z"),
+					Behavior:  &Behavior{SendsHEAD: true, UpdatedAt: at("2025-03-01T10:00:00Z")},
+				},
+				"ip:192.168.1.30": {
+					IP:        "192.168.1.30",
+					Vendor:    "generic",
+					Endpoints: playable(kitchenURL, "2025-02-01T10:00:00Z"),
+				},
+			},
+		},
+		{
+			// bare IP keys next to UDN and ip: keys: merged into them
+			fixture:     "mixed_ip_keys.json",
+			wantVersion: 2,
+			want: Store{
+				"uuid:living": {
+					IP:        "192.168.1.42",
+					Vendor:    "samsung",
+					Identity:  livingIdentity(),
+					Aliases:   []string{"living"},
+					Endpoints: playable(livingURL42, "2025-03-01T10:00:00Z"),
+				},
+				"ip:192.168.1.30": {
+					IP:        "192.168.1.30",
+					Vendor:    "generic",
+					Aliases:   []string{"kitchen"},
+					Endpoints: playable("http://192.168.1.30:8080/AVTransport/control", "2025-04-01T10:00:00Z"),
+					Behavior:  &Behavior{SendsRange: true, UpdatedAt: at("2025-04-01T10:00:00Z")},
+				},
+			},
+		},
+		{
+			fixture:     "v3.json",
+			wantVersion: 3,
+			want: Store{
+				"uuid:living": {
+					IP:        "192.168.1.42",
+					Vendor:    "samsung",
+					Identity:  livingIdentity(),
+					Aliases:   []string{"living"},
+					Endpoints: playable(livingURL42, "2025-03-01T10:00:00Z"),
+				},
+				"ip:192.168.1.30": {
+					IP:        "192.168.1.30",
+					Vendor:    "generic",
+					Endpoints: map[string]*Endpoint{},
+				},
+			},
+		},
+		{fixture: "newer_version.json", wantErr: errNewerVersion},
+		{fixture: "truncated.json", wantErr: errCorrupt},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			store, version, err := decodeStore(fixture(t, tc.fixture), seen)
+			switch {
+			case tc.wantErr == errNewerVersion:
+				if !errors.Is(err, errNewerVersion) {
+					t.Fatalf("err = %v, want %v", err, errNewerVersion)
+				}
+				return
+			case tc.wantErr != nil:
+				if err == nil || errors.Is(err, errNewerVersion) {
+					t.Fatalf("err = %v, want a decode error", err)
+				}
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+
+			if version != tc.wantVersion {
+				t.Errorf("version %d, want %d", version, tc.wantVersion)
+			}
+			if !reflect.DeepEqual(store, tc.want) {
+				for k, cd := range store {
+					t.Logf("got  %s: %+v", k, *cd)
+				}
+				for k, cd := range tc.want {
+					t.Logf("want %s: %+v", k, *cd)
+				}
+				t.Fatal("decoded store differs")
+			}
+		})
+	}
+}
+
+func TestMigrateMergesDuplicates(t *testing.T) {
+	store := Store{
+		"192.168.1.20": {Identity: livingIdentity(), Aliases: []string{"living"}, Endpoints: playable(livingURL20, "2025-01-01T10:00:00Z")},
+		"192.168.1.42": {Identity: livingIdentity(), Aliases: []string{"tv"}, Endpoints: playable(livingURL42, "2025-03-01T10:00:00Z")},
+	}
+	if !store.migrate() {
+		t.Fatal("migrate reported no change")
+	}
+	if len(store) != 1 {
+		t.Fatalf("%d entries, want 1", len(store))
+	}
+	cd := store["uuid:living"]
+	if cd == nil || cd.IP != "192.168.1.42" || !reflect.DeepEqual(cd.Aliases, []string{"tv", "living"}) {
+		t.Fatalf("got %+v", cd)
+	}
+	if store.migrate() {
+		t.Fatal("second migrate reported a change")
+	}
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+======== KEYS, ADDRESSES AND MIGRATION ========
+*/
+
+// ipKeyPrefix keys devices whose UDN is not known (yet).
+const ipKeyPrefix = "ip:"
+
+// keyFor is the store key for a device: its UDN, or its IP until the
+// UDN is known.
+func keyFor(udn, ip string) string {
+	if udn != "" {
+		return udn
+	}
+	return ipKeyPrefix + ip
+}
+
+// UDN returns the device's UDN from its identity ("" when unknown).
+func (cd *CachedDevice) UDN() string {
+	return identityString(cd, "udn")
+}
+
+// ByIP returns the device currently recorded at ip. Should two entries
+// claim it (one of them stale), the most recently seen wins.
+func (s Store) ByIP(ip string) (string, *CachedDevice, bool) {
+	var (
+		key  string
+		best *CachedDevice
+	)
+	for k, cd := range s {
+		if cd.IP != ip {
+			continue
+		}
+		if best == nil || cd.lastSeen().After(best.lastSeen()) {
+			key, best = k, cd
+		}
+	}
+	return key, best, best != nil
+}
+
+// Primary is the endpoint playback uses: the first ControlURL, in sort
+// order, that has known actions (nil when none has).
+func (cd *CachedDevice) Primary() *Endpoint {
+	var urls []string
+	for u, ep := range cd.Endpoints {
+		if len(ep.Actions) > 0 {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	sort.Strings(urls)
+	return cd.Endpoints[urls[0]]
+}
+
+func (cd *CachedDevice) lastSeen() time.Time {
+	var t time.Time
+	for _, ep := range cd.Endpoints {
+		if ep.SeenAt.After(t) {
+			t = ep.SeenAt
+		}
+	}
+	return t
+}
+
+// migrate re-keys entries from files written before UDN keys (keyed by
+// IP, no ip field). Entries of one UDN under several IPs, the duplicates
+// DHCP used to leave behind, are merged into the most recently seen.
+// Reports whether anything changed.
+func (s Store) migrate() bool {
+	var old []string
+	for k, cd := range s {
+		if cd.IP == "" && net.ParseIP(k) != nil {
+			old = append(old, k)
+		}
+	}
+	sort.Strings(old)
+
+	for _, ip := range old {
+		cd := s[ip]
+		delete(s, ip)
+		cd.IP = ip
+
+		key := keyFor(cd.UDN(), ip)
+		if have, ok := s[key]; ok {
+			if cd.lastSeen().After(have.lastSeen()) {
+				cd, have = have, cd
+			}
+			mergeInto(have, cd)
+			s[key] = have
+			continue
+		}
+		s[key] = cd
+	}
+	return len(old) > 0
+}
+
+// mergeInto keeps dst's address and endpoints and takes over what only
+// src knows (aliases, identity, learned behaviour).
+func mergeInto(dst, src *CachedDevice) {
+	for _, a := range src.Aliases {
+		if !dst.hasAlias(a) {
+			dst.Aliases = append(dst.Aliases, a)
+		}
+	}
+	if dst.Identity == nil {
+		dst.Identity = src.Identity
+	}
+	if dst.Behavior == nil {
+		dst.Behavior = src.Behavior
+	}
+	if dst.Vendor == "" {
+		dst.Vendor = src.Vendor
+	}
+}
+
+// moveTo records a new address and points every endpoint at it (ports
+// and paths are kept).
+func (cd *CachedDevice) moveTo(ip string) {
+	cd.IP = ip
+	moved := make(map[string]*Endpoint, len(cd.Endpoints))
+	for _, ep := range cd.Endpoints {
+		ep.ControlURL = rehost(ep.ControlURL, ip)
+		ep.ConnMgrURL = rehost(ep.ConnMgrURL, ip)
+		moved[ep.ControlURL] = ep
+	}
+	cd.Endpoints = moved
+}
+
+func rehost(raw, ip string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(ip, port)
+	} else {
+		u.Host = ip
+	}
+	return u.String()
+}
+
+func isIPKey(k string) bool {
+	return strings.HasPrefix(k, ipKeyPrefix)
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File is a parsed config file. Keys are flag names (Lip, ssdp-timeout,
+// vendor, ...) and values their command-line spelling.
+type File struct {
+	Path     string
+	Defaults map[string]string
+	Profiles map[string]map[string]string
+}
+
+// Path returns ~/.renderctl/config.toml, or config.json when only that exists.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".renderctl")
+
+	toml := filepath.Join(dir, "config.toml")
+	if _, err := os.Stat(toml); err != nil {
+		js := filepath.Join(dir, "config.json")
+		if _, err := os.Stat(js); err == nil {
+			return js, nil
+		}
+	}
+	return toml, nil
+}
+
+// Load reads a .json file as JSON and anything else as TOML.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{Path: path}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = f.parseJSON(data)
+	} else {
+		err = f.parseTOML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Values merges the defaults with the named profile ("" = defaults only).
+func (f *File) Values(profile string) (map[string]string, error) {
+	out := map[string]string{}
+	for k, v := range f.Defaults {
+		out[k] = v
+	}
+	if profile == "" {
+		return out, nil
+	}
+
+	p, ok := f.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (have: %s)", profile, f.profileNames())
+	}
+	for k, v := range p {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (f *File) profileNames() string {
+	if len(f.Profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(f.Profiles))
+	for n := range f.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ---- JSON ----
+//
+// {"Lip": "192.168.1.10", "profiles": {"livingroom": {"Tip": "192.168.1.20"}}}
+
+func (f *File) parseJSON(data []byte) error {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	f.Defaults = map[string]string{}
+	f.Profiles = map[string]map[string]string{}
+
+	for k, v := range raw {
+		if k != "profiles" {
+			s, err := jsonValue(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			f.Defaults[k] = s
+			continue
+		}
+
+		profiles, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("profiles must be an object of objects")
+		}
+		for name, pv := range profiles {
+			entries, ok := pv.(map[string]any)
+			if !ok {
+				return fmt.Errorf("profile %q must be an object", name)
+			}
+			p := map[string]string{}
+			for pk, pval := range entries {
+				s, err := jsonValue(pval)
+				if err != nil {
+					return fmt.Errorf("profiles.%s.%s: %w", name, pk, err)
+				}
+				p[pk] = s
+			}
+			f.Profiles[name] = p
+		}
+	}
+	return nil
+}
+
+func jsonValue(v any) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, 0, len(x))
+		for _, e := range x {
+			s, err := jsonValue(e)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v (use a string, number, bool or list)", v)
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ---- TOML (the subset a flag file needs) ----
+//
+//	Lip = "192.168.1.10"
+//	ssdp-timeout = "20s"
+//	allow = ["192.168.1.0/24"]
+//
+//	[profiles.livingroom]
+//	Tip = "192.168.1.20"
+//	vendor = "lg"
+//
+// Strings, numbers, booleans and (possibly multi-line) arrays of those;
+// top-level keys are the defaults, [profiles.<name>] tables the profiles.
+
+func (f *File) parseTOML(data []byte) error {
+	f.Defaults = map[string]string{}
+	f.Profiles = map[string]map[string]string{}
+
+	table := f.Defaults
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name, err := profileHeader(line)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			if _, dup := f.Profiles[name]; dup {
+				return fmt.Errorf("line %d: profile %q defined twice", n, name)
+			}
+			table = map[string]string{}
+			f.Profiles[name] = table
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", n)
+		}
+		key, err := unquoteKey(strings.TrimSpace(key))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		val = strings.TrimSpace(val)
+
+		// arrays may span lines until the closing bracket
+		for strings.HasPrefix(val, "[") && !closedArray(val) && i+1 < len(lines) {
+			i++
+			val += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		s, err := tomlValue(val)
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		if _, dup := table[key]; dup {
+			return fmt.Errorf("line %d: %s set twice", n, key)
+		}
+		table[key] = s
+	}
+	return nil
+}
+
+// profileHeader accepts [profiles.name] and [profiles."name with spaces"].
+func profileHeader(line string) (string, error) {
+	if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+		return "", fmt.Errorf("invalid table header %s", line)
+	}
+	inner := strings.TrimSpace(line[1 : len(line)-1])
+	name, ok := strings.CutPrefix(inner, "profiles.")
+	if !ok {
+		return "", fmt.Errorf("unknown table %s (only [profiles.<name>] is supported)", line)
+	}
+	return unquoteKey(strings.TrimSpace(name))
+}
+
+func unquoteKey(k string) (string, error) {
+	if strings.HasPrefix(k, `"`) || strings.HasPrefix(k, "'") {
+		return tomlString(k)
+	}
+	if k == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	for _, r := range k {
+		if !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return "", fmt.Errorf("invalid key %q", k)
+		}
+	}
+	return k, nil
+}
+
+func tomlValue(v string) (string, error) {
+	switch {
+	case v == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(v, "["):
+		return tomlArray(v)
+	case strings.HasPrefix(v, `"`), strings.HasPrefix(v, "'"):
+		return tomlString(v)
+	case v == "true", v == "false":
+		return v, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(v, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(v, "_", ""), nil
+	}
+	return "", fmt.Errorf("unsupported value %s (quote strings)", v)
+}
+
+func tomlString(v string) (string, error) {
+	if len(v) < 2 || v[len(v)-1] != v[0] {
+		return "", fmt.Errorf("unterminated string %s", v)
+	}
+	if v[0] == '\'' {
+		// literal string: no escapes
+		return v[1 : len(v)-1], nil
+	}
+	s, err := unescape(v[1 : len(v)-1])
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s: %w", v, err)
+	}
+	return s, nil
+}
+
+// unescape decodes a basic string body. Only TOML's escapes are valid;
+// Go-only ones such as \x41 or \a are rejected, not silently decoded.
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return "", fmt.Errorf("unescaped quote")
+		case c < 0x20 && c != '\t', c == 0x7f:
+			return "", fmt.Errorf("control character %q", c)
+		case c != '\\':
+			b.WriteByte(c)
+			continue
+		}
+
+		if i++; i == len(s) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+		switch e := s[i]; e {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(e)
+		case 'u', 'U':
+			n := 4
+			if e == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", fmt.Errorf("short \\%c escape", e)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", fmt.Errorf("invalid \\%c escape %s", e, s[i+1:i+1+n])
+			}
+			b.WriteRune(rune(r))
+			i += n
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", e)
+		}
+	}
+	return b.String(), nil
+}
+
+func tomlArray(v string) (string, error) {
+	if !closedArray(v) || !strings.HasSuffix(v, "]") {
+		return "", fmt.Errorf("unterminated array %s", v)
+	}
+	var parts []string
+	for _, e := range splitArray(v[1 : len(v)-1]) {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue // trailing comma
+		}
+		if strings.HasPrefix(e, "[") {
+			return "", fmt.Errorf("nested arrays are not supported")
+		}
+		s, err := tomlValue(e)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// splitArray splits on commas outside quotes.
+func splitArray(s string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// closedArray reports whether the brackets outside quotes balance.
+func closedArray(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+// stripComment drops a # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		defaults map[string]string
+		profiles map[string]map[string]string
+		wantErr  string
+	}{
+		{
+			name: "scalars",
+			in: `Lip = "192.168.1.10"   # this machine
+ssdp-timeout = '20s'
+retries = 1_000
+debug = true`,
+			defaults: map[string]string{"Lip": "192.168.1.10", "ssdp-timeout": "20s", "retries": "1000", "debug": "true"},
+		},
+		{
+			name: "multi-line array",
+			in: `allow = [
+  "192.168.1.0/24",   # the LAN
+  "10.0.0.5",
+]
+Lip = "192.168.1.10"`,
+			defaults: map[string]string{"allow": "192.168.1.0/24,10.0.0.5", "Lip": "192.168.1.10"},
+		},
+		{
+			name:     "comment characters inside strings",
+			in:       `name = "Kitchen #2" # trailing` + "\n" + `title = 'a # b'` + "\n" + `list = ["x#y", 'z]#']`,
+			defaults: map[string]string{"name": "Kitchen #2", "title": "a # b", "list": "x#y,z]#"},
+		},
+		{
+			name: "profiles",
+			in: `vendor = "generic"
+
+[profiles.livingroom]
+Tip = "192.168.1.20"
+
+[ profiles."Bed room" ]
+Tip = "192.168.1.30"
+"quoted key" = "v"`,
+			defaults: map[string]string{"vendor": "generic"},
+			profiles: map[string]map[string]string{
+				"livingroom": {"Tip": "192.168.1.20"},
+				"Bed room":   {"Tip": "192.168.1.30", "quoted key": "v"},
+			},
+		},
+		{
+			name:     "escapes",
+			in:       `s = "tab\there \"quoted\" \\ caf\u00e9 \U0001F3B5"`,
+			defaults: map[string]string{"s": "tab\there \"quoted\" \\ café 🎵"},
+		},
+		{
+			name:     "literal string keeps backslashes",
+			in:       `path = 'C:\media\x41'`,
+			defaults: map[string]string{"path": `C:\media\x41`},
+		},
+		{name: "duplicate key", in: "a = 1\na = 2", wantErr: "line 2: a set twice"},
+		{name: "duplicate key in profile", in: "[profiles.x]\na = 1\na = 2", wantErr: "line 3: a set twice"},
+		{name: "duplicate profile", in: "[profiles.x]\n[profiles.\"x\"]", wantErr: `line 2: profile "x" defined twice`},
+		{name: "unknown table", in: "[servers.x]", wantErr: "unknown table"},
+		{name: "array of tables", in: "[[profiles.x]]", wantErr: "invalid table header"},
+		{name: "bare string", in: "a = hello", wantErr: "quote strings"},
+		{name: "unterminated string", in: `a = "abc`, wantErr: "unterminated string"},
+		{name: "unterminated array", in: "a = [\n1,\n2", wantErr: "unterminated array"},
+		{name: "nested array", in: "a = [[1]]", wantErr: "nested arrays"},
+		{name: "go hex escape", in: `a = "\x41"`, wantErr: `invalid escape \x`},
+		{name: "go bell escape", in: `a = "\a"`, wantErr: `invalid escape \a`},
+		{name: "short unicode escape", in: `a = "\u00e"`, wantErr: `short \u escape`},
+		{name: "surrogate escape", in: `a = "\ud800"`, wantErr: `invalid \u escape`},
+		{name: "two strings", in: `a = "x" "y"`, wantErr: "unescaped quote"},
+		{name: "invalid key", in: "a.b = 1", wantErr: "invalid key"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &File{}
+			err := f.parseTOML([]byte(tc.in))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.profiles == nil {
+				tc.profiles = map[string]map[string]string{}
+			}
+			if !reflect.DeepEqual(f.Defaults, tc.defaults) {
+				t.Fatalf("defaults %v, want %v", f.Defaults, tc.defaults)
+			}
+			if !reflect.DeepEqual(f.Profiles, tc.profiles) {
+				t.Fatalf("profiles %v, want %v", f.Profiles, tc.profiles)
+			}
+		})
+	}
+}
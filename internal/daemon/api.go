@@ -0,0 +1,368 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"renderctl/internal/cache"
+	"renderctl/pkg/renderctl"
+)
+
+// ---- REST API (localhost only) ----
+
+// Request is the JSON body of every POST.
+type Request struct {
+	Device   string `json:"device,omitempty"`   // index, alias, name, UDN or IP ("" = the only cached device)
+	Source   string `json:"source,omitempty"`   // file path or URL
+	Title    string `json:"title,omitempty"`    // optional display title
+	Position string `json:"position,omitempty"` // seek target: 90s, 1m30s, 0:01:30
+	Level    *int   `json:"level,omitempty"`    // volume 0-100
+}
+
+// DeviceInfo is one cached renderer in GET /devices.
+type DeviceInfo struct {
+	Index      int    `json:"index"`
+	UDN        string `json:"udn,omitempty"`
+	IP         string `json:"ip"`
+	Name       string `json:"name,omitempty"`
+	Vendor     string `json:"vendor,omitempty"`
+	ControlURL string `json:"control_url"`
+}
+
+func (d *Daemon) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /devices", d.handleDevices)
+	mux.HandleFunc("POST /devices/discover", d.handleDiscover)
+
+	mux.HandleFunc("POST /play", d.handlePlay)
+	mux.HandleFunc("POST /pause", d.transport((*renderctl.Renderer).Pause, false))
+	mux.HandleFunc("POST /resume", d.transport((*renderctl.Renderer).Resume, false))
+	mux.HandleFunc("POST /stop", d.transport((*renderctl.Renderer).Stop, true))
+	mux.HandleFunc("POST /seek", d.handleSeek)
+
+	mux.HandleFunc("GET /volume", d.handleGetVolume)
+	mux.HandleFunc("POST /volume", d.handleSetVolume)
+
+	mux.HandleFunc("GET /queue", d.handleQueue)
+	mux.HandleFunc("POST /queue", d.handleEnqueue)
+	mux.HandleFunc("POST /queue/next", d.handleNext)
+	mux.HandleFunc("DELETE /queue", d.handleClearQueue)
+
+	mux.HandleFunc("GET /status", d.handleStatus)
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// decode reads the POST body; GET/DELETE take ?device= instead.
+func decode(r *http.Request) (Request, error) {
+	var req Request
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, errors.New("invalid JSON body: " + err.Error())
+		}
+	}
+	if req.Device == "" {
+		req.Device = r.URL.Query().Get("device")
+	}
+	return req, nil
+}
+
+// snapshot copies a session for JSON output. Caller holds d.mu.
+func (s *session) snapshot() session {
+	c := *s
+	c.Queue = append([]Item{}, s.Queue...)
+	if s.Current != nil {
+		cur := *s.Current
+		c.Current = &cur
+	}
+	return c
+}
+
+// ---- HANDLERS ----
+
+func (d *Daemon) handleDevices(w http.ResponseWriter, _ *http.Request) {
+	entries, err := cache.Entries()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	out := []DeviceInfo{}
+	for _, e := range entries {
+		name, _ := e.Device.Identity["friendly_name"].(string)
+		out = append(out, DeviceInfo{
+			Index:      e.Index,
+			UDN:        e.Device.UDN,
+			IP:         e.IP,
+			Name:       name,
+			Vendor:     e.Device.Vendor,
+			ControlURL: e.Device.ControlURL,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (d *Daemon) handleDiscover(w http.ResponseWriter, _ *http.Request) {
+	go d.discover()
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"status": "discovery started (see GET /devices)",
+	})
+}
+
+func (d *Daemon) handlePlay(w http.ResponseWriter, r *http.Request) {
+	req, err := decode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Source == "" {
+		writeError(w, http.StatusBadRequest, errNoSource)
+		return
+	}
+
+	d.mu.Lock()
+	s, err := d.session(req.Device)
+	d.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err := d.play(s, Item{Source: req.Source, Title: req.Title}); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	d.writeSession(w, s)
+}
+
+// transport wraps a bare AVTransport action (pause, resume, stop).
+func (d *Daemon) transport(fn func(*renderctl.Renderer) error, userStop bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := decode(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		d.act(w, req.Device, fn, userStop)
+	}
+}
+
+// act runs fn on the device and answers with its session.
+func (d *Daemon) act(w http.ResponseWriter, device string, fn func(*renderctl.Renderer) error, userStop bool) {
+	s, err := d.control(device, fn, userStop)
+	if s == nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	d.writeSession(w, s)
+}
+
+func (d *Daemon) handleSeek(w http.ResponseWriter, r *http.Request) {
+	req, err := decode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pos, err := renderctl.ParsePosition(req.Position)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	d.act(w, req.Device, func(r *renderctl.Renderer) error { return r.Seek(pos) }, false)
+}
+
+func (d *Daemon) handleGetVolume(w http.ResponseWriter, r *http.Request) {
+	req, _ := decode(r)
+
+	var level int
+	s, err := d.control(req.Device, func(r *renderctl.Renderer) (err error) {
+		level, err = r.Volume()
+		return err
+	}, false)
+	if s == nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ip": s.IP, "volume": level})
+}
+
+func (d *Daemon) handleSetVolume(w http.ResponseWriter, r *http.Request) {
+	req, err := decode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Level == nil {
+		writeError(w, http.StatusBadRequest, errors.New("level required (0-100)"))
+		return
+	}
+	level := *req.Level
+
+	s, err := d.control(req.Device, func(r *renderctl.Renderer) error { return r.SetVolume(level) }, false)
+	if s == nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ip": s.IP, "volume": level})
+}
+
+func (d *Daemon) handleQueue(w http.ResponseWriter, r *http.Request) {
+	req, _ := decode(r)
+
+	d.mu.Lock()
+	s, err := d.session(req.Device)
+	d.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	d.writeSession(w, s)
+}
+
+// handleEnqueue appends to the queue, and starts it when nothing plays.
+func (d *Daemon) handleEnqueue(w http.ResponseWriter, r *http.Request) {
+	req, err := decode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Source == "" {
+		writeError(w, http.StatusBadRequest, errNoSource)
+		return
+	}
+
+	d.mu.Lock()
+	s, err := d.session(req.Device)
+	if err == nil {
+		s.Queue = append(s.Queue, Item{Source: req.Source, Title: req.Title})
+	}
+	idle := err == nil && s.Current == nil
+	d.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if idle {
+		if _, err := d.next(s); err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+	}
+	d.writeSession(w, s)
+}
+
+func (d *Daemon) handleNext(w http.ResponseWriter, r *http.Request) {
+	req, err := decode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	d.mu.Lock()
+	s, err := d.session(req.Device)
+	d.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	ok, err := d.next(s)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if !ok {
+		_, _ = d.control(req.Device, (*renderctl.Renderer).Stop, true)
+	}
+	d.writeSession(w, s)
+}
+
+func (d *Daemon) handleClearQueue(w http.ResponseWriter, r *http.Request) {
+	req, _ := decode(r)
+
+	d.mu.Lock()
+	s, err := d.session(req.Device)
+	if err == nil {
+		s.Queue = nil
+	}
+	d.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	d.writeSession(w, s)
+}
+
+// statusSession adds live transport info to a session.
+type statusSession struct {
+	session
+	Playback *renderctl.Status `json:"playback,omitempty"`
+}
+
+func (d *Daemon) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	d.mu.Lock()
+	var list []session
+	for _, s := range d.sessions {
+		list = append(list, s.snapshot())
+	}
+	discovering := d.discovering
+	d.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].IP < list[j].IP })
+
+	out := make([]statusSession, 0, len(list))
+	for _, s := range list {
+		ss := statusSession{session: s}
+		if s.Current != nil {
+			if st, err := s.renderer.Status(); err == nil {
+				ss.Playback = &st
+			}
+		}
+		out = append(out, ss)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"started":      d.started,
+		"uptime":       time.Since(d.started).Round(time.Second).String(),
+		"media_server": d.media.URL(),
+		"discovering":  discovering,
+		"sessions":     out,
+	})
+}
+
+func (d *Daemon) writeSession(w http.ResponseWriter, s *session) {
+	d.mu.Lock()
+	snap := s.snapshot()
+	d.mu.Unlock()
+	writeJSON(w, http.StatusOK, snap)
+}
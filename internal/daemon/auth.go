@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ---- API ACCESS ----
+//
+// Listening on loopback keeps other machines out, not web pages: a
+// browser on this machine can POST to the API (CSRF) or rebind its own
+// host name to 127.0.0.1 and read the answers. So every request must
+// name a loopback Host, carry this run's token, and send its body as
+// application/json (which a cross-site form cannot).
+
+// TokenHeader carries the per-run API token.
+const TokenHeader = "X-Renderctl-Token"
+
+// TokenPath is where a daemon listening on addr keeps its token:
+// ~/.renderctl/daemon-<port>.token, readable by this user only.
+func TokenPath(addr string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".renderctl", "daemon-"+port+".token"), nil
+}
+
+// ReadToken returns the token of the daemon listening on addr.
+func ReadToken(addr string) (string, error) {
+	path, err := TokenPath(addr)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// writeToken creates a fresh token for this run and stores it at path.
+func writeToken(path string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	tok := hex.EncodeToString(b)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(tok+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return tok, nil
+}
+
+// IsLoopback reports whether host (with or without a port) is
+// localhost or a loopback IP.
+func IsLoopback(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// guard applies the rules above before any handler runs.
+func (d *Daemon) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsLoopback(r.Host) {
+			writeError(w, http.StatusForbidden, errors.New("host not allowed: "+r.Host))
+			return
+		}
+
+		tok := r.Header.Get(TokenHeader)
+		if subtle.ConstantTimeCompare([]byte(tok), []byte(d.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong "+TokenHeader+" (the daemon writes it to ~/.renderctl at startup)"))
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mt != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("content type must be application/json"))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
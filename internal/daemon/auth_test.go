@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"renderctl/internal/models"
+)
+
+func TestGuard(t *testing.T) {
+	d := &Daemon{token: "secret"}
+	h := d.guard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		name   string
+		method string
+		host   string
+		token  string
+		ctype  string
+		want   int
+	}{
+		{"get", "GET", "127.0.0.1:8765", "secret", "", http.StatusNoContent},
+		{"localhost name", "GET", "localhost:8765", "secret", "", http.StatusNoContent},
+		{"ipv6 loopback", "GET", "[::1]:8765", "secret", "", http.StatusNoContent},
+		{"post json", "POST", "127.0.0.1:8765", "secret", "application/json; charset=utf-8", http.StatusNoContent},
+		{"rebound host", "GET", "evil.example:8765", "secret", "", http.StatusForbidden},
+		{"lan host", "POST", "192.168.1.2:8765", "secret", "application/json", http.StatusForbidden},
+		{"no token", "GET", "127.0.0.1:8765", "", "", http.StatusUnauthorized},
+		{"wrong token", "POST", "127.0.0.1:8765", "guess", "application/json", http.StatusUnauthorized},
+		{"form post", "POST", "127.0.0.1:8765", "secret", "text/plain", http.StatusUnsupportedMediaType},
+		{"no content type", "DELETE", "127.0.0.1:8765", "secret", "", http.StatusUnsupportedMediaType},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, "/queue", strings.NewReader("{}"))
+			r.Host = tc.host
+			if tc.token != "" {
+				r.Header.Set(TokenHeader, tc.token)
+			}
+			if tc.ctype != "" {
+				r.Header.Set("Content-Type", tc.ctype)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.want {
+				t.Fatalf("status %d, want %d (%s)", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRunRefusesRemoteAPI(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:8765", ":8765", "192.168.1.2:8765"} {
+		if err := Run(&models.Config{APIAddr: addr}, nil); err == nil {
+			t.Fatalf("Run accepted API address %s", addr)
+		}
+	}
+}
+
+// A second daemon on a busy port must leave the running one's token alone.
+func TestRunKeepsLiveToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	path, err := TokenPath(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := writeToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Run(&models.Config{APIAddr: addr}, nil); err == nil {
+		t.Fatal("Run succeeded on a busy port")
+	}
+	if tok, err := ReadToken(addr); err != nil || tok != live {
+		t.Fatalf("token now %q (%v), want the live daemon's", tok, err)
+	}
+}
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Client talks to a running daemon's API (renderctl --remote).
+type Client struct {
+	Addr  string // host:port
+	Token string // the daemon's per-run token
+	http  *http.Client
+}
+
+// NewClient reads the token the daemon on addr wrote at startup; a
+// missing file is reported by the daemon as an auth error.
+func NewClient(addr string) *Client {
+	// the token never goes to another machine
+	var tok string
+	if IsLoopback(addr) {
+		tok, _ = ReadToken(addr)
+	}
+	// casts wait on SOAP round trips, give them room
+	return &Client{Addr: addr, Token: tok, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Do sends req to path and decodes the JSON answer into out (may be nil).
+// GET and DELETE carry the device as a query parameter.
+func (c *Client) Do(method, path string, req Request, out any) error {
+	u := "http://" + c.Addr + path
+
+	var body *bytes.Reader
+	if method == http.MethodPost {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	} else {
+		if req.Device != "" {
+			u += "?device=" + url.QueryEscape(req.Device)
+		}
+		body = bytes.NewReader(nil)
+	}
+
+	hr, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		hr.Header.Set(TokenHeader, c.Token)
+	}
+
+	resp, err := c.http.Do(hr)
+	if err != nil {
+		return fmt.Errorf("daemon not reachable at %s: %w", c.Addr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return errors.New(resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,396 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"renderctl/internal/avtransport"
+	"renderctl/internal/cache"
+	"renderctl/internal/models"
+	"renderctl/logger"
+	"renderctl/pkg/renderctl"
+)
+
+// watchInterval is how often renderers with a current item are polled
+// to advance their queue.
+const watchInterval = 2 * time.Second
+
+// Item is one thing to play: a local file or a URL the TV fetches itself.
+type Item struct {
+	Source string `json:"source"`
+	Title  string `json:"title,omitempty"`
+}
+
+// session is the daemon's view of one renderer. Guarded by Daemon.mu.
+type session struct {
+	IP         string `json:"ip"`
+	Name       string `json:"name,omitempty"`
+	Vendor     string `json:"vendor,omitempty"`
+	ControlURL string `json:"control_url"`
+	renderer   *renderctl.Renderer
+	key        string // cache store key, follows the device across moves
+
+	Current *Item     `json:"current,omitempty"`
+	Queue   []Item    `json:"queue"`
+	State   string    `json:"state,omitempty"` // last CurrentTransportState
+	Since   time.Time `json:"since,omitzero"`  // Current started
+
+	playing  bool // saw PLAYING since the last cast
+	userStop bool // stopped through the API: do not advance
+}
+
+// Daemon keeps the media server up and drives renderers on request.
+// It is built on pkg/renderctl like any other embedder.
+type Daemon struct {
+	cfg     *models.Config
+	started time.Time
+	media   *renderctl.MediaServer
+	token   string // per-run API token, see guard
+
+	mu       sync.Mutex
+	sessions map[string]*session // key = UDN (IP when unknown)
+
+	discovering bool
+}
+
+// Run starts the media server, the API on cfg.APIAddr and the queue
+// watcher. Everything stops when stop is closed.
+func Run(cfg *models.Config, stop <-chan struct{}) error {
+	d := &Daemon{
+		cfg:      cfg,
+		started:  time.Now(),
+		sessions: map[string]*session{},
+	}
+
+	// whatever set it, the API never leaves this machine
+	if !IsLoopback(cfg.APIAddr) {
+		return fmt.Errorf("API address %s is not a localhost address", cfg.APIAddr)
+	}
+
+	tokenPath, err := TokenPath(cfg.APIAddr)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", cfg.APIAddr)
+	if err != nil {
+		return err
+	}
+
+	// the API only drives the TVs; media goes through its own server
+	d.media, err = renderctl.NewMediaServer(renderctl.MediaServerOptions{
+		Addr:  "0.0.0.0:" + cfg.ServePort,
+		Host:  cfg.LIP,
+		Allow: cfg.Allow,
+		Logf:  logger.Info,
+	})
+	if err != nil {
+		ln.Close()
+		return err
+	}
+
+	// only once the port is ours: a second daemon failing to start
+	// must not replace (or remove) the running one's token
+	if d.token, err = writeToken(tokenPath); err != nil {
+		ln.Close()
+		_ = d.media.Close()
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           d.guard(d.routes()),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logger.Notify("Daemon API error: %v", err)
+		}
+	}()
+	go d.watch(stop)
+
+	if cfg.Discover {
+		go d.discover()
+	}
+
+	go func() {
+		<-stop
+		logger.Notify("Shutting down daemon API")
+		_ = srv.Close()
+		_ = d.media.Close()
+		_ = os.Remove(tokenPath)
+	}()
+
+	logger.Success("Media server listening: %s (published media only)", d.media.URL())
+	logger.Success("Daemon API listening: http://%s", cfg.APIAddr)
+	return nil
+}
+
+// ---- DEVICES ----
+
+// resolve finds a cached renderer by index, alias, name, UDN or IP and
+// returns its cache key. An empty ref picks the only cached device.
+func resolve(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		store, _ := cache.Load()
+		if len(store) != 1 {
+			return "", fmt.Errorf("device required (%d cached devices)", len(store))
+		}
+		ref = "0"
+	}
+	return cache.Resolve(ref)
+}
+
+// session returns (creating) the session for ref. Caller holds d.mu.
+func (d *Daemon) session(ref string) (*session, error) {
+	key, err := resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return d.sessionFor(key)
+}
+
+// sessionFor returns (creating) the session of the device stored under
+// key, refreshed from the cache. Caller holds d.mu.
+func (d *Daemon) sessionFor(key string) (*session, error) {
+	ip, dev, ok := cache.Select(key)
+	if !ok {
+		return nil, fmt.Errorf("cached device %s has no playable endpoint", key)
+	}
+
+	id := dev.UDN
+	if id == "" {
+		id = ip
+	}
+	s, ok := d.sessions[id]
+	if !ok {
+		s = &session{}
+		d.sessions[id] = s
+	}
+	// refresh from the cache, it may have been re-enriched or moved
+	s.key = key
+	s.IP = ip
+	s.Vendor = dev.Vendor
+	s.ControlURL = dev.ControlURL
+	s.renderer = &renderctl.Renderer{
+		ControlURL: dev.ControlURL,
+		ConnMgrURL: dev.ConnMgrURL,
+		Vendor:     dev.Vendor,
+		Logf:       logger.Info,
+	}
+	if name, _ := dev.Identity["friendly_name"].(string); name != "" {
+		s.Name = name
+	}
+	return s, nil
+}
+
+func (d *Daemon) discover() {
+	d.mu.Lock()
+	if d.discovering {
+		d.mu.Unlock()
+		return
+	}
+	d.discovering = true
+	d.mu.Unlock()
+
+	local := *d.cfg
+	local.AutoCache = true
+
+	disc := renderctl.Discoverer{
+		Timeout: local.SSDPTimeout,
+		LocalIP: local.LIP,
+		Logf:    logger.Info,
+	}
+	devices, err := disc.Discover()
+	if err != nil {
+		logger.Notify("SSDP discovery failed: %v", err)
+	}
+	found := make([]avtransport.Found, 0, len(devices))
+	for _, dev := range devices {
+		found = append(found, avtransport.Found{
+			UDN:        dev.UDN,
+			IP:         dev.IP,
+			Location:   dev.Location,
+			ControlURL: dev.ControlURL,
+			ConnMgrURL: dev.ConnMgrURL,
+			SCPDURL:    dev.SCPDURL,
+			Vendor:     dev.Vendor,
+		})
+	}
+	avtransport.CacheFound(&local, found)
+
+	d.mu.Lock()
+	d.discovering = false
+	d.mu.Unlock()
+}
+
+// relocate follows s to a new address after err, when err says the
+// renderer is unreachable (not a SOAP fault). Reports whether s moved.
+// Caller must NOT hold d.mu.
+func (d *Daemon) relocate(s *session, err error) bool {
+	var fault *renderctl.UPnPError
+	if err == nil || errors.As(err, &fault) {
+		return false
+	}
+
+	d.mu.Lock()
+	key := s.key
+	d.mu.Unlock()
+
+	if _, ok := cache.RelocateKey(key); !ok {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = d.sessionFor(key)
+	return err == nil
+}
+
+// ---- PLAYBACK ----
+
+// play casts it on s right away, once more after following the renderer
+// to a new address. Caller must NOT hold d.mu (SOAP is slow).
+func (d *Daemon) play(s *session, it Item) error {
+	err := d.cast(s, it)
+	if d.relocate(s, err) {
+		err = d.cast(s, it)
+	}
+	return err
+}
+
+func (d *Daemon) cast(s *session, it Item) error {
+	d.mu.Lock()
+	ip, r := s.IP, s.renderer
+	d.mu.Unlock()
+
+	m := renderctl.Media{URL: it.Source}
+	if isURL(it.Source) {
+		d.media.Allow(r.ControlURL)
+	} else {
+		var err error
+		if m, err = d.media.Media(it.Source, r); err != nil {
+			return err
+		}
+	}
+	if it.Title != "" {
+		m.Title = it.Title
+	}
+
+	logger.Notify("Casting %s on %s", it.Source, ip)
+	if err := r.Play(m); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	cur := it
+	s.Current = &cur
+	s.Since = time.Now()
+	s.playing = false
+	s.userStop = false
+	d.mu.Unlock()
+	return nil
+}
+
+// next pops the queue onto s. Returns false when the queue is empty.
+func (d *Daemon) next(s *session) (bool, error) {
+	d.mu.Lock()
+	if len(s.Queue) == 0 {
+		s.Current = nil
+		d.mu.Unlock()
+		return false, nil
+	}
+	it := s.Queue[0]
+	s.Queue = s.Queue[1:]
+	d.mu.Unlock()
+
+	return true, d.play(s, it)
+}
+
+// watch advances queues when a renderer finishes its current item.
+func (d *Daemon) watch(stop <-chan struct{}) {
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+		}
+
+		d.mu.Lock()
+		active := map[*session]*renderctl.Renderer{}
+		for _, s := range d.sessions {
+			if s.Current != nil {
+				active[s] = s.renderer
+			}
+		}
+		d.mu.Unlock()
+
+		for s, r := range active {
+			st, _ := r.Status()
+			state := st.State
+
+			d.mu.Lock()
+			s.State = state
+			if state == "PLAYING" {
+				s.playing = true
+			}
+			finished := s.Current != nil && s.playing && !s.userStop &&
+				(state == "STOPPED" || state == "NO_MEDIA_PRESENT")
+			var src string
+			if finished {
+				src = s.Current.Source
+			}
+			d.mu.Unlock()
+
+			if !finished {
+				continue
+			}
+			logger.Info("%s finished on %s", src, s.IP)
+			if _, err := d.next(s); err != nil {
+				logger.Notify("Queue advance failed on %s: %v", s.IP, err)
+			}
+		}
+	}
+}
+
+// control runs a renderer action on the device and records user stops.
+func (d *Daemon) control(ref string, fn func(*renderctl.Renderer) error, userStop bool) (*session, error) {
+	d.mu.Lock()
+	s, err := d.session(ref)
+	var r *renderctl.Renderer
+	if err == nil {
+		r = s.renderer
+		if userStop {
+			s.userStop = true
+		}
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	err = fn(r)
+	if d.relocate(s, err) {
+		d.mu.Lock()
+		r = s.renderer
+		d.mu.Unlock()
+		err = fn(r)
+	}
+	return s, err
+}
+
+func isURL(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")
+}
+
+var errNoSource = errors.New("source required (file path or http(s) URL)")
@@ -0,0 +1,398 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// ---- Audio-only formats (MP3, FLAC, Ogg, WAV, ADTS AAC) ----
+
+func isAudioMagic(head []byte) bool {
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")),
+		bytes.HasPrefix(head, []byte("fLaC")),
+		bytes.HasPrefix(head, []byte("OggS")),
+		len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WAVE",
+		isMP3Frame(head),
+		isADTS(head):
+		return true
+	}
+	return false
+}
+
+func parseAudio(r io.ReaderAt, size int64) (*Info, error) {
+	head := make([]byte, 12)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return parseFLAC(r)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return parseOgg(r, size)
+	case bytes.HasPrefix(head, []byte("RIFF")):
+		return parseWAV(r, size)
+	}
+
+	// MP3 / ADTS may be preceded by an ID3v2 tag
+	off := id3Size(r)
+	frame := make([]byte, 4)
+	if _, err := r.ReadAt(frame, off); err != nil {
+		return nil, ErrUnknownFormat
+	}
+	switch {
+	case isADTS(frame):
+		return parseADTS(r, off, size)
+	case isMP3Frame(frame):
+		return parseMP3(r, off, size)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func audioInfo(container, codec string, channels, rate int, d time.Duration) *Info {
+	return &Info{
+		Container: container,
+		Duration:  d,
+		Tracks: []Track{{
+			ID:         1,
+			Kind:       KindAudio,
+			Codec:      codec,
+			Channels:   channels,
+			SampleRate: rate,
+		}},
+	}
+}
+
+// ---- ID3v2 header ----
+
+// id3Size returns the byte length of a leading ID3v2 tag (0 if none).
+func id3Size(r io.ReaderAt) int64 {
+	h := make([]byte, 10)
+	if _, err := r.ReadAt(h, 0); err != nil || string(h[:3]) != "ID3" {
+		return 0
+	}
+	size := int64(syncsafe(h[6:10])) + 10
+	if h[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	return size
+}
+
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// ---- MP3 ----
+
+var (
+	mp3Bitrates = [2][16]int{
+		// MPEG-1 Layer III
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		// MPEG-2/2.5 Layer III
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	}
+	mp3Rates = [4][3]int{
+		{11025, 12000, 8000},  // MPEG-2.5
+		{},                    // reserved
+		{22050, 24000, 16000}, // MPEG-2
+		{44100, 48000, 32000}, // MPEG-1
+	}
+)
+
+func isMP3Frame(b []byte) bool {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return false
+	}
+	layer := (b[1] >> 1) & 0x3
+	version := (b[1] >> 3) & 0x3
+	return layer == 1 && version != 1 && (b[2]>>4) != 0xF && (b[2]>>2)&0x3 != 3
+}
+
+func parseMP3(r io.ReaderAt, off, size int64) (*Info, error) {
+	h := make([]byte, 4)
+	if _, err := r.ReadAt(h, off); err != nil {
+		return nil, err
+	}
+
+	version := (h[1] >> 3) & 0x3
+	v := 1
+	if version == 3 {
+		v = 0
+	}
+	bitrate := mp3Bitrates[v][h[2]>>4] * 1000
+	rate := mp3Rates[version][(h[2]>>2)&0x3]
+	mono := (h[3] >> 6) == 3
+
+	channels := 2
+	if mono {
+		channels = 1
+	}
+
+	samplesPerFrame := 1152
+	if version != 3 {
+		samplesPerFrame = 576
+	}
+
+	// side info length decides where a Xing/Info header sits
+	side := 32
+	switch {
+	case version == 3 && mono:
+		side = 17
+	case version != 3 && mono:
+		side = 9
+	case version != 3:
+		side = 17
+	}
+
+	var dur time.Duration
+	x := make([]byte, 12)
+	if _, err := r.ReadAt(x, off+4+int64(side)); err == nil {
+		tag := string(x[:4])
+		if (tag == "Xing" || tag == "Info") && x[7]&0x1 != 0 && rate > 0 {
+			frames := int64(binary.BigEndian.Uint32(x[8:12]))
+			dur = time.Duration(frames * int64(samplesPerFrame) * int64(time.Second) / int64(rate))
+		}
+	}
+
+	// CBR estimate
+	if dur == 0 && bitrate > 0 {
+		dur = time.Duration((size - off) * 8 * int64(time.Second) / int64(bitrate))
+	}
+
+	return audioInfo("mp3", "mp3", channels, rate, dur), nil
+}
+
+// ---- ADTS AAC ----
+
+func isADTS(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0xFF && b[1]&0xF6 == 0xF0
+}
+
+func parseADTS(r io.ReaderAt, off, size int64) (*Info, error) {
+	h := make([]byte, 7)
+	if _, err := r.ReadAt(h, off); err != nil {
+		return nil, err
+	}
+	var t Track
+	adtsInfo(h, &t)
+
+	// walk frame headers to count frames (1024 samples each)
+	frames := int64(0)
+	for p := off; p+7 <= size; {
+		if _, err := r.ReadAt(h, p); err != nil || !isADTS(h) {
+			break
+		}
+		l := int64(h[3]&0x3)<<11 | int64(h[4])<<3 | int64(h[5]>>5)
+		if l < 7 {
+			break
+		}
+		frames++
+		p += l
+	}
+
+	var dur time.Duration
+	if t.SampleRate > 0 {
+		dur = time.Duration(frames * 1024 * int64(time.Second) / int64(t.SampleRate))
+	}
+	return audioInfo("aac", "aac", t.Channels, t.SampleRate, dur), nil
+}
+
+// ---- FLAC ----
+
+// flacBlocks iterates metadata blocks; fn returns false to stop.
+func flacBlocks(r io.ReaderAt, fn func(typ byte, start int64, length int) bool) {
+	h := make([]byte, 4)
+	for off := int64(4); ; {
+		if _, err := r.ReadAt(h, off); err != nil {
+			return
+		}
+		last := h[0]&0x80 != 0
+		typ := h[0] & 0x7F
+		length := int(h[1])<<16 | int(h[2])<<8 | int(h[3])
+
+		if !fn(typ, off+4, length) || last {
+			return
+		}
+		off += 4 + int64(length)
+	}
+}
+
+func parseFLAC(r io.ReaderAt) (*Info, error) {
+	var info *Info
+
+	flacBlocks(r, func(typ byte, start int64, length int) bool {
+		if typ != 0 || length < 18 {
+			return true
+		}
+		b := make([]byte, 18)
+		if _, err := r.ReadAt(b, start); err != nil {
+			return false
+		}
+		rate := int(b[10])<<12 | int(b[11])<<4 | int(b[12]>>4)
+		channels := int((b[12]>>1)&0x7) + 1
+		total := int64(b[13]&0x0F)<<32 | int64(binary.BigEndian.Uint32(b[14:18]))
+
+		var dur time.Duration
+		if rate > 0 {
+			dur = time.Duration(total * int64(time.Second) / int64(rate))
+		}
+		info = audioInfo("flac", "flac", channels, rate, dur)
+		return false
+	})
+
+	if info == nil {
+		return nil, errors.New("flac: STREAMINFO not found")
+	}
+	return info, nil
+}
+
+// ---- Ogg (Vorbis / Opus) ----
+
+// oggPackets reassembles the first n packets of the first logical stream.
+func oggPackets(r io.ReaderAt, n int) [][]byte {
+	var (
+		packets [][]byte
+		cur     []byte
+	)
+	hdr := make([]byte, 27)
+
+	for off := int64(0); len(packets) < n; {
+		if _, err := r.ReadAt(hdr, off); err != nil || string(hdr[:4]) != "OggS" {
+			break
+		}
+		segs := make([]byte, hdr[26])
+		if _, err := r.ReadAt(segs, off+27); err != nil {
+			break
+		}
+		total := 0
+		for _, s := range segs {
+			total += int(s)
+		}
+		body := make([]byte, total)
+		if _, err := r.ReadAt(body, off+27+int64(len(segs))); err != nil {
+			break
+		}
+
+		p := 0
+		for _, s := range segs {
+			cur = append(cur, body[p:p+int(s)]...)
+			p += int(s)
+			if s < 255 {
+				packets = append(packets, cur)
+				cur = nil
+				if len(packets) == n {
+					break
+				}
+			}
+		}
+		off += 27 + int64(len(segs)) + int64(total)
+	}
+	return packets
+}
+
+func parseOgg(r io.ReaderAt, size int64) (*Info, error) {
+	packets := oggPackets(r, 1)
+	if len(packets) == 0 {
+		return nil, errors.New("ogg: no packets")
+	}
+	id := packets[0]
+
+	var (
+		codec    string
+		channels int
+		rate     int
+		preskip  int64
+	)
+	switch {
+	case len(id) >= 16 && string(id[1:7]) == "vorbis":
+		codec = "vorbis"
+		channels = int(id[11])
+		rate = int(binary.LittleEndian.Uint32(id[12:16]))
+	case len(id) >= 12 && string(id[:8]) == "OpusHead":
+		codec = "opus"
+		channels = int(id[9])
+		preskip = int64(binary.LittleEndian.Uint16(id[10:12]))
+		rate = 48000 // Opus granule is always 48 kHz
+	default:
+		return nil, errors.New("ogg: unsupported codec")
+	}
+
+	var dur time.Duration
+	if g := oggLastGranule(r, size); g > 0 && rate > 0 {
+		dur = time.Duration((g - preskip) * int64(time.Second) / int64(rate))
+	}
+	return audioInfo("ogg", codec, channels, rate, dur), nil
+}
+
+func oggLastGranule(r io.ReaderAt, size int64) int64 {
+	start := size - 64<<10
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, size-start)
+	n, _ := r.ReadAt(buf, start)
+	buf = buf[:n]
+
+	i := bytes.LastIndex(buf, []byte("OggS"))
+	if i < 0 || i+14 > len(buf) {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(buf[i+6 : i+14]))
+}
+
+// ---- WAV ----
+
+// riffChunks iterates RIFF chunks in [start, end).
+func riffChunks(r io.ReaderAt, start, end int64, fn func(id string, start int64, size int64) bool) {
+	h := make([]byte, 8)
+	for off := start; off+8 <= end; {
+		if _, err := r.ReadAt(h, off); err != nil {
+			return
+		}
+		size := int64(binary.LittleEndian.Uint32(h[4:8]))
+		if !fn(string(h[:4]), off+8, size) {
+			return
+		}
+		off += 8 + size + size%2 // word aligned
+	}
+}
+
+func parseWAV(r io.ReaderAt, size int64) (*Info, error) {
+	var (
+		channels, rate, byteRate int
+		codec                    = "pcm_s16le"
+		dataSize                 int64
+	)
+
+	riffChunks(r, 12, size, func(id string, start, n int64) bool {
+		switch id {
+		case "fmt ":
+			b := make([]byte, 16)
+			if _, err := r.ReadAt(b, start); err != nil {
+				return false
+			}
+			if binary.LittleEndian.Uint16(b[0:2]) == 3 {
+				codec = "pcm_f32le"
+			}
+			channels = int(binary.LittleEndian.Uint16(b[2:4]))
+			rate = int(binary.LittleEndian.Uint32(b[4:8]))
+			byteRate = int(binary.LittleEndian.Uint32(b[8:12]))
+		case "data":
+			dataSize = n
+		}
+		return true
+	})
+
+	if rate == 0 {
+		return nil, errors.New("wav: fmt chunk not found")
+	}
+
+	var dur time.Duration
+	if byteRate > 0 {
+		dur = time.Duration(dataSize * int64(time.Second) / int64(byteRate))
+	}
+	return audioInfo("wav", codec, channels, rate, dur), nil
+}
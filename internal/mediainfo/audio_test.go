@@ -0,0 +1,219 @@
+package mediainfo
+
+import (
+	"testing"
+	"time"
+)
+
+// ---- audio fixtures ----
+
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+// id3v23 wraps frames in an ID3v2.3 tag.
+func id3v23(frames ...[]byte) []byte {
+	body := cat(frames...)
+	return cat([]byte("ID3"), []byte{3, 0, 0}, syncsafeBytes(len(body)), body)
+}
+
+func id3Frame(id string, body []byte) []byte {
+	return cat([]byte(id), be32(uint32(len(body))), be16(0), body)
+}
+
+// mp3Frame is an MPEG-1 Layer III, 128 kbit/s, 44.1 kHz stereo frame;
+// xing adds an Xing header announcing frames frames.
+func mp3Frame(xing bool, frames uint32) []byte {
+	f := make([]byte, 417)
+	copy(f, []byte{0xFF, 0xFB, 0x90, 0x00})
+	if xing {
+		copy(f[4+32:], cat([]byte("Xing"), be32(1), be32(frames)))
+	}
+	return f
+}
+
+func mp3File(tagged bool) []byte {
+	var tag []byte
+	if tagged {
+		tag = id3v23(id3Frame("TIT2", []byte("\x00Song")))
+	}
+	return cat(tag, mp3Frame(true, 1000), mp3Frame(false, 0), mp3Frame(false, 0))
+}
+
+// adtsFrame is a 48 kHz stereo ADTS frame of n bytes (header included).
+func adtsFrame(n int) []byte {
+	f := make([]byte, max(n, 7))
+	copy(f, []byte{0xFF, 0xF1, 0x4C, 0x80 | byte(n>>11&0x3), byte(n >> 3), byte(n&0x7)<<5 | 0x1F, 0xFC})
+	return f
+}
+
+func adtsFile(frames int) []byte {
+	var b []byte
+	for range frames {
+		b = append(b, adtsFrame(64)...)
+	}
+	return b
+}
+
+func flacBlock(typ byte, last bool, body []byte) []byte {
+	if last {
+		typ |= 0x80
+	}
+	n := len(body)
+	return cat([]byte{typ, byte(n >> 16), byte(n >> 8), byte(n)}, body)
+}
+
+// streamInfo describes 10 s of 44.1 kHz, 16-bit stereo.
+func streamInfo() []byte {
+	b := make([]byte, 34)
+	b[10], b[11], b[12], b[13] = 0x0A, 0xC4, 0x42, 0xF0
+	copy(b[14:], be32(441000))
+	return b
+}
+
+func vorbisCommentBlock(kv ...string) []byte {
+	b := cat(le32(6), []byte("vendor"), le32(uint32(len(kv))))
+	for _, s := range kv {
+		b = cat(b, le32(uint32(len(s))), []byte(s))
+	}
+	return b
+}
+
+func flacPictureBody(mime string, data []byte) []byte {
+	return cat(
+		be32(3),
+		be32(uint32(len(mime))), []byte(mime),
+		be32(0),
+		make([]byte, 16),
+		be32(uint32(len(data))), data,
+	)
+}
+
+func flacFile() []byte {
+	return cat(
+		[]byte("fLaC"),
+		flacBlock(0, false, streamInfo()),
+		flacBlock(4, false, vorbisCommentBlock("TITLE=Song", "ARTIST=Band")),
+		flacBlock(6, true, flacPictureBody("image/png", []byte("png!"))),
+		make([]byte, 32),
+	)
+}
+
+// oggPage writes one page holding the given complete packets.
+func oggPage(granule uint64, packets ...[]byte) []byte {
+	var segs, body []byte
+	for _, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			segs = append(segs, 255)
+		}
+		segs = append(segs, byte(n))
+		body = append(body, p...)
+	}
+	return cat([]byte("OggS"), []byte{0, 0}, le64(granule), le32(1), le32(0), le32(0),
+		[]byte{byte(len(segs))}, segs, body)
+}
+
+func opusHead(ch byte, preskip uint16) []byte {
+	return cat([]byte("OpusHead"), []byte{1, ch}, le16(preskip), le32(48000), le16(0), []byte{0})
+}
+
+func oggOpus() []byte {
+	return cat(
+		oggPage(0, opusHead(2, 312)),
+		oggPage(0, cat([]byte("OpusTags"), vorbisCommentBlock("TITLE=Song", "ALBUM=Record"))),
+		oggPage(48000*5+312, make([]byte, 40)),
+	)
+}
+
+func oggVorbis() []byte {
+	id := cat([]byte("\x01vorbis"), le32(0), []byte{1}, le32(44100), make([]byte, 14))
+	comments := cat([]byte("\x03vorbis"), vorbisCommentBlock("ARTIST=Band"))
+	return cat(
+		oggPage(0, id),
+		oggPage(0, comments),
+		oggPage(44100*3, make([]byte, 40)),
+	)
+}
+
+func riffChunk(id string, body []byte) []byte {
+	b := cat([]byte(id), le32(uint32(len(body))), body)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func wavFmt(ch uint16, rate uint32) []byte {
+	return cat(le16(1), le16(ch), le32(rate), le32(rate*uint32(ch)*2), le16(ch*2), le16(16))
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := cat(chunks...)
+	return cat([]byte("RIFF"), le32(uint32(4+len(body))), []byte("WAVE"), body)
+}
+
+func wavFile() []byte {
+	return riff(
+		riffChunk("fmt ", wavFmt(2, 44100)),
+		riffChunk("LIST", cat([]byte("INFO"), riffChunk("INAM", []byte("Song\x00")), riffChunk("IART", []byte("Band\x00")))),
+		riffChunk("data", make([]byte, 1764)),
+	)
+}
+
+func TestParseAudio(t *testing.T) {
+	xingDur := time.Duration(1000 * 1152 * int64(time.Second) / 44100)
+	cbr := mp3File(false)[417:]
+
+	// second ADTS frame claims a length shorter than its header
+	shortADTS := cat(adtsFrame(64), adtsFrame(3), adtsFrame(64))
+
+	// STREAMINFO shorter than 18 bytes, and the only block
+	shortInfo := cat([]byte("fLaC"), flacBlock(0, true, streamInfo()[:10]))
+
+	// padding block whose length skips past the end of the file
+	skipped := cat([]byte("fLaC"), flacBlock(1, false, nil), flacBlock(0, true, streamInfo()))
+	skipped[5], skipped[6], skipped[7] = 0xFF, 0xFF, 0xFF
+
+	// segment table promising more body than the file has
+	shortPage := oggPage(0, opusHead(2, 312))
+	shortPage[27] = 200
+
+	// fmt chunk with a size past the end hides the data chunk
+	hugeFmt := riff(riffChunk("fmt ", wavFmt(1, 8000)), riffChunk("data", make([]byte, 16)))
+	copy(hugeFmt[16:20], le32(0xFFFFFFF0))
+
+	runParseCases(t, []parseCase{
+		{name: "mp3 xing", data: mp3File(false), want: audioInfo("mp3", "mp3", 2, 44100, xingDur)},
+		{name: "mp3 behind id3", data: mp3File(true), want: audioInfo("mp3", "mp3", 2, 44100, xingDur)},
+		{
+			name: "mp3 cbr estimate",
+			data: cbr,
+			want: audioInfo("mp3", "mp3", 2, 44100, time.Duration(int64(len(cbr))*8*int64(time.Second)/128000)),
+		},
+		{
+			name: "adts",
+			data: adtsFile(10),
+			want: audioInfo("aac", "aac", 2, 48000, time.Duration(10*1024*int64(time.Second)/48000)),
+		},
+		{
+			name: "adts frame shorter than header",
+			data: shortADTS,
+			want: audioInfo("aac", "aac", 2, 48000, time.Duration(1024*int64(time.Second)/48000)),
+		},
+		{name: "flac", data: flacFile(), want: audioInfo("flac", "flac", 2, 44100, 10*time.Second)},
+		{name: "flac short streaminfo", data: shortInfo, wantErr: true},
+		{name: "flac block past end", data: skipped, wantErr: true},
+		{name: "ogg opus", data: oggOpus(), want: audioInfo("ogg", "opus", 2, 48000, 5*time.Second)},
+		{name: "ogg vorbis", data: oggVorbis(), want: audioInfo("ogg", "vorbis", 1, 44100, 3*time.Second)},
+		{name: "ogg page past end", data: shortPage, wantErr: true},
+		{
+			name:    "ogg unknown codec",
+			data:    oggPage(0, []byte("Speex   header..")),
+			wantErr: true,
+		},
+		{name: "wav", data: wavFile(), want: audioInfo("wav", "pcm_s16le", 2, 44100, 10*time.Millisecond)},
+		{name: "wav fmt size past end", data: hugeFmt, want: audioInfo("wav", "pcm_s16le", 1, 8000, 0)},
+		{name: "wav without fmt", data: riff(riffChunk("data", make([]byte, 16))), wantErr: true},
+	})
+}
@@ -0,0 +1,173 @@
+package mediainfo
+
+// ---- H.264 SPS (resolution only) ----
+
+type bitReader struct {
+	b   []byte
+	pos int // bit position
+}
+
+func (br *bitReader) bit() uint {
+	if br.pos >= len(br.b)*8 {
+		return 0
+	}
+	v := (br.b[br.pos/8] >> (7 - uint(br.pos%8))) & 1
+	br.pos++
+	return uint(v)
+}
+
+func (br *bitReader) bits(n int) uint {
+	var v uint
+	for i := 0; i < n; i++ {
+		v = v<<1 | br.bit()
+	}
+	return v
+}
+
+// ue reads an unsigned Exp-Golomb code.
+func (br *bitReader) ue() uint {
+	zeros := 0
+	for br.bit() == 0 && zeros < 32 {
+		zeros++
+	}
+	return (1<<zeros - 1) + br.bits(zeros)
+}
+
+func (br *bitReader) se() int {
+	v := br.ue()
+	if v&1 == 1 {
+		return int(v+1) / 2
+	}
+	return -int(v / 2)
+}
+
+// unescape removes emulation prevention bytes (00 00 03).
+func unescape(nal []byte) []byte {
+	out := make([]byte, 0, len(nal))
+	zeros := 0
+	for _, b := range nal {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// h264Info finds the SPS in an Annex-B elementary stream chunk.
+func h264Info(es []byte, t *Track) bool {
+	for i := 0; i+4 < len(es); i++ {
+		if es[i] != 0 || es[i+1] != 0 || es[i+2] != 1 {
+			continue
+		}
+		if es[i+3]&0x1F != 7 {
+			continue
+		}
+		w, h, ok := parseSPS(unescape(es[i+4:]))
+		if ok {
+			t.Width, t.Height = w, h
+		}
+		return ok
+	}
+	return false
+}
+
+func parseSPS(b []byte) (int, int, bool) {
+	if len(b) < 4 {
+		return 0, 0, false
+	}
+	br := &bitReader{b: b}
+
+	profile := br.bits(8)
+	br.bits(16) // constraint flags + level
+	br.ue()     // seq_parameter_set_id
+
+	chroma := uint(1)
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chroma = br.ue()
+		if chroma == 3 {
+			br.bit() // separate_colour_plane
+		}
+		br.ue()            // bit_depth_luma
+		br.ue()            // bit_depth_chroma
+		br.bit()           // qpprime_y_zero_transform_bypass
+		if br.bit() == 1 { // seq_scaling_matrix_present
+			n := 8
+			if chroma == 3 {
+				n = 12
+			}
+			for i := 0; i < n; i++ {
+				if br.bit() == 1 {
+					size := 16
+					if i >= 6 {
+						size = 64
+					}
+					skipScalingList(br, size)
+				}
+			}
+		}
+	}
+
+	br.ue()          // log2_max_frame_num
+	switch br.ue() { // pic_order_cnt_type
+	case 0:
+		br.ue()
+	case 1:
+		br.bit()
+		br.se()
+		br.se()
+		n := br.ue()
+		for i := uint(0); i < n && i < 256; i++ {
+			br.se()
+		}
+	}
+	br.ue()  // max_num_ref_frames
+	br.bit() // gaps_in_frame_num_allowed
+
+	mbW := br.ue() + 1
+	mbH := br.ue() + 1
+	frameMBsOnly := br.bit()
+	if frameMBsOnly == 0 {
+		br.bit() // mb_adaptive_frame_field
+	}
+	br.bit() // direct_8x8_inference
+
+	width := int(mbW * 16)
+	height := int((2 - frameMBsOnly) * mbH * 16)
+
+	if br.bit() == 1 { // frame_cropping
+		l, r := br.ue(), br.ue()
+		tp, bt := br.ue(), br.ue()
+
+		cropX, cropY := uint(2), 2*(2-frameMBsOnly)
+		if chroma == 0 || chroma == 3 {
+			cropX, cropY = 1, 2-frameMBsOnly
+		}
+		width -= int((l + r) * cropX)
+		height -= int((tp + bt) * cropY)
+	}
+
+	if width <= 0 || height <= 0 || br.pos > len(b)*8 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+func skipScalingList(br *bitReader, size int) {
+	last, next := 8, 8
+	for j := 0; j < size; j++ {
+		if next != 0 {
+			next = (last + br.se() + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+}
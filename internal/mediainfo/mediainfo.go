@@ -0,0 +1,197 @@
+package mediainfo
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TrackKind int
+
+const (
+	KindVideo TrackKind = iota
+	KindAudio
+	KindSubtitle
+)
+
+type Info struct {
+	Container string // mp4, mkv, webm, ts, m4a, mp3, flac, ogg, wav, aac
+	Duration  time.Duration
+	Tracks    []Track
+}
+
+type Track struct {
+	ID       int
+	Kind     TrackKind
+	Codec    string // ffprobe-style codec name (h264, hevc, aac, ac3, ...)
+	Language string
+
+	// video
+	Width  int
+	Height int
+
+	// audio
+	Channels   int
+	SampleRate int
+}
+
+// ChannelLayout names common channel counts.
+func (t Track) ChannelLayout() string {
+	switch t.Channels {
+	case 0:
+		return ""
+	case 1:
+		return "mono"
+	case 2:
+		return "stereo"
+	case 6:
+		return "5.1"
+	case 8:
+		return "7.1"
+	}
+	return ""
+}
+
+// Mime returns the usual DLNA mime type for the container.
+func (i *Info) Mime() string {
+	switch i.Container {
+	case "mp4":
+		return "video/mp4"
+	case "mkv":
+		return "video/x-matroska"
+	case "webm":
+		return "video/webm"
+	case "ts":
+		return "video/mpeg"
+	}
+	if m := audioMimes[i.Container]; len(m) > 0 {
+		return m[0]
+	}
+	return ""
+}
+
+// audioMimes lists the spellings renderers use in their sink
+// protocol info, most common first.
+var audioMimes = map[string][]string{
+	"mp3":  {"audio/mpeg", "audio/mp3", "audio/x-mpeg"},
+	"flac": {"audio/flac", "audio/x-flac"},
+	"ogg":  {"audio/ogg", "application/ogg", "audio/x-ogg", "audio/vorbis"},
+	"wav":  {"audio/wav", "audio/x-wav", "audio/wave", "audio/L16"},
+	"aac":  {"audio/aac", "audio/x-aac", "audio/vnd.dlna.adts", "audio/aacp"},
+	"m4a":  {"audio/mp4", "audio/x-m4a", "audio/m4a"},
+}
+
+// MimeCandidates returns every mime spelling worth matching against
+// a renderer sink list, preferred first.
+func (i *Info) MimeCandidates() []string {
+	if m := audioMimes[i.Container]; len(m) > 0 {
+		return m
+	}
+	if m := i.Mime(); m != "" {
+		return []string{m}
+	}
+	return nil
+}
+
+// IsAudio reports whether the media has audio but no video.
+func (i *Info) IsAudio() bool {
+	return i.Video() == nil && i.Audio() != nil
+}
+
+// Resolution returns "WxH" of the first video track, or "".
+func (i *Info) Resolution() string {
+	v := i.Video()
+	if v == nil || v.Width == 0 || v.Height == 0 {
+		return ""
+	}
+	return strconv.Itoa(v.Width) + "x" + strconv.Itoa(v.Height)
+}
+
+// Video returns the first video track, or nil.
+func (i *Info) Video() *Track { return i.first(KindVideo) }
+
+// Audio returns the first audio track, or nil.
+func (i *Info) Audio() *Track { return i.first(KindAudio) }
+
+func (i *Info) first(k TrackKind) *Track {
+	for n := range i.Tracks {
+		if i.Tracks[n].Kind == k {
+			return &i.Tracks[n]
+		}
+	}
+	return nil
+}
+
+var ErrUnknownFormat = errors.New("unrecognized container format")
+
+// ParseFile opens and parses a local media file.
+func ParseFile(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := Parse(f, st.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Container == "mkv" && strings.EqualFold(filepath.Ext(path), ".webm") {
+		info.Container = "webm"
+	}
+	return info, nil
+}
+
+// Parse detects the container by magic bytes and parses its headers.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	head := make([]byte, 12)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case len(head) >= 4 && head[0] == 0x1A && head[1] == 0x45 && head[2] == 0xDF && head[3] == 0xA3:
+		return parseMatroska(r, size)
+
+	case len(head) >= 8 && isBoxType(head[4:8]):
+		info, err := parseMP4(r, size)
+		if err == nil && info.Video() == nil && info.Audio() != nil {
+			info.Container = "m4a"
+		}
+		return info, err
+
+	case isTS(r):
+		return parseTS(r, size)
+
+	case isAudioMagic(head):
+		return parseAudio(r, size)
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+func isBoxType(b []byte) bool {
+	switch string(b) {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "styp":
+		return true
+	}
+	return false
+}
+
+func isTS(r io.ReaderAt) bool {
+	buf := make([]byte, tsPacket*3)
+	n, _ := r.ReadAt(buf, 0)
+	if n < len(buf) {
+		return n >= tsPacket && buf[0] == 0x47
+	}
+	return buf[0] == 0x47 && buf[tsPacket] == 0x47 && buf[2*tsPacket] == 0x47
+}
@@ -0,0 +1,103 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// ---- fixture helpers ----
+
+func cat(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+func be16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func be32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+func be64(v uint64) []byte { return binary.BigEndian.AppendUint64(nil, v) }
+func le16(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+func le32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+func le64(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+
+func parseBytes(b []byte) (*Info, error) {
+	return Parse(bytes.NewReader(b), int64(len(b)))
+}
+
+type parseCase struct {
+	name    string
+	data    []byte
+	want    *Info
+	wantErr bool
+}
+
+func runParseCases(t *testing.T, cases []parseCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseBytes(tc.data)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// ---- truncation ----
+
+// Every prefix of a valid file must parse or fail, never panic.
+func TestTruncated(t *testing.T) {
+	fixtures := map[string][]byte{
+		"mp4":        mp4File(),
+		"mp4 frag":   mp4Fragmented(),
+		"mp4 tags":   mp4Tagged(),
+		"mkv":        mkvFile("matroska"),
+		"ts":         tsFile(),
+		"mp3":        mp3File(true),
+		"adts":       adtsFile(10),
+		"flac":       flacFile(),
+		"ogg opus":   oggOpus(),
+		"ogg vorbis": oggVorbis(),
+		"wav":        wavFile(),
+	}
+	for name, data := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			for n := range len(data) {
+				r := bytes.NewReader(data[:n])
+				Parse(r, int64(n))
+				readTags(r, int64(n))
+			}
+		})
+	}
+}
+
+// Every byte overwritten with 0xFF (sizes become huge, vints unknown)
+// must not panic either.
+func TestCorrupted(t *testing.T) {
+	fixtures := map[string][]byte{
+		"mp4":  mp4File(),
+		"mkv":  mkvFile("matroska"),
+		"ts":   tsFile(),
+		"flac": flacFile(),
+		"ogg":  oggOpus(),
+		"wav":  wavFile(),
+	}
+	for name, data := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			for i := range data {
+				b := bytes.Clone(data)
+				b[i] = 0xFF
+				r := bytes.NewReader(b)
+				Parse(r, int64(len(b)))
+				readTags(r, int64(len(b)))
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package mediainfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ---- Matroska / WebM (EBML) ----
+
+const (
+	ebmlHeader   = 0x1A45DFA3
+	ebmlDocType  = 0x4282
+	mkvSegment   = 0x18538067
+	mkvInfo      = 0x1549A966
+	mkvTracks    = 0x1654AE6B
+	mkvCluster   = 0x1F43B675
+	mkvTimescale = 0x2AD7B1
+	mkvDuration  = 0x4489
+
+	mkvTrackEntry  = 0xAE
+	mkvTrackNumber = 0xD7
+	mkvTrackType   = 0x83
+	mkvCodecID     = 0x86
+	mkvLanguage    = 0x22B59C
+	mkvVideo       = 0xE0
+	mkvPixelWidth  = 0xB0
+	mkvPixelHeight = 0xBA
+	mkvAudio       = 0xE1
+	mkvChannels    = 0x9F
+	mkvSampling    = 0xB5
+
+	unknownSize = -1
+)
+
+type element struct {
+	id    uint32
+	start int64 // payload start
+	size  int64 // unknownSize for live / unfinished files
+}
+
+// readVint reads an EBML variable-length integer at off.
+// keepMarker retains the length marker bit (element IDs).
+func readVint(r io.ReaderAt, off int64, keepMarker bool) (uint64, int, error) {
+	first := make([]byte, 1)
+	if _, err := r.ReadAt(first, off); err != nil {
+		return 0, 0, err
+	}
+
+	length := 1
+	for mask := byte(0x80); length <= 8 && first[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, 0, errors.New("mkv: invalid vint")
+	}
+
+	buf := make([]byte, length)
+	if _, err := r.ReadAt(buf, off); err != nil {
+		return 0, 0, err
+	}
+
+	v := uint64(buf[0])
+	if !keepMarker {
+		v &= uint64(0xFF >> length)
+	}
+	allOnes := v == uint64(0xFF>>length)
+	for _, b := range buf[1:] {
+		v = v<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+
+	if !keepMarker && allOnes {
+		return math.MaxUint64, length, nil
+	}
+	return v, length, nil
+}
+
+func readElement(r io.ReaderAt, off int64) (element, error) {
+	id, idLen, err := readVint(r, off, true)
+	if err != nil {
+		return element{}, err
+	}
+	size, sizeLen, err := readVint(r, off+int64(idLen), false)
+	if err != nil {
+		return element{}, err
+	}
+
+	e := element{
+		id:    uint32(id),
+		start: off + int64(idLen+sizeLen),
+		size:  int64(size),
+	}
+	if size == math.MaxUint64 {
+		e.size = unknownSize
+	}
+	return e, nil
+}
+
+// children iterates elements in [start, end) and calls fn;
+// fn returns false to stop.
+func children(r io.ReaderAt, start, end int64, fn func(element) bool) {
+	for off := start; off < end; {
+		e, err := readElement(r, off)
+		if err != nil {
+			return
+		}
+		if !fn(e) {
+			return
+		}
+		if e.size == unknownSize {
+			// only master elements may be unsized; descend instead
+			off = e.start
+			continue
+		}
+		off = e.start + e.size
+	}
+}
+
+func readBytes(r io.ReaderAt, e element, max int64) []byte {
+	n := e.size
+	if n < 0 || n > max {
+		n = max
+	}
+	buf := make([]byte, n)
+	k, _ := r.ReadAt(buf, e.start)
+	return buf[:k]
+}
+
+func readUint(r io.ReaderAt, e element) uint64 {
+	var v uint64
+	for _, b := range readBytes(r, e, 8) {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+func readFloat(r io.ReaderAt, e element) float64 {
+	b := readBytes(r, e, 8)
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func readString(r io.ReaderAt, e element) string {
+	return strings.TrimRight(string(readBytes(r, e, 256)), "\x00")
+}
+
+var mkvCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "hevc",
+	"V_MPEG2":          "mpeg2video",
+	"V_MPEG1":          "mpeg1video",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"V_AV1":            "av1",
+	"A_AAC":            "aac",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_DTS":            "dts",
+	"A_MPEG/L3":        "mp3",
+	"A_MPEG/L2":        "mp2",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_FLAC":           "flac",
+	"A_PCM/INT/LIT":    "pcm_s16le",
+	"S_TEXT/UTF8":      "subrip",
+	"S_TEXT/ASS":       "ass",
+	"S_TEXT/SSA":       "ssa",
+	"S_HDMV/PGS":       "hdmv_pgs_subtitle",
+	"S_VOBSUB":         "dvd_subtitle",
+	"S_TEXT/WEBVTT":    "webvtt",
+}
+
+func mkvCodec(id string) string {
+	if c, ok := mkvCodecs[id]; ok {
+		return c
+	}
+	// A_AAC/MPEG4/LC etc.
+	if strings.HasPrefix(id, "A_AAC") {
+		return "aac"
+	}
+	return strings.ToLower(id)
+}
+
+func parseMatroska(r io.ReaderAt, size int64) (*Info, error) {
+	hdr, err := readElement(r, 0)
+	if err != nil || hdr.id != ebmlHeader {
+		return nil, errors.New("mkv: missing EBML header")
+	}
+
+	info := &Info{Container: "mkv"}
+	children(r, hdr.start, hdr.start+hdr.size, func(e element) bool {
+		if e.id == ebmlDocType && readString(r, e) == "webm" {
+			info.Container = "webm"
+		}
+		return true
+	})
+
+	seg, err := readElement(r, hdr.start+hdr.size)
+	if err != nil || seg.id != mkvSegment {
+		return nil, errors.New("mkv: segment not found")
+	}
+	segEnd := size
+	if seg.size != unknownSize && seg.start+seg.size < size {
+		segEnd = seg.start + seg.size
+	}
+
+	var (
+		scale     uint64 = 1000000 // ns per tick (Matroska default)
+		rawDur    float64
+		gotInfo   bool
+		gotTracks bool
+	)
+
+	children(r, seg.start, segEnd, func(e element) bool {
+		switch e.id {
+		case mkvInfo:
+			gotInfo = true
+			children(r, e.start, e.start+e.size, func(c element) bool {
+				switch c.id {
+				case mkvTimescale:
+					scale = readUint(r, c)
+				case mkvDuration:
+					rawDur = readFloat(r, c)
+				}
+				return true
+			})
+
+		case mkvTracks:
+			gotTracks = true
+			children(r, e.start, e.start+e.size, func(c element) bool {
+				if c.id == mkvTrackEntry {
+					if t, ok := mkvTrack(r, c); ok {
+						info.Tracks = append(info.Tracks, t)
+					}
+				}
+				return true
+			})
+
+		case mkvCluster:
+			// media data starts — headers are behind us
+			return false
+		}
+
+		if e.size == unknownSize {
+			return false
+		}
+		return !(gotInfo && gotTracks)
+	})
+
+	if rawDur > 0 {
+		info.Duration = time.Duration(rawDur * float64(scale))
+	}
+	return info, nil
+}
+
+func mkvTrack(r io.ReaderAt, entry element) (Track, bool) {
+	var (
+		t   Track
+		typ uint64
+	)
+	t.Language = "eng" // Matroska default when the element is absent
+
+	children(r, entry.start, entry.start+entry.size, func(c element) bool {
+		switch c.id {
+		case mkvTrackNumber:
+			t.ID = int(readUint(r, c))
+		case mkvTrackType:
+			typ = readUint(r, c)
+		case mkvCodecID:
+			t.Codec = mkvCodec(readString(r, c))
+		case mkvLanguage:
+			t.Language = readString(r, c)
+		case mkvVideo:
+			children(r, c.start, c.start+c.size, func(v element) bool {
+				switch v.id {
+				case mkvPixelWidth:
+					t.Width = int(readUint(r, v))
+				case mkvPixelHeight:
+					t.Height = int(readUint(r, v))
+				}
+				return true
+			})
+		case mkvAudio:
+			children(r, c.start, c.start+c.size, func(a element) bool {
+				switch a.id {
+				case mkvChannels:
+					t.Channels = int(readUint(r, a))
+				case mkvSampling:
+					t.SampleRate = int(readFloat(r, a))
+				}
+				return true
+			})
+		}
+		return true
+	})
+
+	switch typ {
+	case 1:
+		t.Kind = KindVideo
+	case 2:
+		t.Kind = KindAudio
+	case 17:
+		t.Kind = KindSubtitle
+	default:
+		return t, false
+	}
+	if t.Language == "und" {
+		t.Language = ""
+	}
+	if t.Kind == KindAudio && t.Channels == 0 {
+		t.Channels = 1 // Matroska default
+	}
+	return t, true
+}
@@ -0,0 +1,141 @@
+package mediainfo
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// ---- Matroska fixtures ----
+
+func ebmlID(id uint32) []byte {
+	b := be32(id)
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// ebmlSize encodes n as a 1-byte vint when it fits, else 8 bytes.
+func ebmlSize(n int) []byte {
+	if n < 0x7F {
+		return []byte{0x80 | byte(n)}
+	}
+	return cat([]byte{0x01}, be64(uint64(n))[1:])
+}
+
+func el(id uint32, payload ...[]byte) []byte {
+	body := cat(payload...)
+	return cat(ebmlID(id), ebmlSize(len(body)), body)
+}
+
+// elSized writes an element header claiming size bytes (-1: unknown).
+func elSized(id uint32, size int, payload ...[]byte) []byte {
+	sz := []byte{0xFF}
+	if size >= 0 {
+		sz = ebmlSize(size)
+	}
+	return cat(ebmlID(id), sz, cat(payload...))
+}
+
+func uintEl(id uint32, v uint32) []byte   { return el(id, be32(v)) }
+func floatEl(id uint32, f float64) []byte { return el(id, be64(math.Float64bits(f))) }
+func strEl(id uint32, s string) []byte    { return el(id, []byte(s)) }
+
+func mkvHeader(docType string) []byte {
+	return el(ebmlHeader, strEl(ebmlDocType, docType))
+}
+
+func mkvInfoEl() []byte {
+	return el(mkvInfo, uintEl(mkvTimescale, 1000000), floatEl(mkvDuration, 12500))
+}
+
+func mkvTracksEl() []byte { return el(mkvTracks, mkvEntries()) }
+
+func mkvEntries() []byte {
+	return cat(
+		el(mkvTrackEntry,
+			uintEl(mkvTrackNumber, 1),
+			uintEl(mkvTrackType, 1),
+			strEl(mkvCodecID, "V_MPEG4/ISO/AVC"),
+			el(mkvVideo, uintEl(mkvPixelWidth, 1280), uintEl(mkvPixelHeight, 720)),
+		),
+		el(mkvTrackEntry,
+			uintEl(mkvTrackNumber, 2),
+			uintEl(mkvTrackType, 2),
+			strEl(mkvCodecID, "A_AAC/MPEG4/LC"),
+			strEl(mkvLanguage, "ger"),
+			el(mkvAudio, uintEl(mkvChannels, 6), floatEl(mkvSampling, 48000)),
+		),
+		el(mkvTrackEntry,
+			uintEl(mkvTrackNumber, 3),
+			uintEl(mkvTrackType, 17),
+			strEl(mkvCodecID, "S_TEXT/UTF8"),
+			strEl(mkvLanguage, "und"),
+		),
+	)
+}
+
+func mkvFile(docType string) []byte {
+	return cat(
+		mkvHeader(docType),
+		el(mkvSegment, mkvInfoEl(), mkvTracksEl(), el(mkvCluster, make([]byte, 16))),
+	)
+}
+
+var mkvTracksWant = []Track{
+	{ID: 1, Kind: KindVideo, Codec: "h264", Language: "eng", Width: 1280, Height: 720},
+	{ID: 2, Kind: KindAudio, Codec: "aac", Language: "ger", Channels: 6, SampleRate: 48000},
+	{ID: 3, Kind: KindSubtitle, Codec: "subrip"},
+}
+
+func TestParseMatroska(t *testing.T) {
+	runParseCases(t, []parseCase{
+		{
+			name: "matroska",
+			data: mkvFile("matroska"),
+			want: &Info{Container: "mkv", Duration: 12500 * time.Millisecond, Tracks: mkvTracksWant},
+		},
+		{
+			name: "webm doctype",
+			data: mkvFile("webm"),
+			want: &Info{Container: "webm", Duration: 12500 * time.Millisecond, Tracks: mkvTracksWant},
+		},
+		{
+			name: "unknown-size segment",
+			data: cat(mkvHeader("matroska"), elSized(mkvSegment, -1, mkvInfoEl(), mkvTracksEl())),
+			want: &Info{Container: "mkv", Duration: 12500 * time.Millisecond, Tracks: mkvTracksWant},
+		},
+		{
+			name: "segment larger than file",
+			data: cat(mkvHeader("matroska"), elSized(mkvSegment, 1<<30, mkvInfoEl(), mkvTracksEl())),
+			want: &Info{Container: "mkv", Duration: 12500 * time.Millisecond, Tracks: mkvTracksWant},
+		},
+		{
+			name: "tracks larger than file",
+			data: cat(mkvHeader("matroska"), el(mkvSegment, mkvInfoEl(),
+				elSized(mkvTracks, 1<<20, mkvEntries()))),
+			want: &Info{Container: "mkv", Duration: 12500 * time.Millisecond, Tracks: mkvTracksWant},
+		},
+		{
+			name: "info element with invalid vint",
+			data: cat(mkvHeader("matroska"), el(mkvSegment, el(mkvInfo, []byte{0x00, 0x00}), mkvTracksEl())),
+			want: &Info{Container: "mkv", Tracks: mkvTracksWant},
+		},
+		{
+			name: "empty track entry is skipped",
+			data: cat(mkvHeader("matroska"), el(mkvSegment, mkvInfoEl(), el(mkvTracks, el(mkvTrackEntry)))),
+			want: &Info{Container: "mkv", Duration: 12500 * time.Millisecond},
+		},
+		{
+			name:    "header larger than file",
+			data:    cat(elSized(ebmlHeader, 1<<20, strEl(ebmlDocType, "matroska"))),
+			wantErr: true,
+		},
+		{
+			name:    "segment missing",
+			data:    cat(mkvHeader("matroska"), mkvInfoEl()),
+			wantErr: true,
+		},
+	})
+}
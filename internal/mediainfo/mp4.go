@@ -0,0 +1,435 @@
+package mediainfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// ---- ISO-BMFF (MP4 / MOV) ----
+
+type box struct {
+	typ   string
+	start int64 // payload start
+	end   int64
+}
+
+// boxes lists the child boxes in [start, end).
+func boxes(r io.ReaderAt, start, end int64) []box {
+	var out []box
+	hdr := make([]byte, 16)
+
+	for off := start; off+8 <= end; {
+		if _, err := r.ReadAt(hdr[:8], off); err != nil {
+			break
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[0:4]))
+		typ := string(hdr[4:8])
+		payload := off + 8
+
+		switch size {
+		case 0: // to end of parent
+			size = end - off
+		case 1: // 64-bit largesize
+			if _, err := r.ReadAt(hdr[8:16], off+8); err != nil {
+				return out
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[8:16]))
+			payload = off + 16
+		}
+		// header must fit, and off+size must not overflow past end
+		if size < payload-off || size > end-off {
+			break
+		}
+
+		out = append(out, box{typ: typ, start: payload, end: off + size})
+		off += size
+	}
+	return out
+}
+
+func child(r io.ReaderAt, parent box, typ string) (box, bool) {
+	for _, b := range boxes(r, parent.start, parent.end) {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return box{}, false
+}
+
+func readBox(r io.ReaderAt, b box, max int64) []byte {
+	n := b.end - b.start
+	if n > max {
+		n = max
+	}
+	if n < 0 {
+		n = 0
+	}
+	buf := make([]byte, n)
+	k, _ := r.ReadAt(buf, b.start)
+	return buf[:k]
+}
+
+var mp4Codecs = map[string]string{
+	"avc1": "h264", "avc3": "h264",
+	"hvc1": "hevc", "hev1": "hevc",
+	"mp4v": "mpeg4",
+	"av01": "av1",
+	"vp09": "vp9",
+	"mp4a": "aac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"Opus": "opus",
+	"fLaC": "flac",
+	".mp3": "mp3",
+	"alac": "alac",
+	"tx3g": "mov_text",
+	"wvtt": "webvtt",
+}
+
+func parseMP4(r io.ReaderAt, size int64) (*Info, error) {
+	var moov box
+	found := false
+	for _, b := range boxes(r, 0, size) {
+		if b.typ == "moov" {
+			moov, found = b, true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.New("mp4: moov box not found")
+	}
+
+	info := &Info{Container: "mp4"}
+
+	// ---- mvhd: movie duration ----
+	if mvhd, ok := child(r, moov, "mvhd"); ok {
+		info.Duration = mp4Duration(readBox(r, mvhd, 32), 12)
+	}
+
+	// ---- mvex/mehd: fragmented files carry duration here ----
+	if info.Duration == 0 {
+		if mvex, ok := child(r, moov, "mvex"); ok {
+			if mehd, ok := child(r, mvex, "mehd"); ok {
+				b := readBox(r, mehd, 12)
+				info.Duration = mehdDuration(b, mvhdTimescale(r, moov))
+			}
+		}
+	}
+
+	// ---- trak ----
+	scales := map[int]uint64{}
+	for _, trak := range boxes(r, moov.start, moov.end) {
+		if trak.typ != "trak" {
+			continue
+		}
+		t, d, scale, ok := mp4Track(r, trak)
+		if !ok {
+			continue
+		}
+		if d > info.Duration {
+			info.Duration = d
+		}
+		scales[t.ID] = scale
+		info.Tracks = append(info.Tracks, t)
+	}
+
+	// ---- fragmented without mehd: walk moof/traf ----
+	if info.Duration == 0 {
+		info.Duration = fragmentDuration(r, size, moov, scales)
+	}
+
+	return info, nil
+}
+
+// fragmentDuration sums sample durations across all movie fragments.
+func fragmentDuration(r io.ReaderAt, size int64, moov box, scales map[int]uint64) time.Duration {
+	// trex default_sample_duration per track
+	defaults := map[int]uint32{}
+	if mvex, ok := child(r, moov, "mvex"); ok {
+		for _, trex := range boxes(r, mvex.start, mvex.end) {
+			if trex.typ != "trex" {
+				continue
+			}
+			b := readBox(r, trex, 16)
+			if len(b) >= 16 {
+				id := int(binary.BigEndian.Uint32(b[4:8]))
+				defaults[id] = binary.BigEndian.Uint32(b[12:16])
+			}
+		}
+	}
+
+	ends := map[int]uint64{}
+	for _, moof := range boxes(r, 0, size) {
+		if moof.typ != "moof" {
+			continue
+		}
+		for _, traf := range boxes(r, moof.start, moof.end) {
+			if traf.typ != "traf" {
+				continue
+			}
+			id, end := trafEnd(r, traf, defaults, ends)
+			if id != 0 {
+				ends[id] = end
+			}
+		}
+	}
+
+	var max time.Duration
+	for id, end := range ends {
+		if d := scaled(end, scales[id]); d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// trafEnd returns the track ID and the decode time at the end of the fragment.
+func trafEnd(r io.ReaderAt, traf box, defaults map[int]uint32, ends map[int]uint64) (int, uint64) {
+	tfhd, ok := child(r, traf, "tfhd")
+	if !ok {
+		return 0, 0
+	}
+	b := readBox(r, tfhd, 32)
+	if len(b) < 8 {
+		return 0, 0
+	}
+	flags := binary.BigEndian.Uint32(b[0:4]) & 0xFFFFFF
+	id := int(binary.BigEndian.Uint32(b[4:8]))
+
+	defDur := defaults[id]
+	off := 8
+	if flags&0x01 != 0 {
+		off += 8 // base_data_offset
+	}
+	if flags&0x02 != 0 {
+		off += 4 // sample_description_index
+	}
+	if flags&0x08 != 0 && len(b) >= off+4 {
+		defDur = binary.BigEndian.Uint32(b[off : off+4])
+	}
+
+	start := ends[id]
+	if tfdt, ok := child(r, traf, "tfdt"); ok {
+		tb := readBox(r, tfdt, 12)
+		if len(tb) >= 12 && tb[0] == 1 {
+			start = binary.BigEndian.Uint64(tb[4:12])
+		} else if len(tb) >= 8 {
+			start = uint64(binary.BigEndian.Uint32(tb[4:8]))
+		}
+	}
+
+	end := start
+	for _, trun := range boxes(r, traf.start, traf.end) {
+		if trun.typ != "trun" {
+			continue
+		}
+		tb := readBox(r, trun, trun.end-trun.start)
+		if len(tb) < 8 {
+			continue
+		}
+		tflags := binary.BigEndian.Uint32(tb[0:4]) & 0xFFFFFF
+		count := int(binary.BigEndian.Uint32(tb[4:8]))
+
+		p := 8
+		if tflags&0x01 != 0 {
+			p += 4 // data_offset
+		}
+		if tflags&0x04 != 0 {
+			p += 4 // first_sample_flags
+		}
+
+		if tflags&0x100 == 0 {
+			end += uint64(count) * uint64(defDur)
+			continue
+		}
+
+		stride := 4
+		for _, f := range []uint32{0x200, 0x400, 0x800} {
+			if tflags&f != 0 {
+				stride += 4
+			}
+		}
+		for i := 0; i < count && p+4 <= len(tb); i++ {
+			end += uint64(binary.BigEndian.Uint32(tb[p : p+4]))
+			p += stride
+		}
+	}
+
+	return id, end
+}
+
+// mp4Duration reads a version 0/1 [m]vhd/mdhd payload.
+// tsOff0 is the timescale offset for version 0.
+func mp4Duration(b []byte, tsOff0 int) time.Duration {
+	if len(b) < 4 {
+		return 0
+	}
+	var scale, dur uint64
+	if b[0] == 1 {
+		if len(b) < 32 {
+			return 0
+		}
+		scale = uint64(binary.BigEndian.Uint32(b[20:24]))
+		dur = binary.BigEndian.Uint64(b[24:32])
+	} else {
+		if len(b) < tsOff0+8 {
+			return 0
+		}
+		scale = uint64(binary.BigEndian.Uint32(b[tsOff0 : tsOff0+4]))
+		dur = uint64(binary.BigEndian.Uint32(b[tsOff0+4 : tsOff0+8]))
+	}
+	return scaled(dur, scale)
+}
+
+func mvhdTimescale(r io.ReaderAt, moov box) uint64 {
+	mvhd, ok := child(r, moov, "mvhd")
+	if !ok {
+		return 0
+	}
+	b := readBox(r, mvhd, 24)
+	if len(b) >= 24 && b[0] == 1 {
+		return uint64(binary.BigEndian.Uint32(b[20:24]))
+	}
+	if len(b) >= 16 {
+		return uint64(binary.BigEndian.Uint32(b[12:16]))
+	}
+	return 0
+}
+
+func mehdDuration(b []byte, scale uint64) time.Duration {
+	if len(b) < 8 {
+		return 0
+	}
+	if b[0] == 1 && len(b) >= 12 {
+		return scaled(binary.BigEndian.Uint64(b[4:12]), scale)
+	}
+	return scaled(uint64(binary.BigEndian.Uint32(b[4:8])), scale)
+}
+
+func scaled(v, scale uint64) time.Duration {
+	if scale == 0 || v == 0 || v == ^uint64(0) || v == 0xFFFFFFFF {
+		return 0
+	}
+	return time.Duration(float64(v) / float64(scale) * float64(time.Second))
+}
+
+func mp4Track(r io.ReaderAt, trak box) (Track, time.Duration, uint64, bool) {
+	var t Track
+
+	if tkhd, ok := child(r, trak, "tkhd"); ok {
+		b := readBox(r, tkhd, 24)
+		if len(b) >= 24 && b[0] == 1 {
+			t.ID = int(binary.BigEndian.Uint32(b[20:24]))
+		} else if len(b) >= 16 {
+			t.ID = int(binary.BigEndian.Uint32(b[12:16]))
+		}
+	}
+
+	mdia, ok := child(r, trak, "mdia")
+	if !ok {
+		return t, 0, 0, false
+	}
+
+	// ---- hdlr: track kind ----
+	hdlr, ok := child(r, mdia, "hdlr")
+	if !ok {
+		return t, 0, 0, false
+	}
+	hb := readBox(r, hdlr, 12)
+	if len(hb) < 12 {
+		return t, 0, 0, false
+	}
+	switch string(hb[8:12]) {
+	case "vide":
+		t.Kind = KindVideo
+	case "soun":
+		t.Kind = KindAudio
+	case "sbtl", "subt", "text":
+		t.Kind = KindSubtitle
+	default:
+		return t, 0, 0, false
+	}
+
+	// ---- mdhd: duration + language ----
+	var (
+		dur   time.Duration
+		scale uint64
+	)
+	if mdhd, ok := child(r, mdia, "mdhd"); ok {
+		b := readBox(r, mdhd, 36)
+		dur = mp4Duration(b, 12)
+
+		if len(b) >= 24 && b[0] == 1 {
+			scale = uint64(binary.BigEndian.Uint32(b[20:24]))
+		} else if len(b) >= 16 {
+			scale = uint64(binary.BigEndian.Uint32(b[12:16]))
+		}
+
+		langOff := 20
+		if len(b) > 0 && b[0] == 1 {
+			langOff = 32
+		}
+		if len(b) >= langOff+2 {
+			t.Language = mp4Language(binary.BigEndian.Uint16(b[langOff : langOff+2]))
+		}
+	}
+
+	// ---- stsd: codec + dimensions / channels ----
+	minf, ok := child(r, mdia, "minf")
+	if !ok {
+		return t, dur, scale, true
+	}
+	stbl, ok := child(r, minf, "stbl")
+	if !ok {
+		return t, dur, scale, true
+	}
+	stsd, ok := child(r, stbl, "stsd")
+	if !ok {
+		return t, dur, scale, true
+	}
+
+	b := readBox(r, stsd, 64)
+	if len(b) < 16 {
+		return t, dur, scale, true
+	}
+	entry := b[8:] // skip version/flags + entry_count
+	format := string(entry[4:8])
+
+	t.Codec = mp4Codecs[format]
+	if t.Codec == "" {
+		t.Codec = format
+	}
+
+	switch t.Kind {
+	case KindVideo:
+		if len(entry) >= 36 {
+			t.Width = int(binary.BigEndian.Uint16(entry[32:34]))
+			t.Height = int(binary.BigEndian.Uint16(entry[34:36]))
+		}
+	case KindAudio:
+		if len(entry) >= 36 {
+			t.Channels = int(binary.BigEndian.Uint16(entry[24:26]))
+			t.SampleRate = int(binary.BigEndian.Uint32(entry[32:36]) >> 16)
+		}
+	}
+
+	return t, dur, scale, true
+}
+
+// mp4Language unpacks the ISO-639-2/T code (3 x 5 bits).
+func mp4Language(v uint16) string {
+	if v == 0 || v == 0x7FFF {
+		return ""
+	}
+	b := []byte{
+		byte((v>>10)&0x1F) + 0x60,
+		byte((v>>5)&0x1F) + 0x60,
+		byte(v&0x1F) + 0x60,
+	}
+	if string(b) == "und" {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,195 @@
+package mediainfo
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// ---- MP4 fixtures ----
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := cat(payload...)
+	return cat(be32(uint32(8+len(body))), []byte(typ), body)
+}
+
+// largeBox writes a box with size=1 and the given 64-bit largesize.
+func largeBox(typ string, size uint64, payload ...[]byte) []byte {
+	return cat(be32(1), []byte(typ), be64(size), cat(payload...))
+}
+
+func packLang(s string) uint16 {
+	return uint16(s[0]-0x60)<<10 | uint16(s[1]-0x60)<<5 | uint16(s[2]-0x60)
+}
+
+func mvhd(scale, dur uint32) []byte {
+	return mp4Box("mvhd", be32(0), be32(0), be32(0), be32(scale), be32(dur), make([]byte, 12))
+}
+
+func mvhdV1(scale uint32, dur uint64) []byte {
+	return mp4Box("mvhd", be32(1<<24), be64(0), be64(0), be32(scale), be64(dur))
+}
+
+func videoEntry(format string, w, h uint16) []byte {
+	return cat(be32(86), []byte(format), make([]byte, 24), be16(w), be16(h), make([]byte, 16))
+}
+
+func audioEntry(format string, ch uint16, rate uint32) []byte {
+	return cat(be32(36), []byte(format), make([]byte, 16), be16(ch), make([]byte, 6), be32(rate<<16))
+}
+
+func trak(id uint32, handler string, scale, dur uint32, lang string, entry []byte) []byte {
+	var l uint16
+	if lang != "" {
+		l = packLang(lang)
+	}
+	return mp4Box("trak",
+		mp4Box("tkhd", be32(0), be32(0), be32(0), be32(id), make([]byte, 8)),
+		mp4Box("mdia",
+			mp4Box("mdhd", be32(0), be32(0), be32(0), be32(scale), be32(dur), be16(l), be16(0)),
+			mp4Box("hdlr", be32(0), be32(0), []byte(handler), make([]byte, 12)),
+			mp4Box("minf", mp4Box("stbl", mp4Box("stsd", be32(0), be32(1), entry))),
+		),
+	)
+}
+
+func ftyp() []byte {
+	return mp4Box("ftyp", []byte("isom"), be32(512), []byte("isomavc1"))
+}
+
+func mp4Moov(head []byte) []byte {
+	return mp4Box("moov",
+		head,
+		trak(1, "vide", 90000, 90000*90, "", videoEntry("avc1", 1920, 1080)),
+		trak(2, "soun", 48000, 48000*90, "eng", audioEntry("mp4a", 2, 48000)),
+	)
+}
+
+func mp4File() []byte {
+	return cat(ftyp(), mp4Moov(mvhd(1000, 90500)), mp4Box("mdat", make([]byte, 64)))
+}
+
+func mp4Fragmented() []byte {
+	moof := func(seq uint32) []byte {
+		return mp4Box("moof",
+			mp4Box("mfhd", be32(0), be32(seq)),
+			mp4Box("traf",
+				mp4Box("tfhd", be32(0), be32(1)),
+				mp4Box("trun", be32(0), be32(5)),
+			),
+		)
+	}
+	return cat(
+		ftyp(),
+		mp4Box("moov",
+			mvhd(1000, 0),
+			trak(1, "vide", 1000, 0, "", videoEntry("avc1", 640, 360)),
+			mp4Box("mvex", mp4Box("trex", be32(0), be32(1), be32(1), be32(1000), be32(0), be32(0))),
+		),
+		moof(1), mp4Box("mdat", make([]byte, 8)),
+		moof(2), mp4Box("mdat", make([]byte, 8)),
+	)
+}
+
+var (
+	mp4Video = Track{ID: 1, Kind: KindVideo, Codec: "h264", Width: 1920, Height: 1080}
+	mp4Audio = Track{ID: 2, Kind: KindAudio, Codec: "aac", Language: "eng", Channels: 2, SampleRate: 48000}
+)
+
+func TestParseMP4(t *testing.T) {
+	cases := []parseCase{
+		{
+			name: "video and audio",
+			data: mp4File(),
+			want: &Info{Container: "mp4", Duration: 90500 * time.Millisecond, Tracks: []Track{mp4Video, mp4Audio}},
+		},
+		{
+			name: "audio only is m4a",
+			data: cat(ftyp(), mp4Box("moov", mvhd(1000, 3000),
+				trak(1, "soun", 44100, 44100*3, "und", audioEntry("mp4a", 1, 44100)))),
+			want: &Info{Container: "m4a", Duration: 3 * time.Second, Tracks: []Track{
+				{ID: 1, Kind: KindAudio, Codec: "aac", Channels: 1, SampleRate: 44100},
+			}},
+		},
+		{
+			name: "mvhd version 1",
+			data: cat(ftyp(), mp4Moov(mvhdV1(600, 600*100))),
+			want: &Info{Container: "mp4", Duration: 100 * time.Second, Tracks: []Track{mp4Video, mp4Audio}},
+		},
+		{
+			name: "unknown sample entry keeps fourcc",
+			data: cat(ftyp(), mp4Box("moov", mvhd(1000, 1000),
+				trak(7, "vide", 1000, 1000, "", videoEntry("xyzw", 320, 240)))),
+			want: &Info{Container: "mp4", Duration: time.Second, Tracks: []Track{
+				{ID: 7, Kind: KindVideo, Codec: "xyzw", Width: 320, Height: 240},
+			}},
+		},
+		{
+			name: "fragmented without mehd",
+			data: mp4Fragmented(),
+			want: &Info{Container: "mp4", Duration: 10 * time.Second, Tracks: []Track{
+				{ID: 1, Kind: KindVideo, Codec: "h264", Width: 640, Height: 360},
+			}},
+		},
+		{
+			name: "valid largesize before moov",
+			data: cat(ftyp(), largeBox("mdat", 16+32, make([]byte, 32)), mp4Moov(mvhd(1000, 90500))),
+			want: &Info{Container: "mp4", Duration: 90500 * time.Millisecond, Tracks: []Track{mp4Video, mp4Audio}},
+		},
+		{
+			name: "size zero runs to end of file",
+			data: cat(ftyp(), mp4Moov(mvhd(1000, 90500)), be32(0), []byte("mdat"), make([]byte, 16)),
+			want: &Info{Container: "mp4", Duration: 90500 * time.Millisecond, Tracks: []Track{mp4Video, mp4Audio}},
+		},
+		{
+			name: "trak larger than moov is dropped",
+			data: cat(ftyp(), mp4Box("moov", mvhd(1000, 2000), be32(4096), []byte("trak"), make([]byte, 16))),
+			want: &Info{Container: "mp4", Duration: 2 * time.Second},
+		},
+		{
+			name:    "no moov",
+			data:    cat(ftyp(), mp4Box("mdat", make([]byte, 64))),
+			wantErr: true,
+		},
+		{
+			name:    "moov larger than file",
+			data:    cat(ftyp(), be32(1<<20), []byte("moov"), mvhd(1000, 1000)),
+			wantErr: true,
+		},
+		{
+			name:    "box smaller than its header",
+			data:    cat(ftyp(), be32(4), []byte("free"), mp4Moov(mvhd(1000, 1000))),
+			wantErr: true,
+		},
+		{
+			name:    "largesize near MaxInt64",
+			data:    cat(ftyp(), largeBox("moov", math.MaxInt64-4, mvhd(1000, 1000))),
+			wantErr: true,
+		},
+		{
+			name:    "largesize overflowing int64",
+			data:    cat(ftyp(), largeBox("moov", math.MaxUint64, mvhd(1000, 1000))),
+			wantErr: true,
+		},
+	}
+
+	// a largesize below the 16-byte header must be rejected, not read
+	// as a box whose payload starts after its end
+	for size := range uint64(16) {
+		cases = append(cases, parseCase{
+			name:    fmt.Sprintf("largesize %d", size),
+			data:    cat(ftyp(), largeBox("moov", size, mvhd(1000, 1000), make([]byte, 32))),
+			wantErr: true,
+		})
+	}
+
+	runParseCases(t, cases)
+}
+
+func TestReadBoxNegative(t *testing.T) {
+	if b := readBox(bytes.NewReader(nil), box{start: 16, end: 8}, 64); len(b) != 0 {
+		t.Fatalf("got %d bytes for an inverted box", len(b))
+	}
+}
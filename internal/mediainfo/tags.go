@@ -0,0 +1,426 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// ---- Track tags & embedded cover art ----
+
+type Tags struct {
+	Title  string
+	Artist string
+	Album  string
+	Genre  string
+	Track  string
+
+	Picture     []byte
+	PictureMime string
+}
+
+const maxPicture = 16 << 20
+
+// ReadTags extracts title/artist/album and the first embedded picture
+// from ID3v2 (MP3/AAC), FLAC, Ogg Vorbis/Opus, MP4/M4A and WAV files.
+// Missing tags are not an error; the result is empty instead.
+func ReadTags(path string) (*Tags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readTags(f, st.Size()), nil
+}
+
+func readTags(r io.ReaderAt, size int64) *Tags {
+	t := &Tags{}
+
+	head := make([]byte, 12)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")):
+		id3Tags(r, t)
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		flacTags(r, t)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		oggTags(r, t)
+	case bytes.HasPrefix(head, []byte("RIFF")):
+		wavTags(r, size, t)
+	case len(head) >= 8 && isBoxType(head[4:8]):
+		mp4Tags(r, size, t)
+	}
+	return t
+}
+
+// ---- ID3v2 ----
+
+func id3Tags(r io.ReaderAt, t *Tags) {
+	h := make([]byte, 10)
+	if _, err := r.ReadAt(h, 0); err != nil {
+		return
+	}
+	major := h[3]
+	size := int64(syncsafe(h[6:10]))
+
+	body := make([]byte, size)
+	n, _ := r.ReadAt(body, 10)
+	body = body[:n]
+
+	if h[5]&0x80 != 0 && major < 4 {
+		body = unsync(body)
+	}
+
+	p := 0
+	if h[5]&0x40 != 0 && len(body) >= 4 { // extended header
+		if major == 4 {
+			p = int(syncsafe(body[:4]))
+		} else {
+			p = int(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+	}
+
+	idLen, hdrLen := 4, 10
+	if major == 2 {
+		idLen, hdrLen = 3, 6
+	}
+
+	for p+hdrLen <= len(body) {
+		id := string(body[p : p+idLen])
+		if id[0] == 0 {
+			break
+		}
+
+		var fsize int
+		switch major {
+		case 2:
+			fsize = int(body[p+3])<<16 | int(body[p+4])<<8 | int(body[p+5])
+		case 4:
+			fsize = int(syncsafe(body[p+4 : p+8]))
+		default:
+			fsize = int(binary.BigEndian.Uint32(body[p+4 : p+8]))
+		}
+		p += hdrLen
+		if fsize <= 0 || p+fsize > len(body) {
+			break
+		}
+		frame := body[p : p+fsize]
+		p += fsize
+
+		switch id {
+		case "TIT2", "TT2":
+			t.Title = id3Text(frame)
+		case "TPE1", "TP1":
+			t.Artist = id3Text(frame)
+		case "TALB", "TAL":
+			t.Album = id3Text(frame)
+		case "TCON", "TCO":
+			t.Genre = id3Text(frame)
+		case "TRCK", "TRK":
+			t.Track = id3Text(frame)
+		case "APIC":
+			if t.Picture == nil {
+				t.PictureMime, t.Picture = apicFrame(frame)
+			}
+		case "PIC":
+			if t.Picture == nil && len(frame) > 5 {
+				enc := frame[0]
+				t.PictureMime = imageMime(string(frame[1:4]))
+				_, data := splitTerminated(frame[5:], enc)
+				t.Picture = data
+			}
+		}
+	}
+}
+
+func unsync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xFF, 0x00}, []byte{0xFF})
+}
+
+func id3Text(frame []byte) string {
+	if len(frame) < 1 {
+		return ""
+	}
+	s := decodeText(frame[1:], frame[0])
+	// v2.4 multi-value: keep the first
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func apicFrame(frame []byte) (string, []byte) {
+	if len(frame) < 4 {
+		return "", nil
+	}
+	enc := frame[0]
+	rest := frame[1:]
+
+	i := bytes.IndexByte(rest, 0)
+	if i < 0 || i+2 > len(rest) {
+		return "", nil
+	}
+	mime := imageMime(string(rest[:i]))
+	rest = rest[i+2:] // skip terminator + picture type
+
+	_, data := splitTerminated(rest, enc)
+	return mime, data
+}
+
+// splitTerminated cuts a text field terminated per the ID3 encoding.
+func splitTerminated(b []byte, enc byte) ([]byte, []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:]
+			}
+		}
+		return b, nil
+	}
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return b[:i], b[i+1:]
+	}
+	return b, nil
+}
+
+func decodeText(b []byte, enc byte) string {
+	switch enc {
+	case 0: // ISO-8859-1
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		return string(r)
+	case 1, 2: // UTF-16 with BOM / UTF-16BE
+		big := enc == 2
+		if len(b) >= 2 {
+			switch {
+			case b[0] == 0xFF && b[1] == 0xFE:
+				big, b = false, b[2:]
+			case b[0] == 0xFE && b[1] == 0xFF:
+				big, b = true, b[2:]
+			}
+		}
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			if big {
+				u[i] = binary.BigEndian.Uint16(b[2*i:])
+			} else {
+				u[i] = binary.LittleEndian.Uint16(b[2*i:])
+			}
+		}
+		return string(utf16.Decode(u))
+	}
+	return string(b)
+}
+
+func imageMime(s string) string {
+	switch strings.ToLower(s) {
+	case "png", "image/png":
+		return "image/png"
+	case "jpg", "jpeg", "image/jpg", "image/jpeg", "":
+		return "image/jpeg"
+	}
+	return strings.ToLower(s)
+}
+
+// ---- Vorbis comments (FLAC / Ogg) ----
+
+func vorbisComments(b []byte, t *Tags) {
+	if len(b) < 8 {
+		return
+	}
+	p := 4 + int(binary.LittleEndian.Uint32(b))
+	if p+4 > len(b) {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(b[p:]))
+	p += 4
+
+	for i := 0; i < count && p+4 <= len(b); i++ {
+		l := int(binary.LittleEndian.Uint32(b[p:]))
+		p += 4
+		if l < 0 || p+l > len(b) {
+			return
+		}
+		kv := string(b[p : p+l])
+		p += l
+
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(k) {
+		case "TITLE":
+			t.Title = v
+		case "ARTIST":
+			t.Artist = v
+		case "ALBUM":
+			t.Album = v
+		case "GENRE":
+			t.Genre = v
+		case "TRACKNUMBER":
+			t.Track = v
+		case "METADATA_BLOCK_PICTURE":
+			if t.Picture == nil {
+				if raw, err := base64.StdEncoding.DecodeString(v); err == nil {
+					t.PictureMime, t.Picture = flacPicture(raw)
+				}
+			}
+		}
+	}
+}
+
+// flacPicture decodes a FLAC PICTURE block body.
+func flacPicture(b []byte) (string, []byte) {
+	rd := func(p int) (int, bool) {
+		if p+4 > len(b) {
+			return 0, false
+		}
+		return int(binary.BigEndian.Uint32(b[p:])), true
+	}
+
+	p := 4 // picture type
+	ml, ok := rd(p)
+	if !ok || p+4+ml > len(b) {
+		return "", nil
+	}
+	mime := imageMime(string(b[p+4 : p+4+ml]))
+	p += 4 + ml
+
+	dl, ok := rd(p)
+	if !ok {
+		return "", nil
+	}
+	p += 4 + dl + 16 // description + width/height/depth/colors
+
+	n, ok := rd(p)
+	if !ok || p+4+n > len(b) {
+		return "", nil
+	}
+	return mime, b[p+4 : p+4+n]
+}
+
+func flacTags(r io.ReaderAt, t *Tags) {
+	flacBlocks(r, func(typ byte, start int64, length int) bool {
+		switch typ {
+		case 4:
+			vorbisComments(readAt(r, start, length), t)
+		case 6:
+			if t.Picture == nil && length <= maxPicture {
+				t.PictureMime, t.Picture = flacPicture(readAt(r, start, length))
+			}
+		}
+		return true
+	})
+}
+
+func oggTags(r io.ReaderAt, t *Tags) {
+	packets := oggPackets(r, 2)
+	if len(packets) < 2 {
+		return
+	}
+	c := packets[1]
+	switch {
+	case len(c) > 7 && string(c[1:7]) == "vorbis":
+		vorbisComments(c[7:], t)
+	case len(c) > 8 && string(c[:8]) == "OpusTags":
+		vorbisComments(c[8:], t)
+	}
+}
+
+func readAt(r io.ReaderAt, off int64, n int) []byte {
+	buf := make([]byte, n)
+	k, _ := r.ReadAt(buf, off)
+	return buf[:k]
+}
+
+// ---- MP4 / M4A (moov/udta/meta/ilst) ----
+
+func mp4Tags(r io.ReaderAt, size int64, t *Tags) {
+	var moov box
+	for _, b := range boxes(r, 0, size) {
+		if b.typ == "moov" {
+			moov = b
+		}
+	}
+	udta, ok := child(r, moov, "udta")
+	if !ok {
+		return
+	}
+	meta, ok := child(r, udta, "meta")
+	if !ok {
+		return
+	}
+	meta.start += 4 // full box
+	ilst, ok := child(r, meta, "ilst")
+	if !ok {
+		return
+	}
+
+	for _, item := range boxes(r, ilst.start, ilst.end) {
+		data, ok := child(r, item, "data")
+		if !ok || data.end-data.start < 8 {
+			continue
+		}
+		kind := binary.BigEndian.Uint32(readAt(r, data.start, 4))
+		payload := box{start: data.start + 8, end: data.end}
+
+		switch item.typ {
+		case "\xa9nam":
+			t.Title = string(readBox(r, payload, 4096))
+		case "\xa9ART", "aART":
+			if t.Artist == "" {
+				t.Artist = string(readBox(r, payload, 4096))
+			}
+		case "\xa9alb":
+			t.Album = string(readBox(r, payload, 4096))
+		case "\xa9gen":
+			t.Genre = string(readBox(r, payload, 4096))
+		case "covr":
+			if t.Picture == nil {
+				t.Picture = readBox(r, payload, maxPicture)
+				t.PictureMime = "image/jpeg"
+				if kind == 14 {
+					t.PictureMime = "image/png"
+				}
+			}
+		}
+	}
+}
+
+// ---- WAV (LIST/INFO) ----
+
+func wavTags(r io.ReaderAt, size int64, t *Tags) {
+	riffChunks(r, 12, size, func(id string, start, n int64) bool {
+		if id != "LIST" || string(readAt(r, start, 4)) != "INFO" {
+			return true
+		}
+		riffChunks(r, start+4, start+n, func(id string, s, l int64) bool {
+			v := strings.TrimRight(string(readAt(r, s, int(min(l, 4096)))), "\x00 ")
+			switch id {
+			case "INAM":
+				t.Title = v
+			case "IART":
+				t.Artist = v
+			case "IPRD":
+				t.Album = v
+			case "IGNR":
+				t.Genre = v
+			}
+			return true
+		})
+		return false
+	})
+}
@@ -0,0 +1,112 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func ilstItem(typ string, kind uint32, value []byte) []byte {
+	return mp4Box(typ, mp4Box("data", be32(kind), be32(0), value))
+}
+
+func mp4Tagged(items ...[]byte) []byte {
+	if items == nil {
+		items = [][]byte{
+			ilstItem("\xa9nam", 1, []byte("Song")),
+			ilstItem("aART", 1, []byte("Band")),
+			ilstItem("\xa9alb", 1, []byte("Record")),
+			ilstItem("covr", 14, []byte("png!")),
+		}
+	}
+	return cat(ftyp(), mp4Box("moov",
+		mvhd(1000, 1000),
+		mp4Box("udta", mp4Box("meta", be32(0), mp4Box("ilst", items...))),
+	))
+}
+
+func TestReadTags(t *testing.T) {
+	utf16 := cat([]byte{1, 0xFF, 0xFE}, []byte("B\x00a\x00n\x00d\x00"))
+
+	// frame size past the end of the tag: earlier frames are kept
+	id3Long := id3v23(
+		id3Frame("TIT2", []byte("\x00Song")),
+		cat([]byte("TPE1"), be32(1<<20), be16(0), []byte("\x00Band")),
+	)
+
+	// second comment length runs past the block
+	badComment := cat(le32(0), le32(2), le32(10), []byte("TITLE=Song"), le32(1<<30), []byte("ARTIST=Band"))
+	flacBad := cat([]byte("fLaC"), flacBlock(0, false, streamInfo()), flacBlock(4, true, badComment))
+
+	// picture mime length past the block
+	pic := flacPictureBody("image/png", []byte("png!"))
+	copy(pic[4:8], be32(1<<20))
+	flacBadPic := cat([]byte("fLaC"), flacBlock(0, false, streamInfo()), flacBlock(6, true, pic))
+
+	// Opus picture carried as METADATA_BLOCK_PICTURE
+	opusPic := cat(
+		oggPage(0, opusHead(2, 0)),
+		oggPage(0, cat([]byte("OpusTags"), vorbisCommentBlock(
+			"METADATA_BLOCK_PICTURE="+base64.StdEncoding.EncodeToString(flacPictureBody("image/jpeg", []byte("jpg!"))),
+		))),
+	)
+
+	// covr data box larger than its item
+	bigCovr := mp4Box("covr", be32(1<<20), []byte("data"), be32(13), be32(0), []byte("jpg!"))
+
+	cases := []struct {
+		name string
+		data []byte
+		want Tags
+	}{
+		{
+			name: "id3v2.3",
+			data: cat(id3v23(
+				id3Frame("TIT2", []byte("\x00Song")),
+				id3Frame("TPE1", utf16),
+				id3Frame("TALB", []byte("\x03Record")),
+				id3Frame("TRCK", []byte("\x003/12")),
+				id3Frame("APIC", cat([]byte("\x00image/png\x00\x03desc\x00"), []byte("png!"))),
+			), mp3Frame(false, 0)),
+			want: Tags{Title: "Song", Artist: "Band", Album: "Record", Track: "3/12", Picture: []byte("png!"), PictureMime: "image/png"},
+		},
+		{
+			name: "id3v2.2",
+			data: cat([]byte("ID3"), []byte{2, 0, 0}, syncsafeBytes(6+5), []byte("TT2"), []byte{0, 0, 5}, []byte("\x00Song")),
+			want: Tags{Title: "Song"},
+		},
+		{name: "id3 frame past end", data: id3Long, want: Tags{Title: "Song"}},
+		{
+			name: "flac",
+			data: flacFile(),
+			want: Tags{Title: "Song", Artist: "Band", Picture: []byte("png!"), PictureMime: "image/png"},
+		},
+		{name: "flac comment past end", data: flacBad, want: Tags{Title: "Song"}},
+		{name: "flac picture past end", data: flacBadPic},
+		{name: "ogg opus", data: oggOpus(), want: Tags{Title: "Song", Album: "Record"}},
+		{name: "ogg vorbis", data: oggVorbis(), want: Tags{Artist: "Band"}},
+		{name: "ogg opus picture", data: opusPic, want: Tags{Picture: []byte("jpg!"), PictureMime: "image/jpeg"}},
+		{
+			name: "mp4",
+			data: mp4Tagged(),
+			want: Tags{Title: "Song", Artist: "Band", Album: "Record", Picture: []byte("png!"), PictureMime: "image/png"},
+		},
+		{
+			name: "mp4 covr larger than item",
+			data: mp4Tagged(ilstItem("\xa9nam", 1, []byte("Song")), bigCovr),
+			want: Tags{Title: "Song"},
+		},
+		{name: "wav", data: wavFile(), want: Tags{Title: "Song", Artist: "Band"}},
+		{name: "mkv has none", data: mkvFile("matroska")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := readTags(bytes.NewReader(tc.data), int64(len(tc.data)))
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Fatalf("got  %+v\nwant %+v", *got, tc.want)
+			}
+		})
+	}
+}
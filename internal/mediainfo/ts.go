@@ -0,0 +1,312 @@
+package mediainfo
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ---- MPEG-TS ----
+
+const (
+	tsPacket   = 188
+	tsScanHead = 4 << 20 // bytes scanned for PAT/PMT + first PTS
+	tsScanTail = 2 << 20 // bytes scanned for last PTS
+)
+
+var tsStreamTypes = map[byte]struct {
+	kind  TrackKind
+	codec string
+}{
+	0x01: {KindVideo, "mpeg1video"},
+	0x02: {KindVideo, "mpeg2video"},
+	0x03: {KindAudio, "mp2"},
+	0x04: {KindAudio, "mp2"},
+	0x0F: {KindAudio, "aac"},
+	0x10: {KindVideo, "mpeg4"},
+	0x11: {KindAudio, "aac"},
+	0x1B: {KindVideo, "h264"},
+	0x24: {KindVideo, "hevc"},
+	0x81: {KindAudio, "ac3"},
+	0x87: {KindAudio, "eac3"},
+}
+
+type tsStream struct {
+	track    Track
+	firstPTS int64
+	lastPTS  int64
+	probed   bool // codec details read from the first PES
+}
+
+type tsParser struct {
+	pmtPID  int
+	streams map[int]*tsStream
+	order   []int
+}
+
+func parseTS(r io.ReaderAt, size int64) (*Info, error) {
+	p := &tsParser{pmtPID: -1, streams: map[int]*tsStream{}}
+
+	// ---- head: tables + first timestamps ----
+	headEnd := size
+	if headEnd > tsScanHead {
+		headEnd = tsScanHead
+	}
+	p.scan(r, 0, headEnd, true)
+
+	if len(p.streams) == 0 {
+		return nil, errors.New("ts: no PMT found")
+	}
+
+	// ---- tail: last timestamps ----
+	tailStart := size - tsScanTail
+	if tailStart < headEnd {
+		tailStart = headEnd
+	}
+	tailStart -= tailStart % tsPacket
+	if tailStart < size {
+		p.scan(r, tailStart, size, false)
+	}
+
+	info := &Info{Container: "ts"}
+	var dur time.Duration
+	for _, pid := range p.order {
+		s := p.streams[pid]
+		if s.firstPTS >= 0 && s.lastPTS > s.firstPTS {
+			d := time.Duration((s.lastPTS - s.firstPTS) * int64(time.Second) / 90000)
+			if d > dur {
+				dur = d
+			}
+		}
+		info.Tracks = append(info.Tracks, s.track)
+	}
+	info.Duration = dur
+
+	return info, nil
+}
+
+func (p *tsParser) scan(r io.ReaderAt, start, end int64, head bool) {
+	buf := make([]byte, tsPacket*512)
+
+	for off := start; off < end; {
+		n, err := r.ReadAt(buf, off)
+		if n < tsPacket {
+			return
+		}
+		for i := 0; i+tsPacket <= n; i += tsPacket {
+			p.packet(buf[i:i+tsPacket], head)
+		}
+		off += int64(n - n%tsPacket)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *tsParser) packet(pkt []byte, head bool) {
+	if pkt[0] != 0x47 {
+		return
+	}
+
+	pusi := pkt[1]&0x40 != 0
+	pid := int(pkt[1]&0x1F)<<8 | int(pkt[2])
+	afc := (pkt[3] >> 4) & 0x3
+
+	payload := pkt[4:]
+	if afc&0x2 != 0 {
+		if len(payload) < 1 || int(payload[0])+1 > len(payload) {
+			return
+		}
+		payload = payload[int(payload[0])+1:]
+	}
+	if afc&0x1 == 0 || !pusi {
+		return
+	}
+
+	switch {
+	case head && pid == 0:
+		p.pat(payload)
+	case head && pid == p.pmtPID && len(p.streams) == 0:
+		p.pmt(payload)
+	default:
+		if s, ok := p.streams[pid]; ok {
+			p.pes(s, payload, head)
+		}
+	}
+}
+
+func section(payload []byte) []byte {
+	if len(payload) < 1 {
+		return nil
+	}
+	ptr := int(payload[0])
+	if 1+ptr+3 > len(payload) {
+		return nil
+	}
+	sec := payload[1+ptr:]
+	length := int(sec[1]&0x0F)<<8 | int(sec[2])
+	if 3+length > len(sec) {
+		return nil // tables spanning packets are ignored
+	}
+	return sec[:3+length]
+}
+
+func (p *tsParser) pat(payload []byte) {
+	sec := section(payload)
+	if len(sec) < 12 || sec[0] != 0x00 {
+		return
+	}
+	// entries between header (8) and CRC (4)
+	for i := 8; i+4 <= len(sec)-4; i += 4 {
+		program := int(sec[i])<<8 | int(sec[i+1])
+		if program == 0 {
+			continue // network PID
+		}
+		p.pmtPID = int(sec[i+2]&0x1F)<<8 | int(sec[i+3])
+		return
+	}
+}
+
+func (p *tsParser) pmt(payload []byte) {
+	sec := section(payload)
+	if len(sec) < 16 || sec[0] != 0x02 {
+		return
+	}
+
+	infoLen := int(sec[10]&0x0F)<<8 | int(sec[11])
+	i := 12 + infoLen
+	end := len(sec) - 4
+
+	for i+5 <= end {
+		typ := sec[i]
+		pid := int(sec[i+1]&0x1F)<<8 | int(sec[i+2])
+		esLen := int(sec[i+3]&0x0F)<<8 | int(sec[i+4])
+		desc := sec[i+5:]
+		if esLen <= len(desc) {
+			desc = desc[:esLen]
+		}
+		i += 5 + esLen
+
+		t, ok := tsTrack(typ, desc)
+		if !ok {
+			continue
+		}
+		t.ID = pid
+		p.streams[pid] = &tsStream{track: t, firstPTS: -1, lastPTS: -1}
+		p.order = append(p.order, pid)
+	}
+}
+
+func tsTrack(typ byte, desc []byte) (Track, bool) {
+	var t Track
+	if st, ok := tsStreamTypes[typ]; ok {
+		t.Kind, t.Codec = st.kind, st.codec
+	}
+
+	for j := 0; j+2 <= len(desc); {
+		tag, l := desc[j], int(desc[j+1])
+		if j+2+l > len(desc) {
+			break
+		}
+		body := desc[j+2 : j+2+l]
+		j += 2 + l
+
+		switch tag {
+		case 0x0A: // ISO_639_language
+			if len(body) >= 3 {
+				t.Language = string(body[:3])
+			}
+		case 0x6A: // AC-3
+			t.Kind, t.Codec = KindAudio, "ac3"
+		case 0x7A: // E-AC-3
+			t.Kind, t.Codec = KindAudio, "eac3"
+		case 0x7B: // DTS
+			t.Kind, t.Codec = KindAudio, "dts"
+		case 0x59: // DVB subtitles
+			t.Kind, t.Codec = KindSubtitle, "dvb_subtitle"
+			if len(body) >= 3 {
+				t.Language = string(body[:3])
+			}
+		case 0x56: // teletext
+			t.Kind, t.Codec = KindSubtitle, "dvb_teletext"
+			if len(body) >= 3 {
+				t.Language = string(body[:3])
+			}
+		}
+	}
+
+	if t.Language == "und" {
+		t.Language = ""
+	}
+	return t, t.Codec != ""
+}
+
+func (p *tsParser) pes(s *tsStream, payload []byte, head bool) {
+	if len(payload) < 9 || payload[0] != 0 || payload[1] != 0 || payload[2] != 1 {
+		return
+	}
+
+	flags := payload[7]
+	hdrLen := int(payload[8])
+	if flags&0x80 != 0 && len(payload) >= 14 {
+		pts := int64(payload[9]&0x0E)<<29 |
+			int64(payload[10])<<22 |
+			int64(payload[11]&0xFE)<<14 |
+			int64(payload[12])<<7 |
+			int64(payload[13])>>1
+
+		if head && s.firstPTS < 0 {
+			s.firstPTS = pts
+		}
+		if !head || s.lastPTS < pts {
+			s.lastPTS = pts
+		}
+	}
+
+	if !head || s.probed || 9+hdrLen > len(payload) {
+		return
+	}
+	es := payload[9+hdrLen:]
+
+	switch s.track.Codec {
+	case "aac":
+		s.probed = adtsInfo(es, &s.track)
+	case "mpeg2video", "mpeg1video":
+		s.probed = mpegSeqInfo(es, &s.track)
+	case "h264":
+		s.probed = h264Info(es, &s.track)
+	default:
+		s.probed = true
+	}
+}
+
+var adtsRates = []int{
+	96000, 88200, 64000, 48000, 44100, 32000,
+	24000, 22050, 16000, 12000, 11025, 8000, 7350,
+}
+
+func adtsInfo(es []byte, t *Track) bool {
+	if len(es) < 4 || es[0] != 0xFF || es[1]&0xF0 != 0xF0 {
+		return false
+	}
+	if idx := int(es[2]>>2) & 0x0F; idx < len(adtsRates) {
+		t.SampleRate = adtsRates[idx]
+	}
+	ch := int(es[2]&0x01)<<2 | int(es[3]>>6)
+	if ch == 7 {
+		ch = 8
+	}
+	t.Channels = ch
+	return true
+}
+
+func mpegSeqInfo(es []byte, t *Track) bool {
+	for i := 0; i+7 < len(es); i++ {
+		if es[i] == 0 && es[i+1] == 0 && es[i+2] == 1 && es[i+3] == 0xB3 {
+			t.Width = int(es[i+4])<<4 | int(es[i+5]>>4)
+			t.Height = int(es[i+5]&0x0F)<<8 | int(es[i+6])
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package mediainfo
+
+import (
+	"testing"
+	"time"
+)
+
+// ---- MPEG-TS fixtures ----
+
+type bitWriter struct {
+	b []byte
+	n int
+}
+
+func (w *bitWriter) bit(v uint) {
+	if w.n%8 == 0 {
+		w.b = append(w.b, 0)
+	}
+	if v != 0 {
+		w.b[len(w.b)-1] |= 0x80 >> (w.n % 8)
+	}
+	w.n++
+}
+
+func (w *bitWriter) bits(v uint, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bit(v >> i & 1)
+	}
+}
+
+func (w *bitWriter) ue(v uint) {
+	v++
+	n := 0
+	for x := v; x > 1; x >>= 1 {
+		n++
+	}
+	w.bits(0, n)
+	w.bits(v, n+1)
+}
+
+// baselineSPS encodes a profile 66 SPS NAL unit for a 1920x1080 frame.
+func baselineSPS() []byte {
+	w := &bitWriter{}
+	w.bits(66, 8)  // profile
+	w.bits(40, 16) // constraint flags + level
+	w.ue(0)        // seq_parameter_set_id
+	w.ue(0)        // log2_max_frame_num
+	w.ue(2)        // pic_order_cnt_type
+	w.ue(1)        // max_num_ref_frames
+	w.bit(0)       // gaps
+	w.ue(119)      // width in MBs - 1
+	w.ue(67)       // height in map units - 1
+	w.bit(1)       // frame_mbs_only
+	w.bit(1)       // direct_8x8_inference
+	w.bit(1)       // frame_cropping
+	w.ue(0)
+	w.ue(0)
+	w.ue(0)
+	w.ue(4) // 1088 - 2*4
+	w.bit(1)
+	return cat([]byte{0, 0, 0, 1, 0x67}, w.b)
+}
+
+func tsPacketOf(pid int, pusi bool, payload []byte) []byte {
+	p := make([]byte, tsPacket)
+	for i := range p {
+		p[i] = 0xFF
+	}
+	p[0] = 0x47
+	p[1] = byte(pid >> 8 & 0x1F)
+	if pusi {
+		p[1] |= 0x40
+	}
+	p[2] = byte(pid)
+	p[3] = 0x10 // payload only
+	copy(p[4:], payload)
+	return p
+}
+
+// psi wraps a section body (after section_length) with a pointer
+// field, table id, length and a dummy CRC.
+func psi(table byte, body []byte) []byte {
+	n := len(body) + 4
+	return cat([]byte{0, table, 0xB0 | byte(n>>8), byte(n)}, body, be32(0))
+}
+
+func patSection(pmtPID int) []byte {
+	return psi(0x00, cat(
+		be16(1), []byte{0xC1, 0, 0},
+		be16(1), be16(0xE000|uint16(pmtPID)),
+	))
+}
+
+func pmtStream(typ byte, pid int, desc []byte) []byte {
+	return cat([]byte{typ}, be16(0xE000|uint16(pid)), be16(0xF000|uint16(len(desc))), desc)
+}
+
+func pmtSection(streams ...[]byte) []byte {
+	return psi(0x02, cat(
+		be16(1), []byte{0xC1, 0, 0},
+		be16(0xE100), be16(0xF000),
+		cat(streams...),
+	))
+}
+
+func pes(pts int64, es []byte) []byte {
+	return cat(
+		[]byte{0, 0, 1, 0xE0, 0, 0, 0x80, 0x80, 5},
+		[]byte{
+			0x21 | byte(pts>>29)&0x0E,
+			byte(pts >> 22),
+			byte(pts>>14) | 1,
+			byte(pts >> 7),
+			byte(pts<<1) | 1,
+		},
+		es,
+	)
+}
+
+var adtsHeader = []byte{0xFF, 0xF1, 0x4C, 0x80, 0x02, 0x1F, 0xFC} // 48 kHz stereo
+
+func tsStreams() [][]byte {
+	return [][]byte{
+		pmtStream(0x1B, 0x100, nil),
+		pmtStream(0x0F, 0x101, []byte{0x0A, 4, 'e', 'n', 'g', 0}),
+		pmtStream(0x06, 0x102, []byte{0x6A, 1, 0}),
+	}
+}
+
+func tsFile() []byte {
+	return cat(
+		tsPacketOf(0, true, patSection(0x1000)),
+		tsPacketOf(0x1000, true, pmtSection(tsStreams()...)),
+		tsPacketOf(0x100, true, pes(90000, baselineSPS())),
+		tsPacketOf(0x101, true, pes(90000, adtsHeader)),
+		tsPacketOf(0x102, true, pes(90000, nil)),
+		tsPacketOf(0x100, true, pes(90000*11, nil)),
+		tsPacketOf(0x101, true, pes(90000*11, adtsHeader)),
+	)
+}
+
+var tsTracksWant = []Track{
+	{ID: 0x100, Kind: KindVideo, Codec: "h264", Width: 1920, Height: 1080},
+	{ID: 0x101, Kind: KindAudio, Codec: "aac", Language: "eng", Channels: 2, SampleRate: 48000},
+	{ID: 0x102, Kind: KindAudio, Codec: "ac3"},
+}
+
+func TestParseTS(t *testing.T) {
+	// PAT behind an adaptation field claiming more than the packet holds
+	badAF := tsPacketOf(0, true, nil)
+	badAF[3] = 0x30
+	badAF[4] = 200
+
+	// PAT whose section_length runs past the packet
+	longPAT := patSection(0x1000)
+	longPAT[3] = 0xFF
+
+	// first stream's ES_info_length runs past the section
+	streams := tsStreams()
+	streams[0] = cat([]byte{0x1B}, be16(0xE100), be16(0xF3FF))
+
+	runParseCases(t, []parseCase{
+		{
+			name: "h264 aac ac3",
+			data: tsFile(),
+			want: &Info{Container: "ts", Duration: 10 * time.Second, Tracks: tsTracksWant},
+		},
+		{
+			name: "no PES timestamps",
+			data: cat(
+				tsPacketOf(0, true, patSection(0x1000)),
+				tsPacketOf(0x1000, true, pmtSection(tsStreams()...)),
+				tsPacketOf(0x1FFF, false, nil),
+			),
+			want: &Info{Container: "ts", Tracks: []Track{
+				{ID: 0x100, Kind: KindVideo, Codec: "h264"},
+				{ID: 0x101, Kind: KindAudio, Codec: "aac", Language: "eng"},
+				tsTracksWant[2],
+			}},
+		},
+		{
+			name: "ES info past section end",
+			data: cat(
+				tsPacketOf(0, true, patSection(0x1000)),
+				tsPacketOf(0x1000, true, pmtSection(streams...)),
+				tsPacketOf(0x100, true, pes(90000, baselineSPS())),
+			),
+			want: &Info{Container: "ts", Tracks: []Track{tsTracksWant[0]}},
+		},
+		{
+			name: "adaptation field too long",
+			data: cat(
+				badAF,
+				tsPacketOf(0x1000, true, pmtSection(tsStreams()...)),
+				tsPacketOf(0x1FFF, false, nil),
+			),
+			wantErr: true,
+		},
+		{
+			name: "section length too long",
+			data: cat(
+				tsPacketOf(0, true, longPAT),
+				tsPacketOf(0x1000, true, pmtSection(tsStreams()...)),
+				tsPacketOf(0x1FFF, false, nil),
+			),
+			wantErr: true,
+		},
+	})
+}
@@ -4,13 +4,14 @@ import "time"
 
 type Config struct {
 	Interactive bool
-	Mode        string // "auto" | "manual"
+	Mode        string // "auto" | "manual" | "scan" | "stream" | "slideshow" | "daemon"
 
 	Verbose        bool
 	ReportFile     bool
 	ReportFileName string
 
 	SelectCache  int
+	Device       string // cached device by alias, name, UDN, IP or index
 	CacheDetails int
 	AutoCache    bool
 	UseCache     bool
@@ -19,6 +20,8 @@ type Config struct {
 	ShowMedia    string
 	ShowMediaAll bool
 	Showactions  bool
+	CacheTTL     time.Duration // cache check: prune devices unseen this long (0 = keep)
+	CacheRefresh bool          // cache check: re-enrich online, search offline by UDN
 
 	ProbeOnly   bool
 	Discover    bool
@@ -31,10 +34,30 @@ type Config struct {
 	TPath    string // SOAP path
 	TVVendor string // TV vendor
 
-	LIP       string // local IP
-	LFile     string // local file path (used only for MediaURL)
-	LDir      string // directory to serve
-	ServePort string // local HTTP port
+	LIP       string   // local IP
+	LFile     string   // local file path (used only for MediaURL)
+	LDir      string   // slideshow photo directory
+	ServePort string   // local HTTP port
+	Allow     []string // extra client IPs/CIDRs allowed to fetch media
+
+	StreamIdle  time.Duration // stop live pipeline after no readers for this long
+	Transcode   string        // "auto" | "off" | profile name
+	Container   string        // force stream container (required for stdin / fifo)
+	Pace        bool          // release TS packets at PCR (real-time) rate
+	PaceLead    time.Duration // how far pacing may run ahead of real time
+	Stats       bool          // periodic client/source summary on the console
+	MimeTimeout time.Duration // no client GET after this long = try the next mime (0 = off)
+
+	Resolver       string // force resolver by name ("" = per-domain rules)
+	ResolverFormat string // resolver format selector override
+	Quality        string // HLS variant: best | worst | 720p | max bandwidth
+	HLSOutput      string // "auto" | "on" | "off"
+	RadioRefresh   bool   // re-send DIDL when the radio StreamTitle changes
+
+	SlideInterval time.Duration // slideshow: time each photo stays on screen
+
+	APIAddr string // daemon: control API listen address (localhost only)
+	Remote  string // thin client: daemon API address to forward to
 
 	CachedConnMgrURL string
 	CachedControlURL string
@@ -54,6 +77,7 @@ var DefaultConfig = Config{
 	Showactions:  false,
 	AutoCache:    false,
 	UseCache:     true,
+	CacheTTL:     30 * 24 * time.Hour,
 
 	ProbeOnly:  false,
 	Mode:       "auto",
@@ -61,4 +85,20 @@ var DefaultConfig = Config{
 	LDir:       "./directory",
 	Verbose:    false,
 	ReportFile: false,
+	// Stream
+	StreamIdle:  30 * time.Second,
+	Transcode:   "auto",
+	Quality:     "best",
+	HLSOutput:   "auto",
+	PaceLead:    time.Second,
+	MimeTimeout: 15 * time.Second,
+	// Slideshow
+	SlideInterval: 8 * time.Second,
+	// Daemon
+	APIAddr: "127.0.0.1:8765",
 }
+
+// ResolvedConfig is DefaultConfig with the config file (and --profile)
+// applied. Flags are layered on top of it; flag validation and the TUI
+// reset treat it as the defaults.
+var ResolvedConfig = DefaultConfig
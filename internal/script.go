@@ -2,13 +2,55 @@ package internal
 
 import (
 	"log"
+	"net/http"
 	"renderctl/internal/avtransport"
 	"renderctl/internal/models"
+	"renderctl/internal/servers/identity"
+	"renderctl/internal/slideshow"
 	"renderctl/internal/stream"
 	"renderctl/internal/utils"
 	"renderctl/logger"
+	"renderctl/pkg/renderctl"
 )
 
+// media serves the file of the one-shot modes; ServeMedia starts it.
+var media *renderctl.MediaServer
+
+// ServeMedia starts the file server on -LPort, answering as the
+// MediaServer device renderers expect to find behind the URLs.
+func ServeMedia(cfg models.Config, stop <-chan struct{}) {
+	serverUUID, err := identity.FetchUUID()
+	if err != nil {
+		logger.Error("Failed to load server UUID: %v", err)
+	}
+
+	media, err = renderctl.NewMediaServer(renderctl.MediaServerOptions{
+		Addr:  "0.0.0.0:" + cfg.ServePort,
+		Host:  cfg.LIP,
+		Allow: cfg.Allow,
+		Logf:  logger.Info,
+	})
+	if err != nil {
+		logger.Error("HTTP server error: %v", err)
+	}
+
+	ids := http.NewServeMux()
+	identity.RegisterHandlers(ids, serverUUID)
+	for _, p := range []string{"/device.xml", "/cd/scpd.xml", "/cm/scpd.xml"} {
+		media.Handle(p, ids)
+	}
+	logger.Success("HTTP server listening: %s (published media only)", media.URL())
+
+	go identity.AnnounceMediaServer(serverUUID, media.URL()+"/device.xml")
+
+	go func() {
+		<-stop
+		logger.Notify("Shutting down HTTP server")
+		identity.AnnounceMediaServerByeBye(serverUUID)
+		_ = media.Close()
+	}()
+}
+
 func runWithConfig(cfg *models.Config) {
 	controlURL := cfg.CachedControlURL
 	if controlURL == "" {
@@ -21,23 +63,75 @@ func runWithConfig(cfg *models.Config) {
 	}
 
 	logger.Info("Control Url : %s", controlURL)
+	cast(cfg, controlURL)
+}
 
-	target := avtransport.Target{
+// cast publishes cfg.LFile (and nothing else) and plays it on the
+// renderer, with DIDL details and cover art from the file.
+func cast(cfg *models.Config, controlURL string) {
+	if media == nil {
+		logger.Error("No media server running in %s mode", cfg.Mode)
+		return
+	}
+
+	r := &renderctl.Renderer{
 		ControlURL: controlURL,
-		MediaURL:   utils.MediaURL(cfg),
+		ConnMgrURL: cfg.CachedConnMgrURL,
+		Vendor:     cfg.TVVendor,
+		Logf:       logger.Info,
+	}
+	m, err := media.Media(cfg.LFile, r)
+	if err != nil {
+		logger.Error("Cannot publish %s: %v", cfg.LFile, err)
+		return
+	}
+	if m.AlbumArtURL != "" {
+		logger.Info("Album art: %s", m.AlbumArtURL)
 	}
 
-	meta := avtransport.MetadataForVendor(cfg.TVVendor, target)
-	avtransport.Run(target, meta)
+	if err := r.Play(m); err != nil {
+		logger.Error("%v", err)
+	}
+}
+
+// trySSDP discovers renderers and stores them in the cache.
+func trySSDP(cfg *models.Config) bool {
+	logger.Notify("Running SSDP discovery scan")
+
+	d := renderctl.Discoverer{
+		Timeout: cfg.SSDPTimeout,
+		LocalIP: cfg.LIP,
+		Logf:    logger.Info,
+	}
+	devices, err := d.Discover()
+	if err != nil {
+		logger.Notify("SSDP discovery failed: %v", err)
+	}
+
+	found := make([]avtransport.Found, 0, len(devices))
+	for _, dev := range devices {
+		found = append(found, avtransport.Found{
+			UDN:        dev.UDN,
+			IP:         dev.IP,
+			Location:   dev.Location,
+			ControlURL: dev.ControlURL,
+			ConnMgrURL: dev.ConnMgrURL,
+			SCPDURL:    dev.SCPDURL,
+			Vendor:     dev.Vendor,
+		})
+	}
+	return avtransport.CacheFound(cfg, found)
 }
 
 func RunScript(cfg *models.Config) {
-	if cfg.SelectCache != -1 {
+	mode := utils.NormalizeMode(cfg.Mode)
+	// a cached target skips discovery; stream and slideshow still need
+	// their own mode (a pipe or a folder cannot be published as a file)
+	if cfg.SelectCache != -1 && mode != "stream" && mode != "slideshow" && mode != "daemon" {
 		logger.Notify("Using explicitly selected cached device")
 		runWithConfig(cfg)
 		return
 	}
-	mode := utils.NormalizeMode(cfg.Mode)
 	switch mode {
 	case "stream":
 		runStream(cfg)
@@ -47,6 +141,10 @@ func RunScript(cfg *models.Config) {
 		runManual(cfg)
 	case "auto":
 		runAuto(cfg)
+	case "slideshow":
+		runSlideshow(cfg)
+	case "daemon":
+		// driven through the daemon API, nothing to run here
 	default:
 		log.Fatalf("Unknown mode: %s", cfg.Mode)
 	}
@@ -55,7 +153,7 @@ func RunScript(cfg *models.Config) {
 func runAuto(cfg *models.Config) {
 	// 1) SSDP
 	if cfg.Discover {
-		if avtransport.TrySSDP(cfg) {
+		if trySSDP(cfg) {
 			// Device discovered via SSDP saved in cache
 		}
 	}
@@ -83,19 +181,13 @@ func runAuto(cfg *models.Config) {
 }
 
 func runManual(cfg *models.Config) {
-	target := avtransport.Target{
-		ControlURL: utils.ControlURL(cfg),
-		MediaURL:   utils.MediaURL(cfg),
-	}
-
-	meta := avtransport.MetadataForVendor(cfg.TVVendor, target)
-	avtransport.Run(target, meta)
+	cast(cfg, utils.ControlURL(cfg))
 }
 
 func runScan(cfg *models.Config) {
 	// --- SSDP scan ---
 	if cfg.Discover {
-		if avtransport.TrySSDP(cfg) {
+		if trySSDP(cfg) {
 			// Device discovered via SSDP saved in cache
 		}
 	}
@@ -115,3 +207,19 @@ func runScan(cfg *models.Config) {
 func runStream(cfg *models.Config) {
 	stream.StartStreamPlay(cfg)
 }
+
+func runSlideshow(cfg *models.Config) {
+	// --select-cache already loaded the endpoint
+	if cfg.CachedControlURL == "" {
+		if !(cfg.UseCache && avtransport.TryCache(cfg)) && !avtransport.TryProbe(cfg) {
+			logger.Error("Unable to resolve AVTransport endpoint")
+		}
+	}
+
+	controlURL := cfg.CachedControlURL
+	if controlURL == "" {
+		controlURL = utils.ControlURL(cfg)
+	}
+
+	slideshow.Run(cfg, controlURL)
+}
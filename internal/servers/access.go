@@ -0,0 +1,160 @@
+package servers
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"renderctl/internal/models"
+	"renderctl/internal/utils"
+	"renderctl/logger"
+)
+
+// ---- ACCESS CONTROL ----
+//
+// Media, stream and status endpoints answer only the renderer(s) we cast
+// to, --allow entries and this machine. Identity endpoints stay open so
+// discovery keeps working.
+
+var publicPaths = map[string]bool{
+	"/device.xml":  true,
+	"/cd/scpd.xml": true,
+	"/cm/scpd.xml": true,
+}
+
+// Access is a client allowlist: renderers we cast to plus fixed networks.
+// The CLI servers share defaultAccess; embedders create their own.
+type Access struct {
+	mu        sync.RWMutex
+	renderers map[string]bool
+	nets      []*net.IPNet
+	rejected  map[string]bool // logged once per client
+}
+
+func NewAccess(nets []*net.IPNet) *Access {
+	return &Access{
+		renderers: map[string]bool{},
+		nets:      nets,
+		rejected:  map[string]bool{},
+	}
+}
+
+var defaultAccess = NewAccess(nil)
+
+// configureAccess loads --allow and the renderer IP if already known.
+func configureAccess(cfg *models.Config) {
+	// this machine reaches itself through -Lip as well as loopback
+	nets, err := utils.ParseAllowList(append([]string{cfg.LIP}, cfg.Allow...))
+	if err != nil {
+		logger.Error("%v", err)
+	}
+
+	defaultAccess.mu.Lock()
+	defaultAccess.nets = nets
+	defaultAccess.mu.Unlock()
+
+	if cfg.TIP != "" {
+		AllowRenderer(cfg.TIP)
+	}
+	if cfg.CachedControlURL != "" {
+		AllowRenderer(cfg.CachedControlURL)
+	}
+}
+
+// AllowRenderer lets a renderer fetch media from the default servers.
+func AllowRenderer(host string) {
+	defaultAccess.AllowRenderer(host)
+}
+
+func guard(next http.Handler) http.Handler {
+	return defaultAccess.Guard(next)
+}
+
+// AllowRenderer lets a renderer fetch media. host is an IP or the
+// renderer's control URL.
+func (a *Access) AllowRenderer(host string) {
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	ips := []string{host}
+	if net.ParseIP(host) == nil {
+		// a host name: allow what it resolves to
+		addrs, err := net.LookupHost(host)
+		if err != nil {
+			return
+		}
+		ips = addrs
+	}
+
+	a.mu.Lock()
+	for _, ip := range ips {
+		if !a.renderers[ip] {
+			a.renderers[ip] = true
+			logger.Info("Allowing renderer %s", ip)
+		}
+	}
+	a.mu.Unlock()
+}
+
+// isRenderer reports whether ip belongs to a renderer we cast to, as
+// opposed to an --allow client or this machine.
+func (a *Access) isRenderer(ip string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.renderers[ip]
+}
+
+func (a *Access) allowed(ipStr string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.renderers[ip.String()] {
+		return true
+	}
+	for _, n := range a.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Guard rejects clients outside the allowlist with 403.
+func (a *Access) Guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if a.allowed(host) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		a.mu.Lock()
+		first := !a.rejected[host]
+		a.rejected[host] = true
+		a.mu.Unlock()
+
+		if first {
+			logger.Notify("Rejected %s (not an allowed client): %s %s", host, r.Method, r.URL.Path)
+		} else {
+			logger.Info("Rejected %s: %s %s", host, r.Method, r.URL.Path)
+		}
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+}
@@ -0,0 +1,71 @@
+package servers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"renderctl/internal/utils"
+)
+
+func TestGuard(t *testing.T) {
+	nets, err := utils.ParseAllowList([]string{"192.168.1.2", "10.20.0.0/16", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAccess(nets)
+	a.AllowRenderer("192.168.1.50")
+	a.AllowRenderer("http://192.168.1.60:49152/upnp/control/AVTransport1")
+
+	h := a.Guard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		name   string
+		remote string
+		path   string
+		want   int
+	}{
+		{"renderer", "192.168.1.50:51000", "/media/tok/a.mp3", http.StatusOK},
+		{"renderer by control URL", "192.168.1.60:51000", "/stream/live.ts", http.StatusOK},
+		{"allowed IP", "192.168.1.2:40000", "/status", http.StatusOK},
+		{"allowed CIDR", "10.20.3.4:40000", "/media/tok/a.mp3", http.StatusOK},
+		{"allowed IPv6 CIDR", "[fd12::1]:40000", "/media/tok/a.mp3", http.StatusOK},
+		{"loopback", "127.0.0.1:40000", "/media/tok/a.mp3", http.StatusOK},
+		{"loopback IPv6", "[::1]:40000", "/media/tok/a.mp3", http.StatusOK},
+		{"other LAN host", "192.168.1.99:40000", "/media/tok/a.mp3", http.StatusForbidden},
+		{"outside CIDR", "10.21.0.1:40000", "/stream/live.ts", http.StatusForbidden},
+		{"other LAN host, identity", "192.168.1.99:40000", "/device.xml", http.StatusOK},
+		{"other LAN host, SCPD", "192.168.1.99:40000", "/cd/scpd.xml", http.StatusOK},
+		{"no port", "192.168.1.50", "/media/tok/a.mp3", http.StatusOK},
+		{"garbage address", "not-an-ip:1", "/media/tok/a.mp3", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			r.RemoteAddr = tc.remote
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.want {
+				t.Fatalf("%s %s: %d, want %d", tc.remote, tc.path, w.Code, tc.want)
+			}
+		})
+	}
+}
+
+// Loopback is allowed even with an empty allowlist; nothing else is.
+func TestGuardDefaultDeny(t *testing.T) {
+	a := NewAccess(nil)
+	if !a.allowed("127.0.0.1") || !a.allowed("::1") {
+		t.Fatal("loopback rejected")
+	}
+	for _, ip := range []string{"192.168.1.50", "0.0.0.0", "::", ""} {
+		if a.allowed(ip) {
+			t.Fatalf("%q allowed by an empty allowlist", ip)
+		}
+	}
+	if a.isRenderer("127.0.0.1") {
+		t.Fatal("loopback counted as a renderer")
+	}
+}
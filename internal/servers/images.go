@@ -0,0 +1,32 @@
+package servers
+
+// ---- IN-MEMORY IMAGES (album art, prepared slideshow photos) ----
+//
+// Kept in the default MediaRegistry like any published file, one item
+// per slot ("cover", "slide").
+
+// PublishImage serves data under a fresh path for the given slot and
+// returns that path ("" when data is empty). The previous image of the
+// slot is dropped; the path changes with every call so renderers never
+// show a cached picture.
+func PublishImage(slot string, data []byte, mime string) string {
+	if len(data) == 0 {
+		defaultMedia.DropData(slot)
+		return ""
+	}
+
+	ext := ".jpg"
+	if mime == "image/png" {
+		ext = ".png"
+	}
+	p, err := defaultMedia.PublishData(slot, slot+ext, data, mime)
+	if err != nil {
+		return ""
+	}
+	return p
+}
+
+// SetAlbumArt publishes the cover image of the current track.
+func SetAlbumArt(data []byte, mime string) string {
+	return PublishImage("cover", data, mime)
+}
@@ -0,0 +1,213 @@
+package servers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"renderctl/internal/servers/identity"
+	"renderctl/logger"
+)
+
+// ---- MEDIA REGISTRY ----
+//
+// Only published files are reachable, under unguessable
+// /media/<token>/<escaped-name> URLs, wherever they live on disk.
+
+const mediaPrefix = "/media/"
+
+type mediaItem struct {
+	path string // absolute
+	name string // base name, for the URL and Content-Type fallback
+	mime string // pinned Content-Type ("" = by extension)
+	data []byte // in-memory item (cover art) instead of a file
+}
+
+// MediaRegistry is a set of published files. The CLI servers share
+// defaultMedia; embedders create their own.
+type MediaRegistry struct {
+	mu     sync.RWMutex
+	media  map[string]*mediaItem // key = token
+	tokens map[string]string     // absolute path -> token
+}
+
+func NewMediaRegistry() *MediaRegistry {
+	return &MediaRegistry{
+		media:  map[string]*mediaItem{},
+		tokens: map[string]string{},
+	}
+}
+
+var defaultMedia = NewMediaRegistry()
+
+// Publish registers a file for serving and returns its URL path.
+// Publishing the same file again returns the same path.
+func (m *MediaRegistry) Publish(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", errors.New("cannot publish a directory: " + abs)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tok, ok := m.tokens[abs]
+	if !ok {
+		tok, err = newToken()
+		if err != nil {
+			return "", err
+		}
+		m.tokens[abs] = tok
+		m.media[tok] = &mediaItem{path: abs, name: filepath.Base(abs)}
+	}
+	return mediaPath(tok, m.media[tok].name), nil
+}
+
+// SetContentType pins the Content-Type for a published media path, so the
+// header matches the mime announced in DIDL-Lite (e.g. audio/x-flac vs audio/flac).
+// Full URLs are accepted too.
+func (m *MediaRegistry) SetContentType(mediaURLPath, mime string) {
+	tok, _ := splitMediaPath(mediaURLPath)
+
+	m.mu.Lock()
+	if it, ok := m.media[tok]; ok {
+		it.mime = mime
+	}
+	m.mu.Unlock()
+}
+
+// PublishData serves data (e.g. cover art) under a fresh path. Publishing
+// the same key again replaces the previous item, so renderers never show
+// a cached picture.
+func (m *MediaRegistry) PublishData(key, name string, data []byte, mime string) (string, error) {
+	tok, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	key = "data:" + key
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.tokens[key]; ok {
+		delete(m.media, old)
+	}
+	m.tokens[key] = tok
+	m.media[tok] = &mediaItem{name: name, mime: mime, data: data}
+	return mediaPath(tok, name), nil
+}
+
+// DropData stops serving the item last published under key.
+func (m *MediaRegistry) DropData(key string) {
+	key = "data:" + key
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if tok, ok := m.tokens[key]; ok {
+		delete(m.media, tok)
+		delete(m.tokens, key)
+	}
+}
+
+func mediaPath(tok, name string) string {
+	return mediaPrefix + tok + "/" + url.PathEscape(name)
+}
+
+// splitMediaPath returns the token and the (still escaped) name.
+func splitMediaPath(p string) (string, string) {
+	if i := strings.Index(p, mediaPrefix); i >= 0 {
+		p = p[i+len(mediaPrefix):]
+	}
+	tok, name, _ := strings.Cut(p, "/")
+	return tok, name
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func registerMedia(mux *http.ServeMux) {
+	defaultMedia.Register(mux)
+}
+
+// Register serves the registry under /media/ on mux.
+func (m *MediaRegistry) Register(mux *http.ServeMux) {
+	mux.HandleFunc(mediaPrefix, func(w http.ResponseWriter, r *http.Request) {
+		identity.PolishHeaders(w)
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tok, _ := splitMediaPath(r.URL.EscapedPath())
+
+		m.mu.RLock()
+		it, ok := m.media[tok]
+		var item mediaItem
+		if ok {
+			item = *it
+		}
+		m.mu.RUnlock()
+
+		if !ok {
+			logger.Info("Unknown media request from %s: %s", r.RemoteAddr, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		if item.data != nil {
+			w.Header().Set("Content-Type", item.mime)
+			if strings.HasPrefix(item.mime, "image/") {
+				w.Header().Set("transferMode.dlna.org", "Interactive")
+			}
+			http.ServeContent(w, r, item.name, time.Time{}, bytes.NewReader(item.data))
+			return
+		}
+
+		f, err := os.Open(item.path)
+		if err != nil {
+			http.Error(w, "media unavailable", http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "media unavailable", http.StatusNotFound)
+			return
+		}
+
+		ct := item.mime
+		if ct == "" {
+			ct = mime.TypeByExtension(filepath.Ext(item.name))
+		}
+		if ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+
+		// handles HEAD, Range, Content-Length and Accept-Ranges
+		http.ServeContent(w, r, item.name, info.ModTime(), f)
+	})
+}
@@ -0,0 +1,104 @@
+package servers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestMediaServesPublishedFilesOnly(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "Café #1 (live).flac")
+	if err := os.WriteFile(song, []byte("fLaC-data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("nope"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMediaRegistry()
+	mux := http.NewServeMux()
+	m.Register(mux)
+
+	p, err := m.Publish(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := m.Publish(song); again != p {
+		t.Fatalf("republished as %s, was %s", again, p)
+	}
+	if strings.ContainsAny(strings.TrimPrefix(p, mediaPrefix), " #(") {
+		t.Fatalf("path %q is not escaped", p)
+	}
+
+	w := get(t, mux, p)
+	if w.Code != http.StatusOK || w.Body.String() != "fLaC-data" {
+		t.Fatalf("published file: %d %q", w.Code, w.Body.String())
+	}
+
+	m.SetContentType("http://192.168.1.2:8080"+p, "audio/x-flac")
+	if ct := get(t, mux, p).Header().Get("Content-Type"); ct != "audio/x-flac" {
+		t.Fatalf("Content-Type %q, want the pinned audio/x-flac", ct)
+	}
+
+	// the token is what grants access, not the name
+	tok, _ := splitMediaPath(p)
+	for _, path := range []string{
+		mediaPrefix + tok + "/secret.txt", // same token, other name: still the song
+		mediaPrefix + "0123456789abcdef0123456789abcdef/secret.txt",
+		mediaPrefix + "secret.txt",
+		mediaPrefix + tok + "/../../" + filepath.Base(secret),
+	} {
+		if w := get(t, mux, path); w.Body.String() == "nope" {
+			t.Fatalf("%s served an unpublished file", path)
+		}
+	}
+	if w := get(t, mux, mediaPrefix+"0123456789abcdef0123456789abcdef/x"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown token: %d, want 404", w.Code)
+	}
+}
+
+func TestMediaDataReplaced(t *testing.T) {
+	m := NewMediaRegistry()
+	mux := http.NewServeMux()
+	m.Register(mux)
+
+	first, err := m.PublishData("cover", "cover.jpg", []byte("one"), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.PublishData("cover", "cover.jpg", []byte("two"), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("replacement reused the old path")
+	}
+
+	if w := get(t, mux, first); w.Code != http.StatusNotFound {
+		t.Fatalf("replaced item still served: %d", w.Code)
+	}
+	w := get(t, mux, second)
+	if w.Code != http.StatusOK || w.Body.String() != "two" {
+		t.Fatalf("new item: %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "image/jpeg" || w.Header().Get("transferMode.dlna.org") != "Interactive" {
+		t.Fatalf("image headers: %v", w.Header())
+	}
+
+	m.DropData("cover")
+	if w := get(t, mux, second); w.Code != http.StatusNotFound {
+		t.Fatalf("dropped item still served: %d", w.Code)
+	}
+}
@@ -14,23 +14,20 @@ func InitDefaultServer(cfg models.Config, stop <-chan struct{}) {
 	}
 
 	cfg.ServerUp = true
-	fs := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		identity.PolishHeaders(w)
-		http.FileServer(http.Dir(cfg.LDir)).ServeHTTP(w, r)
-	})
+	configureAccess(&cfg)
 
 	mux := http.NewServeMux()
 
 	identity.RegisterHandlers(mux, serverUUID)
-	mux.Handle("/", fs)
+	registerMedia(mux)
 
 	srv := &http.Server{
 		Addr:    "0.0.0.0:" + cfg.ServePort,
-		Handler: mux,
+		Handler: guard(mux),
 	}
 
 	go func() {
-		logger.Success("HTTP server serving: %s", cfg.LDir)
+		logger.Success("HTTP server listening: http://%s:%s (published media only)", cfg.LIP, cfg.ServePort)
 
 		identity.AnnounceMediaServer(
 			serverUUID,
@@ -21,6 +21,8 @@ type ClientProfile struct {
 
 	WantsRange bool
 	DidHEAD    bool
+
+	clientStats
 }
 
 var (
@@ -42,53 +44,167 @@ func ServeStream(
 		logger.Error("Failed to load server UUID: %v", err)
 	}
 	cfg.ServerUp = true
+	configureAccess(cfg)
+
+	// ---- SHARED PIPELINE (live sources only, HLS has its own) ----
+	var hub *broadcaster
+	if isLive(source) && container.Key() != "hls" {
+		var align int64 = 1
+		if container.Key() == "ts" {
+			align = tsPacketSize
+		}
+		hub = newBroadcaster(source, cfg.StreamIdle, align)
+
+		mu.Lock()
+		preOpen := hints.PreOpen
+		mu.Unlock()
+		if preOpen {
+			logger.Notify("TV is known to give up on slow starts — pre-opening the source")
+			if err := hub.Warm(); err != nil {
+				logger.Notify("Pre-open failed: %v", err)
+			}
+		}
+	}
+
+	status := &streamStatus{
+		started:   time.Now(),
+		path:      streamPath,
+		mime:      mime,
+		container: container,
+		source:    source,
+		hub:       hub,
+	}
 
 	mux := http.NewServeMux()
 
 	// ---- REGISTER IDENTITY ENDPOINTS ----
 	identity.RegisterHandlers(mux, serverUUID)
-	// ---- STREAM HANDLER ----
-	mux.HandleFunc(streamPath, func(w http.ResponseWriter, r *http.Request) {
-		// ---- HEADER POLISH (MUST BE FIRST) ----
-		identity.PolishHeaders(w)
+	registerMedia(mux) // cover art
 
-		clientIP := strings.Split(r.RemoteAddr, ":")[0]
+	// ---- HLS OUTPUT (playlist + rolling segments) ----
+	var hls *hlsOutput
+	if container.Key() == "hls" {
+		var audio string
+		if enc, ok := container.(HLSAudioEncoder); ok {
+			audio = enc.HLSAudio()
+		}
+		hls = newHLSOutput(source, status, cfg.StreamIdle, audio)
+		mux.Handle(strings.TrimSuffix(streamPath, "/")+"/", hls)
+		streamPath = HLSPlaylistPath(streamPath)
+	} else {
+		registerStreamHandler(mux, status)
+	}
+	status.path = streamPath
 
-		mu.Lock()
-		p, ok := profiles[clientIP]
-		if !ok {
-			p = &ClientProfile{
-				IP:        clientIP,
-				UserAgent: r.UserAgent(),
-				Headers:   r.Header.Clone(),
-			}
-			profiles[clientIP] = p
-			logger.Notify("TV detected: %s (%s)", p.IP, p.UserAgent)
+	mu.Lock()
+	activeStream = status
+	mu.Unlock()
+
+	// ---- STATUS ----
+	mux.Handle("/status", status)
+	if cfg.Stats {
+		go status.runSummary(statsInterval, stop)
+	}
+
+	srv := &http.Server{
+		// Addr:              "0.0.0.0:" + cfg.ServePort, // added net Listen below
+		Handler:           guard(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		logger.Success(
+			"HTTP stream server listening: %s%s (mime=%s)",
+			"http://"+cfg.LIP+":"+cfg.ServePort,
+			streamPath,
+			mime,
+		)
+
+		ln, err := net.Listen("tcp", "0.0.0.0:"+cfg.ServePort)
+		if err != nil {
+			logger.Error("HTTP stream server listen error: %v", err)
+			return
 		}
-		mu.Unlock()
 
-		if r.Method == http.MethodHead {
-			p.DidHEAD = true
+		// NOW we are truly listening — safe to announce
+		identity.AnnounceMediaServer(
+			serverUUID,
+			"http://"+cfg.LIP+":"+cfg.ServePort+"/device.xml",
+		)
+
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP stream server error: %v", err)
 		}
+	}()
 
-		if r.Header.Get("Range") != "" {
-			p.WantsRange = true
-			logger.Notify("TV %s requested Range", p.IP)
+	go func() {
+		<-stop
+		logger.Notify("Shutting down stream HTTP server")
+		identity.AnnounceMediaServerByeBye(serverUUID)
+		_ = srv.Close()
+		if hub != nil {
+			hub.Close()
 		}
+		if hls != nil {
+			hls.Close()
+		}
+	}()
+}
+
+func registerStreamHandler(mux *http.ServeMux, st *streamStatus) {
+	var (
+		container = st.container
+		source    = st.source
+		hub       = st.hub
+	)
+
+	// ---- STREAM HANDLER ----
+	mux.HandleFunc(st.path, func(w http.ResponseWriter, r *http.Request) {
+		// ---- HEADER POLISH (MUST BE FIRST) ----
+		identity.PolishHeaders(w)
+
+		c := trackRequest(st, w, r)
+		p, wantsRange := c.p, c.wantsRange
+		mime, h := c.mime, c.hints
+
+		// Seekable files get Content-Length + Range when the TV asks for
+		// them now or is known to from an earlier session.
+		seekable, _ := source.(SeekableSource)
+		sized := seekable != nil && hub == nil &&
+			(wantsRange || h.Ranges || h.ContentLength)
+
+		defer c.finish(container.Key(), hub != nil, !sized)
+		w = c.cw
 
 		if r.Method != http.MethodGet && r.Method != http.MethodHead {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		rc, err := source.Open()
-		if err != nil {
-			http.Error(w, "stream source unavailable", http.StatusServiceUnavailable)
+		w.Header().Set("Content-Type", mime)
+
+		if sized {
+			f, err := seekable.OpenSeekable()
+			if err != nil {
+				http.Error(w, "stream source unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			defer f.Close()
+
+			mu.Lock()
+			st.opens++
+			p.Active++
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				p.Active--
+				mu.Unlock()
+			}()
+
+			// handles HEAD, Range, Content-Length and Accept-Ranges
+			http.ServeContent(w, r, "", time.Time{}, f)
 			return
 		}
-		defer rc.Close()
-
-		w.Header().Set("Content-Type", mime)
 
 		// CHANGED: dynamic Accept-Ranges
 		// Range support depends on container semantics
@@ -99,56 +215,160 @@ func ServeStream(
 
 		default:
 			// Non-TS containers MAY support Range (future)
-			if p.WantsRange {
+			if wantsRange && hub == nil {
 				w.Header().Set("Accept-Ranges", "bytes")
 			} else {
 				w.Header().Set("Accept-Ranges", "none")
 			}
 		}
 
+		// HEAD never touches the source (TVs probe a lot)
 		if r.Method == http.MethodHead {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
+		var (
+			rc  StreamReadCloser
+			err error
+		)
+		if hub != nil {
+			rc, err = hub.Attach()
+		} else {
+			rc, err = source.Open()
+			mu.Lock()
+			st.opens++
+			mu.Unlock()
+		}
+		if err != nil {
+			http.Error(w, "stream source unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		defer rc.Close()
+
+		mu.Lock()
+		p.Active++
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			p.Active--
+			mu.Unlock()
+		}()
+
+		// unblock a waiting reader as soon as the TV hangs up
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-r.Context().Done():
+				_ = rc.Close()
+			case <-done:
+			}
+		}()
+
 		_, _ = io.Copy(w, rc)
 	})
+}
 
-	srv := &http.Server{
-		// Addr:              "0.0.0.0:" + cfg.ServePort, // added net Listen below
-		Handler:           mux,
-		ReadHeaderTimeout: 5 * time.Second,
-	}
+// clientRequest is one stream request as seen by the client's profile.
+type clientRequest struct {
+	r          *http.Request
+	p          *ClientProfile
+	cw         *countingWriter
+	now        time.Time
+	rangeHdr   string
+	wantsRange bool
+	mime       string
+	hints      ClientHints
+	report     func(ClientReport)
+}
 
-	go func() {
-		logger.Success(
-			"HTTP stream server listening: %s%s (mime=%s)",
-			"http://"+cfg.LIP+":"+cfg.ServePort,
-			streamPath,
-			mime,
-		)
+// trackRequest updates the requesting client's profile (and the last
+// GET time) and wraps w so the bytes sent are counted.
+func trackRequest(st *streamStatus, w http.ResponseWriter, r *http.Request) *clientRequest {
+	clientIP := strings.Split(r.RemoteAddr, ":")[0]
+	now := time.Now()
 
-		ln, err := net.Listen("tcp", "0.0.0.0:"+cfg.ServePort)
-		if err != nil {
-			logger.Error("HTTP stream server listen error: %v", err)
-			return
+	mu.Lock()
+	defer mu.Unlock()
+
+	p, ok := profiles[clientIP]
+	if !ok {
+		p = &ClientProfile{
+			IP:        clientIP,
+			UserAgent: r.UserAgent(),
+			Headers:   r.Header.Clone(),
 		}
+		p.FirstSeen = now
+		profiles[clientIP] = p
+		logger.Notify("TV detected: %s (%s)", p.IP, p.UserAgent)
+	}
+	p.LastSeen = now
+	if r.Method == http.MethodHead {
+		p.DidHEAD = true
+	}
+	rangeHdr := r.Header.Get("Range")
+	if rangeHdr != "" && !p.WantsRange {
+		p.WantsRange = true
+		logger.Notify("TV %s requested Range", p.IP)
+	}
+	// only the renderer's fetches count as the cast being accepted
+	if r.Method == http.MethodGet && defaultAccess.isRenderer(clientIP) {
+		st.lastGET = now
+	}
 
-		// NOW we are truly listening — safe to announce
-		identity.AnnounceMediaServer(
-			serverUUID,
-			"http://"+cfg.LIP+":"+cfg.ServePort+"/device.xml",
-		)
+	return &clientRequest{
+		r:          r,
+		p:          p,
+		cw:         &countingWriter{ResponseWriter: w, p: p},
+		now:        now,
+		rangeHdr:   rangeHdr,
+		wantsRange: p.WantsRange,
+		mime:       st.mime,
+		hints:      hints,
+		report:     reportHook,
+	}
+}
 
-		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
-			logger.Error("HTTP stream server error: %v", err)
-		}
-	}()
+// finish records the request in the profile and hands it to the
+// report hook.
+func (c *clientRequest) finish(container string, live, chunked bool) {
+	cw, p, r := c.cw, c.p, c.r
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	mu.Lock()
+	p.record(RequestRecord{
+		Time:     c.now,
+		Method:   r.Method,
+		Range:    c.rangeHdr,
+		Status:   cw.status,
+		Bytes:    cw.bytes,
+		Duration: time.Since(c.now),
+	})
+	didHEAD := p.DidHEAD
+	ua := p.UserAgent
+	mu.Unlock()
 
-	go func() {
-		<-stop
-		logger.Notify("Shutting down stream HTTP server")
-		identity.AnnounceMediaServerByeBye(serverUUID)
-		_ = srv.Close()
-	}()
+	if c.report == nil {
+		return
+	}
+	rep := ClientReport{
+		IP:         p.IP,
+		UserAgent:  ua,
+		Method:     r.Method,
+		DidHEAD:    didHEAD,
+		WantsRange: c.wantsRange,
+		Mime:       c.mime,
+		Container:  container,
+		Live:       live,
+		Chunked:    chunked,
+		Bytes:      cw.bytes,
+	}
+	if !cw.first.IsZero() {
+		rep.FirstByte = cw.first.Sub(c.now)
+	}
+	rep.Aborted = r.Method == http.MethodGet &&
+		r.Context().Err() != nil && cw.bytes < playbackBytes
+	c.report(rep)
 }
@@ -0,0 +1,339 @@
+package servers
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"renderctl/logger"
+)
+
+const (
+	broadcastRingSize  = 8 << 20 // 8 MiB of live history
+	broadcastChunkSize = 32 << 10
+	tsPacketSize       = 188
+)
+
+// broadcaster owns ONE upstream pipeline and fans its bytes out
+// to any number of attached clients through a shared ring buffer.
+type broadcaster struct {
+	source StreamSource
+	idle   time.Duration
+	align  int64 // join offsets are aligned to this (188 for TS)
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	ring []byte
+	head int64 // total bytes written, across restarts
+	base int64 // head when the current pipeline started
+
+	upstream StreamReadCloser
+	running  bool
+	starting bool   // source.Open in progress, b.mu released
+	stops    int    // bumped by Close, cancels a start in progress
+	cur      *epoch // the running pipeline's readers share this
+
+	readers   int
+	idleTimer *time.Timer
+
+	// for /status
+	starts  int
+	lastErr string
+}
+
+// epoch is one run of the upstream pipeline. Readers keep reading their
+// epoch after it ends, up to end, unless it was cut or the ring overran them.
+type epoch struct {
+	ended bool
+	cut   bool  // stopped on purpose: readers end right away
+	end   int64 // head when the upstream ended
+}
+
+func newBroadcaster(source StreamSource, idle time.Duration, align int64) *broadcaster {
+	if align <= 0 {
+		align = 1
+	}
+	b := &broadcaster{
+		source: source,
+		idle:   idle,
+		align:  align,
+		ring:   make([]byte, broadcastRingSize),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Attach joins the live stream at its current edge,
+// starting the upstream pipeline if it is not running.
+func (b *broadcaster) Attach() (StreamReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+		b.idleTimer = nil
+	}
+
+	if err := b.startLocked(); err != nil {
+		return nil, err
+	}
+
+	b.readers++
+
+	return &broadcastReader{
+		b:   b,
+		off: b.head - (b.head-b.base)%b.align,
+		ep:  b.cur,
+	}, nil
+}
+
+// Warm starts the pipeline before any client attaches, so a TV that
+// gives up on slow starts finds it running. Unused, it idles out as usual.
+func (b *broadcaster) Warm() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running || b.starting {
+		return nil
+	}
+	if err := b.startLocked(); err != nil {
+		return err
+	}
+	if b.readers == 0 {
+		b.armIdleLocked()
+	}
+	return nil
+}
+
+// startLocked opens the upstream if it is not running. Caller holds b.mu,
+// which is released while the source opens (resolvers, playlist fetches
+// and mirror retries can take seconds); concurrent callers wait for it.
+func (b *broadcaster) startLocked() error {
+	for b.starting {
+		b.cond.Wait()
+	}
+	if b.running {
+		return nil
+	}
+
+	b.starting = true
+	stops := b.stops
+	b.mu.Unlock()
+	rc, err := b.source.Open()
+	b.mu.Lock()
+	b.starting = false
+	b.cond.Broadcast()
+
+	if err == nil && b.stops != stops {
+		_ = rc.Close()
+		err = errors.New("stream stopped while starting")
+	}
+	if err != nil {
+		b.lastErr = err.Error()
+		return err
+	}
+
+	ep := &epoch{}
+	b.upstream = rc
+	b.running = true
+	b.cur = ep
+	b.base = b.head
+	b.starts++
+	go b.pump(rc, ep)
+	logger.Done("Shared stream pipeline started")
+	return nil
+}
+
+func (b *broadcaster) pump(rc StreamReadCloser, ep *epoch) {
+	chunk := make([]byte, broadcastChunkSize)
+
+	for {
+		n, err := rc.Read(chunk)
+
+		b.mu.Lock()
+		if b.cur != ep {
+			// torn down while we were reading
+			b.mu.Unlock()
+			return
+		}
+		if n > 0 {
+			b.write(chunk[:n])
+			b.cond.Broadcast()
+		}
+		if err != nil {
+			if err != io.EOF {
+				logger.Notify("Shared stream upstream ended: %v", err)
+				b.lastErr = err.Error()
+			}
+			// readers still get the tail in the ring
+			b.teardownLocked(false)
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+	}
+}
+
+// write copies p into the ring. Caller holds b.mu.
+func (b *broadcaster) write(p []byte) {
+	size := int64(len(b.ring))
+	for len(p) > 0 {
+		pos := b.head % size
+		n := copy(b.ring[pos:], p)
+		b.head += int64(n)
+		p = p[n:]
+	}
+}
+
+func (b *broadcaster) detach() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.readers--
+	if b.readers > 0 || !b.running {
+		return
+	}
+	b.armIdleLocked()
+}
+
+// armIdleLocked stops the pipeline after b.idle without readers.
+// Caller holds b.mu.
+func (b *broadcaster) armIdleLocked() {
+	ep := b.cur
+	b.idleTimer = time.AfterFunc(b.idle, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if b.readers == 0 && b.running && b.cur == ep {
+			logger.Notify("No stream clients for %v — stopping pipeline", b.idle)
+			b.teardownLocked(true)
+		}
+	})
+}
+
+type hubStats struct {
+	running bool
+	starts  int
+	readers int
+	bytes   int64
+	lastErr string
+}
+
+func (b *broadcaster) stats() hubStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return hubStats{
+		running: b.running,
+		starts:  b.starts,
+		readers: b.readers,
+		bytes:   b.head - b.base,
+		lastErr: b.lastErr,
+	}
+}
+
+// Close stops the upstream pipeline regardless of attached readers.
+func (b *broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+		b.idleTimer = nil
+	}
+	b.stops++
+	b.teardownLocked(true)
+}
+
+// teardownLocked closes the upstream and wakes all readers. With cut
+// they stop at once, otherwise they drain what the ring still holds.
+// Caller holds b.mu.
+func (b *broadcaster) teardownLocked(cut bool) {
+	if !b.running {
+		return
+	}
+	b.running = false
+	b.cur.ended = true
+	b.cur.cut = cut
+	b.cur.end = b.head
+	b.cur = nil
+	if b.upstream != nil {
+		_ = b.upstream.Close()
+		b.upstream = nil
+	}
+	b.cond.Broadcast()
+}
+
+type broadcastReader struct {
+	b      *broadcaster
+	off    int64
+	ep     *epoch
+	closed bool
+}
+
+func (r *broadcastReader) Read(p []byte) (int, error) {
+	b := r.b
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ep := r.ep
+	for !r.closed && !ep.ended && r.off >= b.head {
+		b.cond.Wait()
+	}
+
+	if r.closed || ep.cut {
+		return 0, io.EOF
+	}
+
+	limit := b.head
+	if ep.ended {
+		limit = ep.end
+	}
+
+	size := int64(len(b.ring))
+	if b.head-r.off > size {
+		if ep.ended {
+			// overrun by a newer pipeline: the tail is gone
+			return 0, io.EOF
+		}
+		// fell out of the ring → skip ahead to the live edge
+		r.off = b.head - (b.head-b.base)%b.align
+		logger.Notify("Stream client lagging — skipped to live edge")
+		if r.off >= b.head {
+			return 0, nil
+		}
+	}
+
+	if r.off >= limit {
+		return 0, io.EOF
+	}
+
+	avail := limit - r.off
+	if int64(len(p)) > avail {
+		p = p[:avail]
+	}
+
+	pos := r.off % size
+	n := copy(p, b.ring[pos:])
+	if n < len(p) {
+		n += copy(p[n:], b.ring[:len(p)-n])
+	}
+	r.off += int64(n)
+
+	return n, nil
+}
+
+func (r *broadcastReader) Close() error {
+	r.b.mu.Lock()
+	if r.closed {
+		r.b.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.b.cond.Broadcast()
+	r.b.mu.Unlock()
+
+	r.b.detach()
+	return nil
+}
@@ -0,0 +1,186 @@
+package servers
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// pipeSource is an in-memory upstream: the test writes what the
+// pipeline would produce, and closing the writer ends it.
+type pipeSource struct {
+	w     *io.PipeWriter
+	opens int
+}
+
+func (s *pipeSource) Open() (StreamReadCloser, error) {
+	r, w := io.Pipe()
+	s.w = w
+	s.opens++
+	return r, nil
+}
+
+// testBroadcaster uses a small ring so tests can wrap and overrun it.
+func testBroadcaster(ring int, idle time.Duration, align int64) (*broadcaster, *pipeSource) {
+	src := &pipeSource{}
+	b := newBroadcaster(src, idle, align)
+	b.ring = make([]byte, ring)
+	return b, src
+}
+
+// eventually polls cond until it holds; pump copies into the ring on its own goroutine.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// feed writes p upstream and waits until the ring holds it.
+func feed(t *testing.T, b *broadcaster, src *pipeSource, p []byte) {
+	t.Helper()
+	want := b.stats().bytes + int64(len(p))
+	if _, err := src.w.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "ring write", func() bool { return b.stats().bytes == want })
+}
+
+// tsPackets returns n packets, each a sync byte followed by its index.
+func tsPackets(first, n int) []byte {
+	var out []byte
+	for i := first; i < first+n; i++ {
+		pkt := bytes.Repeat([]byte{byte(i)}, tsPacketSize)
+		pkt[0] = 0x47
+		out = append(out, pkt...)
+	}
+	return out
+}
+
+func TestBroadcastRingWraparound(t *testing.T) {
+	b, src := testBroadcaster(1000, time.Minute, 1)
+	defer b.Close()
+
+	r, err := b.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// 600 + 600 crosses the end of the 1000-byte ring
+	for i := range 2 {
+		want := bytes.Repeat([]byte{byte('a' + i)}, 600)
+		feed(t, b, src, want)
+
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("chunk %d read back wrong", i)
+		}
+	}
+}
+
+func TestBroadcastLaggingReaderSkipsToPacket(t *testing.T) {
+	b, src := testBroadcaster(10*tsPacketSize, time.Minute, tsPacketSize)
+	defer b.Close()
+
+	r, err := b.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// the reader falls more than a ring behind, mid-packet
+	feed(t, b, src, tsPackets(0, 20))
+	feed(t, b, src, tsPackets(20, 1)[:100])
+
+	p := make([]byte, 4*tsPacketSize)
+	n, err := r.Read(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// joined at the start of the packet being written, not mid-packet
+	if n != 100 || p[0] != 0x47 || p[1] != 20 {
+		t.Fatalf("read %d bytes starting % x, want 100 from packet 20", n, p[:min(n, 2)])
+	}
+}
+
+func TestBroadcastReaderDrainsTail(t *testing.T) {
+	b, src := testBroadcaster(1000, time.Minute, 1)
+	defer b.Close()
+
+	r, err := b.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	want := bytes.Repeat([]byte("x"), 500)
+	feed(t, b, src, want)
+	src.w.Close()
+	eventually(t, "upstream end", func() bool { return !b.stats().running })
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("drained %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestBroadcastCloseDuringStart(t *testing.T) {
+	src := &gatedSource{opening: make(chan struct{}), release: make(chan struct{})}
+	b := newBroadcaster(src, time.Minute, 1)
+
+	attached := make(chan error, 1)
+	go func() {
+		_, err := b.Attach()
+		attached <- err
+	}()
+	<-src.opening
+
+	b.Close()
+	close(src.release)
+	if err := <-attached; err == nil {
+		t.Fatal("Attach succeeded after Close")
+	}
+	if st := b.stats(); st.running || st.starts != 0 {
+		t.Fatalf("pipeline running=%v starts=%d after Close", st.running, st.starts)
+	}
+}
+
+func TestBroadcastIdleTeardown(t *testing.T) {
+	b, src := testBroadcaster(1000, 10*time.Millisecond, 1)
+	defer b.Close()
+
+	r, err := b.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed(t, b, src, []byte("hello"))
+	r.Close()
+
+	eventually(t, "idle teardown", func() bool { return !b.stats().running })
+	// the upstream was closed, so the pipeline writer sees it
+	if _, err := src.w.Write([]byte("late")); err == nil {
+		t.Fatal("upstream still open after idle teardown")
+	}
+
+	// the next client starts a fresh pipeline
+	r, err = b.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if src.opens != 2 {
+		t.Fatalf("%d opens, want 2", src.opens)
+	}
+}
@@ -0,0 +1,267 @@
+package servers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"renderctl/internal/servers/identity"
+	"renderctl/logger"
+)
+
+const (
+	hlsSegmentSeconds = "4"
+	hlsWindowSize     = "6"
+	hlsPlaylistName   = "index.m3u8"
+	hlsReadyTimeout   = 20 * time.Second
+)
+
+// HLSPlaylistPath is the playlist URL path served for an "hls" container.
+func HLSPlaylistPath(streamPath string) string {
+	return strings.TrimSuffix(streamPath, "/") + "/" + hlsPlaylistName
+}
+
+// hlsOutput segments the source with ffmpeg into a rolling window
+// on disk and serves the playlist + segments.
+type hlsOutput struct {
+	source StreamSource
+	status *streamStatus // current mime, last GET, client profiles
+	idle   time.Duration
+	audio  string // audio encoder, "" = copy (see HLSAudioEncoder)
+
+	mu       sync.Mutex
+	cond     *sync.Cond // signals the end of a start
+	dir      string
+	cmd      *exec.Cmd
+	input    StreamReadCloser
+	lastSeen time.Time
+	running  bool
+	starting bool // source opening, h.mu released
+	stops    int  // bumped by Close, cancels a start in progress
+}
+
+func newHLSOutput(source StreamSource, st *streamStatus, idle time.Duration, audio string) *hlsOutput {
+	h := &hlsOutput{source: source, status: st, idle: idle, audio: audio}
+	h.cond = sync.NewCond(&h.mu)
+	return h
+}
+
+// start runs the segmenter if it is not running. Like the broadcaster,
+// it opens the source without holding h.mu, so playlist and segment
+// requests are not stuck behind a slow resolver.
+func (h *hlsOutput) start() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastSeen = time.Now()
+	for h.starting {
+		h.cond.Wait()
+	}
+	if h.running {
+		return nil
+	}
+
+	h.starting = true
+	stops := h.stops
+	h.mu.Unlock()
+	in, err := h.source.Open()
+	h.mu.Lock()
+	h.starting = false
+	h.cond.Broadcast()
+
+	if err == nil && h.stops != stops {
+		_ = in.Close()
+		err = errors.New("stream stopped while starting")
+	}
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "renderctl-hls-")
+	if err != nil {
+		_ = in.Close()
+		return err
+	}
+
+	codecs := []string{"-c", "copy"}
+	if h.audio != "" {
+		codecs = []string{"-c:v", "copy", "-c:a", h.audio, "-b:a", "192k"}
+	}
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-re",
+		"-i", "pipe:0",
+	}
+	args = append(args, codecs...)
+	args = append(args,
+		"-f", "hls",
+		"-hls_time", hlsSegmentSeconds,
+		"-hls_list_size", hlsWindowSize,
+		"-hls_flags", "delete_segments+omit_endlist",
+		"-hls_segment_filename", filepath.Join(dir, "seg%05d.ts"),
+		filepath.Join(dir, hlsPlaylistName),
+	)
+	cmd := exec.Command("ffmpeg", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		_ = in.Close()
+		_ = os.RemoveAll(dir)
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		_ = in.Close()
+		_ = os.RemoveAll(dir)
+		return err
+	}
+
+	go func() {
+		_, _ = io.Copy(stdin, in)
+		_ = stdin.Close()
+	}()
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			logger.Notify("HLS segmenter exited: %v", err)
+		}
+		h.mu.Lock()
+		if h.cmd == cmd {
+			h.stopLocked()
+		}
+		h.mu.Unlock()
+	}()
+
+	h.dir, h.cmd, h.input = dir, cmd, in
+	h.running = true
+	go h.watchIdle(cmd)
+
+	logger.Done("HLS segmenter started (%s)", dir)
+	return nil
+}
+
+func (h *hlsOutput) watchIdle(cmd *exec.Cmd) {
+	for {
+		time.Sleep(time.Second)
+
+		h.mu.Lock()
+		if h.cmd != cmd {
+			h.mu.Unlock()
+			return
+		}
+		if time.Since(h.lastSeen) > h.idle {
+			logger.Notify("No HLS requests for %v — stopping segmenter", h.idle)
+			h.stopLocked()
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+	}
+}
+
+// stopLocked kills ffmpeg and removes the window. Caller holds h.mu.
+func (h *hlsOutput) stopLocked() {
+	if !h.running {
+		return
+	}
+	h.running = false
+	if h.input != nil {
+		_ = h.input.Close()
+	}
+	if h.cmd != nil && h.cmd.Process != nil {
+		_ = h.cmd.Process.Kill()
+	}
+	_ = os.RemoveAll(h.dir)
+	h.cmd, h.input, h.dir = nil, nil, ""
+}
+
+func (h *hlsOutput) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stops++
+	h.stopLocked()
+}
+
+func (h *hlsOutput) currentDir() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSeen = time.Now()
+	return h.dir
+}
+
+// waitFor polls until the file exists (ffmpeg writes the playlist
+// only after the first segment is complete).
+func waitFor(file string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(file); err == nil {
+			return true
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return false
+}
+
+func (h *hlsOutput) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	identity.PolishHeaders(w)
+
+	// same profile, stats and report bookkeeping as the plain stream
+	c := trackRequest(h.status, w, r)
+	defer c.finish("hls", true, false)
+	w = c.cw
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Base(r.URL.Path)
+
+	if name == hlsPlaylistName {
+		if err := h.start(); err != nil {
+			http.Error(w, "stream source unavailable", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	dir := h.currentDir()
+	if dir == "" {
+		http.NotFound(w, r)
+		return
+	}
+	file := filepath.Join(dir, name)
+
+	switch {
+	case name == hlsPlaylistName:
+		if !waitFor(file, hlsReadyTimeout) {
+			http.Error(w, "playlist not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", h.status.currentMime())
+		w.Header().Set("Cache-Control", "no-cache")
+
+	case strings.HasSuffix(name, ".ts"):
+		w.Header().Set("Content-Type", "video/mp2t")
+
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	mu.Lock()
+	c.p.Active++
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		c.p.Active--
+		mu.Unlock()
+	}()
+
+	http.ServeFile(w, r, file)
+}
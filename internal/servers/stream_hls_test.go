@@ -0,0 +1,51 @@
+package servers
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gatedSource blocks Open until release is closed.
+type gatedSource struct {
+	opening chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedSource) Open() (StreamReadCloser, error) {
+	close(g.opening)
+	<-g.release
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func TestHLSStartOpensOutsideLock(t *testing.T) {
+	src := &gatedSource{opening: make(chan struct{}), release: make(chan struct{})}
+	h := newHLSOutput(src, &streamStatus{}, time.Minute, "")
+
+	started := make(chan error, 1)
+	go func() { started <- h.start() }()
+	<-src.opening
+
+	// playlist and segment lookups must not wait for the open
+	done := make(chan struct{})
+	go func() {
+		h.currentDir()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("currentDir blocked while the source was opening")
+	}
+
+	// Close during the open cancels the start
+	h.Close()
+	close(src.release)
+	if err := <-started; err == nil {
+		t.Fatal("start succeeded after Close")
+	}
+	if h.running {
+		t.Fatal("segmenter running after Close")
+	}
+}
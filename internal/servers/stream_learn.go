@@ -0,0 +1,59 @@
+package servers
+
+import (
+	"time"
+)
+
+// ---- LEARNED CLIENT BEHAVIOUR ----
+
+// ClientHints is what an earlier session learned about the renderer.
+type ClientHints struct {
+	Ranges        bool // TV seeks: announce Accept-Ranges: bytes up front
+	ContentLength bool // TV wants a sized response instead of chunked
+	PreOpen       bool // TV gives up on slow starts: warm the pipeline early
+}
+
+// ClientReport describes one finished request, for OnClientReport.
+type ClientReport struct {
+	IP         string
+	UserAgent  string
+	Method     string
+	DidHEAD    bool
+	WantsRange bool
+
+	Mime      string
+	Container string
+	Live      bool
+	Chunked   bool // response had no Content-Length
+
+	Bytes     int64
+	FirstByte time.Duration // 0 = nothing was written
+	Aborted   bool          // client hung up before playback got going
+}
+
+// playbackBytes is how much a GET must pull before we call it playing.
+const playbackBytes = 1 << 20
+
+var (
+	hints      ClientHints
+	reportHook func(ClientReport)
+)
+
+// SetClientHints applies learned behaviour to the next ServeStream.
+func SetClientHints(h ClientHints) {
+	mu.Lock()
+	hints = h
+	mu.Unlock()
+}
+
+// OnClientReport registers fn to be called after every stream request.
+func OnClientReport(fn func(ClientReport)) {
+	mu.Lock()
+	reportHook = fn
+	mu.Unlock()
+}
+
+// Playing reports whether the request got far enough to count as playback.
+func (r ClientReport) Playing() bool {
+	return r.Method == "GET" && r.Bytes >= playbackBytes
+}
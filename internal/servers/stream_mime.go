@@ -0,0 +1,32 @@
+package servers
+
+import "time"
+
+// ---- MIME NEGOTIATION ----
+
+// activeStream is the running ServeStream instance, guarded by mu.
+var activeStream *streamStatus
+
+// SetStreamMime switches the Content-Type served for the stream, so a
+// renderer that rejected one mime can be offered the next candidate.
+func SetStreamMime(mime string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if activeStream != nil {
+		activeStream.mime = mime
+	}
+}
+
+// FetchedSince reports whether the renderer GET the stream after t.
+// Requests from --allow clients or this machine do not count.
+func FetchedSince(t time.Time) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return activeStream != nil && activeStream.lastGET.After(t)
+}
+
+func (st *streamStatus) currentMime() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return st.mime
+}
@@ -0,0 +1,273 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"renderctl/logger"
+)
+
+// ---- STATS (/status endpoint + --stats console summary) ----
+
+const (
+	maxRequestHistory = 50
+	rateWindow        = 2 * time.Second
+	statsInterval     = 10 * time.Second
+)
+
+// SourceDetails is implemented by sources with extra state worth
+// reporting (resolver name, radio title, restarts, ...).
+type SourceDetails interface {
+	Details() map[string]any
+}
+
+// RequestRecord is one HTTP request from a client.
+type RequestRecord struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	Range    string        `json:"range,omitempty"`
+	Status   int           `json:"status"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// clientStats is the traffic side of a ClientProfile. Guarded by mu.
+type clientStats struct {
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Active    int // open GET responses
+	BytesSent int64
+	Requests  []RequestRecord
+
+	windowStart time.Time
+	windowBytes int64
+	rate        float64 // bytes/s over the last window
+}
+
+func (s *clientStats) record(rec RequestRecord) {
+	s.Requests = append(s.Requests, rec)
+	if n := len(s.Requests); n > maxRequestHistory {
+		s.Requests = s.Requests[n-maxRequestHistory:]
+	}
+}
+
+func (s *clientStats) addBytes(n int64, now time.Time) {
+	s.BytesSent += n
+	s.LastSeen = now
+
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+	s.windowBytes += n
+	if el := now.Sub(s.windowStart); el >= rateWindow {
+		s.rate = float64(s.windowBytes) / el.Seconds()
+		s.windowStart = now
+		s.windowBytes = 0
+	}
+}
+
+// throughput is 0 once a client stops pulling.
+func (s *clientStats) throughput(now time.Time) float64 {
+	if now.Sub(s.LastSeen) > 2*rateWindow {
+		return 0
+	}
+	return s.rate
+}
+
+// countingWriter feeds bytes written into the client's stats.
+type countingWriter struct {
+	http.ResponseWriter
+	p      *ClientProfile
+	status int
+	bytes  int64
+	first  time.Time // first byte written
+}
+
+func (c *countingWriter) WriteHeader(code int) {
+	c.status = code
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	n, err := c.ResponseWriter.Write(b)
+	if n > 0 && c.first.IsZero() {
+		c.first = time.Now()
+	}
+	c.bytes += int64(n)
+
+	mu.Lock()
+	c.p.addBytes(int64(n), time.Now())
+	mu.Unlock()
+	return n, err
+}
+
+func (c *countingWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// ---- SNAPSHOT ----
+
+type statusClient struct {
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	FirstSeen  time.Time       `json:"first_seen"`
+	LastSeen   time.Time       `json:"last_seen"`
+	Active     int             `json:"active_connections"`
+	BytesSent  int64           `json:"bytes_sent"`
+	Throughput float64         `json:"throughput_bps"`
+	DidHEAD    bool            `json:"did_head"`
+	WantsRange bool            `json:"wants_range"`
+	Requests   []RequestRecord `json:"requests"`
+}
+
+type statusSource struct {
+	Live      bool           `json:"live"`
+	Running   bool           `json:"running"`
+	Starts    int            `json:"starts"`
+	Restarts  int            `json:"restarts"`
+	Readers   int            `json:"readers,omitempty"`
+	BytesRead int64          `json:"bytes_read,omitempty"`
+	LastError string         `json:"last_error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type statusReport struct {
+	Started   time.Time      `json:"started"`
+	Uptime    string         `json:"uptime"`
+	Path      string         `json:"path"`
+	Mime      string         `json:"mime"`
+	Container string         `json:"container"`
+	Source    statusSource   `json:"source"`
+	BytesSent int64          `json:"bytes_sent"`
+	Clients   []statusClient `json:"clients"`
+}
+
+// streamStatus describes one ServeStream instance.
+type streamStatus struct {
+	started   time.Time
+	path      string
+	mime      string
+	container StreamContainer
+	source    StreamSource
+	hub       *broadcaster
+
+	opens   int       // direct (non-hub) source opens, guarded by mu
+	lastGET time.Time // last media GET from the renderer, guarded by mu
+}
+
+func (st *streamStatus) snapshot() statusReport {
+	now := time.Now()
+
+	rep := statusReport{
+		Started:   st.started,
+		Uptime:    now.Sub(st.started).Round(time.Second).String(),
+		Path:      st.path,
+		Mime:      st.currentMime(),
+		Container: st.container.Key(),
+		Source:    statusSource{Live: isLive(st.source)},
+	}
+
+	if st.hub != nil {
+		h := st.hub.stats()
+		rep.Source.Running = h.running
+		rep.Source.Starts = h.starts
+		rep.Source.Readers = h.readers
+		rep.Source.BytesRead = h.bytes
+		rep.Source.LastError = h.lastErr
+	}
+	if d, ok := st.source.(SourceDetails); ok {
+		rep.Source.Details = d.Details()
+	}
+
+	mu.Lock()
+	if st.hub == nil {
+		rep.Source.Starts = st.opens
+	}
+	for _, p := range profiles {
+		c := statusClient{
+			IP:         p.IP,
+			UserAgent:  p.UserAgent,
+			FirstSeen:  p.FirstSeen,
+			LastSeen:   p.LastSeen,
+			Active:     p.Active,
+			BytesSent:  p.BytesSent,
+			Throughput: p.throughput(now),
+			DidHEAD:    p.DidHEAD,
+			WantsRange: p.WantsRange,
+			Requests:   append([]RequestRecord(nil), p.Requests...),
+		}
+		rep.BytesSent += p.BytesSent
+		rep.Clients = append(rep.Clients, c)
+	}
+	mu.Unlock()
+
+	if rep.Source.Starts > 1 {
+		rep.Source.Restarts = rep.Source.Starts - 1
+	}
+	sort.Slice(rep.Clients, func(i, j int) bool { return rep.Clients[i].IP < rep.Clients[j].IP })
+	return rep
+}
+
+func (st *streamStatus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(st.snapshot())
+}
+
+// logSummary prints one --stats line per client plus the source state.
+func (st *streamStatus) logSummary() {
+	rep := st.snapshot()
+
+	state := "idle"
+	if rep.Source.Running {
+		state = "running"
+	}
+	if !rep.Source.Live {
+		state = "file"
+	}
+	logger.Status(
+		"[stats] uptime=%s source=%s restarts=%d sent=%s clients=%d",
+		rep.Uptime, state, rep.Source.Restarts, humanBytes(rep.BytesSent), len(rep.Clients),
+	)
+	for _, c := range rep.Clients {
+		logger.Status(
+			"[stats]   %s active=%d sent=%s rate=%.2f Mbit/s requests=%d",
+			c.IP, c.Active, humanBytes(c.BytesSent), c.Throughput*8/1e6, len(c.Requests),
+		)
+	}
+}
+
+func (st *streamStatus) runSummary(every time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			st.logSummary()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -1,15 +1,43 @@
 package servers
 
+import "io"
+
 type StreamSource interface {
 	Open() (StreamReadCloser, error)
 }
 
+// LiveSource marks sources backed by a running pipeline (yt-dlp, ffmpeg, ...).
+// The stream server opens them ONCE and fans the output out to every client.
+type LiveSource interface {
+	StreamSource
+	Live() bool
+}
+
 type StreamContainer interface {
 	Key() string
 	MimeCandidates() []string
 }
 
+// HLSAudioEncoder is implemented by an "hls" container whose input audio
+// cannot be stream-copied into MPEG-TS segments. HLSAudio names the
+// ffmpeg encoder to use instead ("" copies).
+type HLSAudioEncoder interface {
+	HLSAudio() string
+}
+
 type StreamReadCloser interface {
 	Read(p []byte) (int, error)
 	Close() error
 }
+
+func isLive(s StreamSource) bool {
+	l, ok := s.(LiveSource)
+	return ok && l.Live()
+}
+
+// SeekableSource is a plain file: it can be served with Content-Length
+// and byte ranges instead of one chunked response.
+type SeekableSource interface {
+	StreamSource
+	OpenSeekable() (io.ReadSeekCloser, error)
+}
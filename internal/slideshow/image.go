@@ -0,0 +1,175 @@
+package slideshow
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DLNA JPEG profiles, largest first
+var jpegProfiles = []struct {
+	name string
+	w, h int
+}{
+	{"JPEG_LRG", 4096, 4096},
+	{"JPEG_MED", 1024, 768},
+	{"JPEG_SM", 640, 480},
+}
+
+type limits struct {
+	profile string // "" = renderer did not say, no downscaling
+	w, h    int
+	png     bool // renderer lists image/png
+}
+
+// limitsFor reads the largest JPEG profile and PNG support from the
+// renderer's cached media map.
+func limitsFor(media map[string][]string) limits {
+	var l limits
+
+	for mime, profiles := range media {
+		switch strings.ToLower(mime) {
+		case "image/png":
+			l.png = true
+		case "image/jpeg":
+			for _, jp := range jpegProfiles {
+				if l.profile != "" {
+					break
+				}
+				for _, p := range profiles {
+					if strings.Contains(strings.ToUpper(p), "DLNA.ORG_PN="+jp.name) {
+						l.profile, l.w, l.h = jp.name, jp.w, jp.h
+						break
+					}
+				}
+			}
+		}
+	}
+	return l
+}
+
+type slide struct {
+	data   []byte
+	mime   string
+	width  int
+	height int
+	note   string // what was done to the original, for logging
+}
+
+func (s *slide) resolution() string {
+	return strconv.Itoa(s.width) + "x" + strconv.Itoa(s.height)
+}
+
+// prepare loads an image and, when needed, downscales it to the
+// renderer's JPEG profile and/or re-encodes PNG as JPEG.
+func prepare(path string, l limits) (*slide, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := fitWithin(conf.Width, conf.Height, l)
+	scale := w != conf.Width || h != conf.Height
+	convert := format == "png" && !l.png
+
+	if !scale && !convert {
+		return &slide{
+			data:   raw,
+			mime:   "image/" + format,
+			width:  conf.Width,
+			height: conf.Height,
+		}, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	dst := downscale(src, w, h)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+
+	s := &slide{data: buf.Bytes(), mime: "image/jpeg", width: w, height: h}
+	switch {
+	case scale && convert:
+		s.note = "PNG → JPEG, downscaled to " + l.profile
+	case scale:
+		s.note = "downscaled to " + l.profile
+	default:
+		s.note = "PNG → JPEG"
+	}
+	return s, nil
+}
+
+// fitWithin returns the size that fits the profile box (either
+// orientation), keeping the aspect ratio.
+func fitWithin(w, h int, l limits) (int, int) {
+	if l.profile == "" || w <= 0 || h <= 0 {
+		return w, h
+	}
+	maxW, maxH := l.w, l.h
+	if h > w {
+		maxW, maxH = maxH, maxW
+	}
+	if w <= maxW && h <= maxH {
+		return w, h
+	}
+
+	if w*maxH > h*maxW {
+		return maxW, max(1, h*maxW/w)
+	}
+	return max(1, w*maxH/h), maxH
+}
+
+// downscale box-filters src into a w×h RGBA image. Transparent areas
+// end up black since JPEG has no alpha.
+func downscale(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, b.Min, draw.Over)
+
+	if w == b.Dx() && h == b.Dy() {
+		return flat
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sw, sh := b.Dx(), b.Dy()
+
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+
+			var r, g, bl, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := flat.Pix[sy*flat.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4:]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					bl += uint32(p[2])
+					n++
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0], d[1], d[2], d[3] = uint8(r/n), uint8(g/n), uint8(bl/n), 0xFF
+		}
+	}
+	return dst
+}
@@ -0,0 +1,92 @@
+package slideshow
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"renderctl/internal/avtransport"
+	"renderctl/internal/models"
+	"renderctl/internal/servers"
+	"renderctl/logger"
+)
+
+// Photos lists the JPEG/PNG files of dir in name order.
+func Photos(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".jpg", ".jpeg", ".png":
+			out = append(out, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Run casts every photo of cfg.LDir in turn, cfg.SlideInterval apart.
+// Images are served from memory by the default HTTP server.
+func Run(cfg *models.Config, controlURL string) {
+	photos, err := Photos(cfg.LDir)
+	if err != nil {
+		logger.Error("Slideshow: %v", err)
+		return
+	}
+	if len(photos) == 0 {
+		logger.Error("Slideshow: no JPEG/PNG images in %s", cfg.LDir)
+		return
+	}
+
+	var media map[string][]string
+	if cfg.CachedConnMgrURL != "" {
+		media, _ = avtransport.FetchMediaProtocols(cfg.CachedConnMgrURL)
+	}
+	l := limitsFor(media)
+	if l.profile != "" {
+		logger.Info("Renderer JPEG profile: %s (%dx%d)", l.profile, l.w, l.h)
+	}
+
+	logger.Notify("Slideshow: %d photos, %v each", len(photos), cfg.SlideInterval)
+
+	servers.AllowRenderer(controlURL)
+
+	shown := 0
+	for i, p := range photos {
+		s, err := prepare(p, l)
+		if err != nil {
+			logger.Notify("Skipping %s: %v", filepath.Base(p), err)
+			continue
+		}
+		if s.note != "" {
+			logger.Info("%s: %s", filepath.Base(p), s.note)
+		}
+
+		target := avtransport.Target{
+			ControlURL: controlURL,
+			MediaURL:   "http://" + cfg.LIP + ":" + cfg.ServePort + servers.PublishImage("slide", s.data, s.mime),
+			Mime:       s.mime,
+			Title:      strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)),
+			Resolution: s.resolution(),
+		}
+
+		logger.Status("Photo %d/%d: %s", i+1, len(photos), filepath.Base(p))
+		avtransport.Run(target, avtransport.MetadataForVendor(cfg.TVVendor, target))
+		shown++
+
+		if i < len(photos)-1 {
+			time.Sleep(cfg.SlideInterval)
+		}
+	}
+
+	logger.Done("Slideshow finished (%d photos)", shown)
+}
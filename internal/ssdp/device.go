@@ -4,24 +4,50 @@ import (
 	"encoding/xml"
 	"net/http"
 	"net/url"
-	"renderctl/logger"
 	"strings"
 )
 
 type DeviceDescription struct {
-	Device struct {
-		Manufacturer string `xml:"manufacturer"`
-		ModelName    string `xml:"modelName"`
-		UDN          string `xml:"UDN"`
-
-		ServiceList struct {
-			Services []struct {
-				ServiceType string `xml:"serviceType"`
-				ControlURL  string `xml:"controlURL"`
-				SCPDURL     string `xml:"SCPDURL"`
-			} `xml:"service"`
-		} `xml:"serviceList"`
-	} `xml:"device"`
+	Device DeviceNode `xml:"device"`
+}
+
+type DeviceNode struct {
+	DeviceType   string `xml:"deviceType"`
+	FriendlyName string `xml:"friendlyName"`
+	Manufacturer string `xml:"manufacturer"`
+	ModelName    string `xml:"modelName"`
+	UDN          string `xml:"UDN"`
+
+	ServiceList struct {
+		Services []Service `xml:"service"`
+	} `xml:"serviceList"`
+
+	// Speakers / AVRs often nest the MediaRenderer as an embedded device
+	DeviceList struct {
+		Devices []DeviceNode `xml:"device"`
+	} `xml:"deviceList"`
+}
+
+type Service struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+	SCPDURL     string `xml:"SCPDURL"`
+}
+
+// renderer returns the (possibly embedded) device that owns AVTransport,
+// so ConnectionManager is taken from the renderer and not a sibling MediaServer.
+func (d DeviceNode) renderer() (DeviceNode, bool) {
+	for _, s := range d.ServiceList.Services {
+		if strings.Contains(s.ServiceType, "service:AVTransport") {
+			return d, true
+		}
+	}
+	for _, sub := range d.DeviceList.Devices {
+		if r, ok := sub.renderer(); ok {
+			return r, true
+		}
+	}
+	return d, false
 }
 
 type DetectedTV struct {
@@ -34,14 +60,23 @@ type DetectedTV struct {
 	ConnectionManagerCtrl string
 
 	UDN string
+
+	// from the root device description
+	FriendlyName string
+	Manufacturer string
+	ModelName    string
 }
 
 func FetchAndDetect(location string) (*DetectedTV, error) {
+	return CLI.FetchAndDetect(location)
+}
+
+func (l Log) FetchAndDetect(location string) (*DetectedTV, error) {
 	u, err := url.Parse(location)
 	if err != nil {
 		return nil, err
 	}
-	logger.Notify("SSDP LOCATION: %s", location)
+	l.notify("SSDP LOCATION: %s", location)
 
 	resp, err := http.Get(location)
 	if err != nil {
@@ -60,7 +95,8 @@ func FetchAndDetect(location string) (*DetectedTV, error) {
 	var avTransportSCPD string
 	var connMgrCtrl string
 
-	for _, s := range dd.Device.ServiceList.Services {
+	renderer, _ := dd.Device.renderer()
+	for _, s := range renderer.ServiceList.Services {
 		switch {
 		case strings.Contains(s.ServiceType, "service:AVTransport"):
 			avTransportCtrl = s.ControlURL
@@ -91,9 +127,9 @@ func FetchAndDetect(location string) (*DetectedTV, error) {
 	if port == "" {
 		port = "80"
 	}
-	logger.Info("Detected AVTransport ControlURL: %s", fix(avTransportCtrl))
-	logger.Info("Detected AVTransport SCPDURL : %s", fix(avTransportSCPD))
-	logger.Info("Detected ConnMgr ControlURL  : %s", fix(connMgrCtrl))
+	l.info("Detected AVTransport ControlURL: %s", fix(avTransportCtrl))
+	l.info("Detected AVTransport SCPDURL : %s", fix(avTransportSCPD))
+	l.info("Detected ConnMgr ControlURL  : %s", fix(connMgrCtrl))
 
 	return &DetectedTV{
 		IP:         u.Hostname(),
@@ -104,6 +140,10 @@ func FetchAndDetect(location string) (*DetectedTV, error) {
 		AVTransportSCPD:       fix(avTransportSCPD),
 		ConnectionManagerCtrl: fix(connMgrCtrl),
 		UDN:                   dd.Device.UDN,
+
+		FriendlyName: dd.Device.FriendlyName,
+		Manufacturer: dd.Device.Manufacturer,
+		ModelName:    dd.Device.ModelName,
 	}, nil
 
 }
@@ -109,6 +109,11 @@ func LooksLikeTV(d SSDPDevice) bool {
 		return true
 	}
 
+	// Audio-only renderers (speakers, AV receivers, streamers)
+	if looksLikeAudioRenderer(srv) {
+		return true
+	}
+
 	// Chromecast / Android TV style
 	if strings.Contains(usn, "mdx") || strings.Contains(usn, "dial") {
 		return true
@@ -126,8 +131,55 @@ func LooksLikeTV(d SSDPDevice) bool {
 	return false
 }
 
+var audioRendererHints = []string{
+	"sonos", "denon", "marantz", "heos", "yamaha", "musiccast",
+	"onkyo", "pioneer", "bose", "bluesound", "linkplay", "wiim",
+	"upmpdcli", "gmediarender", "volumio",
+}
+
+func looksLikeAudioRenderer(srv string) bool {
+	for _, h := range audioRendererHints {
+		if strings.Contains(srv, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Log routes the progress messages of discovery. The package functions
+// print through the CLI logger; a library caller supplies its own, and
+// nil fields drop that level.
+type Log struct {
+	Notify, Info, Done func(format string, a ...any)
+}
+
+// CLI is the Log used by the package-level functions.
+var CLI = Log{Notify: logger.Notify, Info: logger.Info, Done: logger.Done}
+
+func (l Log) notify(format string, a ...any) {
+	if l.Notify != nil {
+		l.Notify(format, a...)
+	}
+}
+
+func (l Log) info(format string, a ...any) {
+	if l.Info != nil {
+		l.Info(format, a...)
+	}
+}
+
+func (l Log) done(format string, a ...any) {
+	if l.Done != nil {
+		l.Done(format, a...)
+	}
+}
+
 func ListenNotify(timeout time.Duration, ip string) ([]SSDPDevice, error) {
-	logger.Notify("Listening for SSDP NOTIFY packets (%v)", timeout)
+	return CLI.ListenNotify(timeout, ip)
+}
+
+func (l Log) ListenNotify(timeout time.Duration, ip string) ([]SSDPDevice, error) {
+	l.notify("Listening for SSDP NOTIFY packets (%v)", timeout)
 
 	addr, _ := net.ResolveUDPAddr("udp4", "239.255.255.250:1900")
 
@@ -160,15 +212,15 @@ func ListenNotify(timeout time.Duration, ip string) ([]SSDPDevice, error) {
 		if strings.Contains(resp, "NOTIFY") &&
 			(strings.Contains(resp, "ssdp:alive") ||
 				strings.Contains(resp, "ssdp:byebye")) {
-			dev := parseSSDP(resp)
+			dev := l.parseSSDP(resp)
 			if dev.Location != "" || dev.USN != "" {
-				logger.Info("SSDP NOTIFY device: %s", dev.Location)
+				l.info("SSDP NOTIFY device: %s", dev.Location)
 				devices = append(devices, dev)
 			}
 		}
 	}
 
-	logger.Done("SSDP NOTIFY finished — %d device(s) found", len(devices))
+	l.done("SSDP NOTIFY finished — %d device(s) found", len(devices))
 	return devices, nil
 }
 
@@ -192,7 +244,11 @@ func sendSearch(conn net.PacketConn, st string) error {
 }
 
 func Discover(timeout time.Duration) ([]SSDPDevice, error) {
-	logger.Notify("Starting SSDP active discovery (%v)", timeout)
+	return CLI.Discover(timeout)
+}
+
+func (l Log) Discover(timeout time.Duration) ([]SSDPDevice, error) {
+	l.notify("Starting SSDP active discovery (%v)", timeout)
 
 	conn, err := net.ListenPacket("udp4", ":0")
 	if err != nil {
@@ -207,7 +263,7 @@ func Discover(timeout time.Duration) ([]SSDPDevice, error) {
 	buf := make([]byte, 2048)
 
 	for _, st := range ssdpSearches {
-		logger.Info("SSDP M-SEARCH for ST: %s", st)
+		l.info("SSDP M-SEARCH for ST: %s", st)
 
 		// Send once, TVs often ignore rapid spam
 		_ = sendSearch(conn, st)
@@ -223,10 +279,10 @@ func Discover(timeout time.Duration) ([]SSDPDevice, error) {
 		}
 
 		resp := string(buf[:n])
-		dev := parseSSDP(resp)
+		dev := l.parseSSDP(resp)
 
 		if dev.Location != "" || dev.USN != "" {
-			logger.Info("SSDP response: %s", dev.Location)
+			l.info("SSDP response: %s", dev.Location)
 			devices[dev.Location] = dev
 		}
 	}
@@ -236,11 +292,11 @@ func Discover(timeout time.Duration) ([]SSDPDevice, error) {
 		result = append(result, d)
 	}
 
-	logger.Done("SSDP discovery completed — %d unique device(s) found", len(result))
+	l.done("SSDP discovery completed — %d unique device(s) found", len(result))
 	return result, nil
 }
 
-func parseSSDP(resp string) SSDPDevice {
+func (l Log) parseSSDP(resp string) SSDPDevice {
 	lines := strings.Split(resp, "\r\n")
 	var d SSDPDevice
 
@@ -255,8 +311,49 @@ func parseSSDP(resp string) SSDPDevice {
 			d.USN = strings.TrimSpace(l[4:])
 		}
 	}
-	logger.Info("Parsed SSDP headers: LOCATION=%s SERVER=%s USN=%s",
+	l.info("Parsed SSDP headers: LOCATION=%s SERVER=%s USN=%s",
 		d.Location, d.Server, d.USN)
 
 	return d
 }
+
+// SearchUDN looks for one device by its UDN and returns its description
+// LOCATION. Devices that ignore an M-SEARCH for their uuid are still
+// matched by USN in the MediaRenderer and ssdp:all replies.
+func SearchUDN(udn string, timeout time.Duration) (string, error) {
+	udn = strings.TrimSpace(udn)
+	if !strings.HasPrefix(strings.ToLower(udn), "uuid:") {
+		udn = "uuid:" + udn
+	}
+	logger.Info("SSDP search for %s (%v)", udn, timeout)
+
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	for _, st := range []string{
+		udn,
+		"urn:schemas-upnp-org:device:MediaRenderer:1",
+		"ssdp:all",
+	} {
+		_ = sendSearch(conn, st)
+	}
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return "", fmt.Errorf("%s did not answer SSDP within %v", udn, timeout)
+		}
+
+		dev := CLI.parseSSDP(string(buf[:n]))
+		usn, want := strings.ToLower(dev.USN), strings.ToLower(udn)
+		if dev.Location != "" && (usn == want || strings.HasPrefix(usn, want+"::")) {
+			return dev.Location, nil
+		}
+	}
+}
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"errors"
+	"renderctl/internal/mediainfo"
 	"renderctl/internal/servers"
 	"strings"
 )
@@ -30,10 +31,137 @@ func (p passthroughContainer) MimeCandidates() []string {
 	}
 }
 
+type mkvContainer struct{}
+
+func (mkvContainer) Key() string { return "mkv" }
+
+func (mkvContainer) MimeCandidates() []string {
+	return []string{
+		"video/x-matroska",
+		"video/x-mkv",
+		"video/webm",
+		"application/octet-stream",
+	}
+}
+
+// hlsContainer serves a rolling playlist window instead of one endless response.
+type hlsContainer struct {
+	audio string // encoder for audio MPEG-TS cannot carry, "" = copy
+}
+
+func (hlsContainer) Key() string { return "hls" }
+
+func (h hlsContainer) HLSAudio() string { return h.audio }
+
+func (hlsContainer) MimeCandidates() []string {
+	return []string{
+		"application/vnd.apple.mpegurl",
+		"application/x-mpegurl",
+		"audio/mpegurl",
+	}
+}
+
+// audioContainer serves audio files as-is under an audio/* mime.
+type audioContainer struct {
+	format string // mp3, flac, ogg, wav, aac, m4a
+}
+
+func (a audioContainer) Key() string { return a.format }
+
+func (a audioContainer) MimeCandidates() []string {
+	return (&mediainfo.Info{Container: a.format}).MimeCandidates()
+}
+
+// tsAudioCodecs can be stream-copied into the MPEG-TS segments of HLS output.
+var tsAudioCodecs = map[string]bool{"aac": true, "mp3": true, "mp2": true, "ac3": true, "eac3": true}
+
+// hlsAudio returns the encoder HLS output needs for the audio of the
+// given input ("" copies). FLAC, PCM, Vorbis, Opus or ALAC cannot go into
+// MPEG-TS as they are; inputs of unknown audio (pipes, resolvers, which
+// remux to TS themselves) are copied.
+func hlsAudio(container servers.StreamContainer, mi *mediainfo.Info) string {
+	codec := ""
+	if mi != nil {
+		if a := mi.Audio(); a != nil {
+			codec = a.Codec
+		}
+	}
+	switch c := container.(type) {
+	case transcodeContainer:
+		codec = c.profile.AudioCodec
+	case audioContainer:
+		if codec == "" {
+			switch c.format {
+			case "mp3", "aac":
+				codec = c.format
+			case "m4a":
+				codec = "aac"
+			default:
+				return "aac"
+			}
+		}
+	}
+	if codec == "" || tsAudioCodecs[codec] {
+		return ""
+	}
+	return "aac"
+}
+
+// sinkWantsHLS reports whether the renderer lists an HLS playlist mime.
+func sinkWantsHLS(media map[string][]string) bool {
+	for m := range media {
+		for _, c := range (hlsContainer{}).MimeCandidates() {
+			if strings.EqualFold(m, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Registry for containers
 var containerRegistry = map[string]servers.StreamContainer{
 	"ts":          tsContainer{},
 	"passthrough": passthroughContainer{},
+	"mkv":         mkvContainer{},
+	"hls":         hlsContainer{},
+
+	"mp3":  audioContainer{"mp3"},
+	"flac": audioContainer{"flac"},
+	"ogg":  audioContainer{"ogg"},
+	"wav":  audioContainer{"wav"},
+	"aac":  audioContainer{"aac"},
+	"m4a":  audioContainer{"m4a"},
+}
+
+// containerForFile maps a parsed file container to a registry key.
+func containerForFile(container string) string {
+	switch container {
+	case "mp4", "mov":
+		return "passthrough"
+	case "mkv", "webm":
+		return "mkv"
+	case "mp3", "flac", "ogg", "wav", "aac", "m4a":
+		return container
+	default:
+		return "ts"
+	}
+}
+
+// containerFromFlag maps a --container value (ts, mp4, mkv, webm, mp3, ...)
+// to a registry key.
+func containerFromFlag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, ok := containerRegistry[name]; ok {
+		return name, nil
+	}
+	switch name {
+	case "mp4", "mov", "webm":
+		return containerForFile(name), nil
+	case "mpegts", "m2ts":
+		return "ts", nil
+	}
+	return "", errors.New("unknown --container: " + name + " (ts | mp4 | mkv | webm | mp3 | flac | ogg | wav | aac | m4a | hls)")
 }
 
 func GetContainer(key string) (servers.StreamContainer, error) {
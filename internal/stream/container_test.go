@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"testing"
+
+	"renderctl/internal/mediainfo"
+	"renderctl/internal/servers"
+	"renderctl/internal/transcode"
+)
+
+func TestHLSAudio(t *testing.T) {
+	withAudio := func(codec string) *mediainfo.Info {
+		return &mediainfo.Info{Tracks: []mediainfo.Track{
+			{Kind: mediainfo.KindVideo, Codec: "h264"},
+			{Kind: mediainfo.KindAudio, Codec: codec},
+		}}
+	}
+
+	cases := []struct {
+		name      string
+		container servers.StreamContainer
+		mi        *mediainfo.Info
+		want      string
+	}{
+		{"ts with aac", tsContainer{}, withAudio("aac"), ""},
+		{"ts with ac3", tsContainer{}, withAudio("ac3"), ""},
+		{"mkv with flac", mkvContainer{}, withAudio("flac"), "aac"},
+		{"mkv with opus", mkvContainer{}, withAudio("opus"), "aac"},
+		{"mp4 with alac", passthroughContainer{}, withAudio("alac"), "aac"},
+		{"flac file", audioContainer{"flac"}, nil, "aac"},
+		{"wav file", audioContainer{"wav"}, withAudio("pcm_s16le"), "aac"},
+		{"ogg radio", audioContainer{"ogg"}, nil, "aac"},
+		{"mp3 radio", audioContainer{"mp3"}, nil, ""},
+		{"m4a file", audioContainer{"m4a"}, withAudio("aac"), ""},
+		{"transcoded", transcodeContainer{transcode.Profile{AudioCodec: "aac"}}, withAudio("flac"), ""},
+		{"pipe", tsContainer{}, nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hlsAudio(tc.container, tc.mi); got != tc.want {
+				t.Fatalf("hlsAudio = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
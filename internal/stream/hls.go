@@ -0,0 +1,240 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---- HLS playlist parsing (RFC 8216 subset) ----
+
+type hlsVariant struct {
+	URI       string
+	Bandwidth int
+	Width     int
+	Height    int
+}
+
+type hlsKey struct {
+	Method string // NONE | AES-128
+	URI    string
+	IV     []byte // nil = derive from media sequence
+}
+
+type hlsSegment struct {
+	URI      string
+	Duration time.Duration
+	Seq      int64
+	Key      *hlsKey
+}
+
+type hlsPlaylist struct {
+	Master   bool
+	Variants []hlsVariant
+
+	TargetDuration time.Duration
+	MediaSequence  int64
+	Segments       []hlsSegment
+	EndList        bool
+	HasMap         bool // fMP4 init segment (not TS)
+}
+
+func parsePlaylist(body string, base *url.URL) (*hlsPlaylist, error) {
+	sc := bufio.NewScanner(strings.NewReader(body))
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+
+	if !sc.Scan() || !strings.HasPrefix(strings.TrimSpace(sc.Text()), "#EXTM3U") {
+		return nil, errors.New("hls: not an m3u8 playlist")
+	}
+
+	pl := &hlsPlaylist{}
+	var (
+		pendingVariant *hlsVariant
+		pendingDur     time.Duration
+		key            *hlsKey
+		seq            int64
+	)
+
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			attrs := parseAttrs(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+			v := hlsVariant{}
+			v.Bandwidth, _ = strconv.Atoi(attrs["BANDWIDTH"])
+			if res := attrs["RESOLUTION"]; res != "" {
+				if w, h, ok := strings.Cut(res, "x"); ok {
+					v.Width, _ = strconv.Atoi(w)
+					v.Height, _ = strconv.Atoi(h)
+				}
+			}
+			pendingVariant = &v
+			pl.Master = true
+
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			n, _ := strconv.ParseFloat(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"), 64)
+			pl.TargetDuration = time.Duration(n * float64(time.Second))
+
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			seq, _ = strconv.ParseInt(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"), 10, 64)
+			pl.MediaSequence = seq
+
+		case strings.HasPrefix(line, "#EXT-X-KEY:"):
+			attrs := parseAttrs(strings.TrimPrefix(line, "#EXT-X-KEY:"))
+			k := &hlsKey{Method: attrs["METHOD"], URI: resolveRef(base, attrs["URI"])}
+			if iv := attrs["IV"]; iv != "" {
+				k.IV = parseIV(iv)
+			}
+			if k.Method == "NONE" {
+				k = nil
+			}
+			key = k
+
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			pl.HasMap = true
+
+		case strings.HasPrefix(line, "#EXTINF:"):
+			v := strings.TrimPrefix(line, "#EXTINF:")
+			v, _, _ = strings.Cut(v, ",")
+			n, _ := strconv.ParseFloat(v, 64)
+			pendingDur = time.Duration(n * float64(time.Second))
+
+		case line == "#EXT-X-ENDLIST":
+			pl.EndList = true
+
+		case strings.HasPrefix(line, "#"):
+			// unsupported / informational tag
+
+		default:
+			ref := resolveRef(base, line)
+			if pendingVariant != nil {
+				pendingVariant.URI = ref
+				pl.Variants = append(pl.Variants, *pendingVariant)
+				pendingVariant = nil
+				continue
+			}
+			pl.Segments = append(pl.Segments, hlsSegment{
+				URI:      ref,
+				Duration: pendingDur,
+				Seq:      seq,
+				Key:      key,
+			})
+			seq++
+			pendingDur = 0
+		}
+	}
+
+	if pl.TargetDuration == 0 {
+		pl.TargetDuration = 6 * time.Second
+	}
+	return pl, sc.Err()
+}
+
+// parseAttrs splits an attribute list (KEY=VALUE,KEY="quoted,value").
+func parseAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	for s != "" {
+		k, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var v string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				v, rest = rest[1:], ""
+			} else {
+				v, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			v, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs[strings.ToUpper(strings.TrimSpace(k))] = v
+		s = rest
+	}
+	return attrs
+}
+
+func parseIV(s string) []byte {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s) > 32 {
+		return nil
+	}
+	s = strings.Repeat("0", 32-len(s)) + s
+	iv := make([]byte, 16)
+	for i := range iv {
+		b, err := strconv.ParseUint(s[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil
+		}
+		iv[i] = byte(b)
+	}
+	return iv
+}
+
+func resolveRef(base *url.URL, ref string) string {
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// pickVariant selects by quality: "best" | "worst" | "<height>p" | "<max bandwidth>".
+func pickVariant(variants []hlsVariant, quality string) hlsVariant {
+	vs := append([]hlsVariant{}, variants...)
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Bandwidth < vs[j].Bandwidth })
+
+	q := strings.ToLower(strings.TrimSpace(quality))
+	switch {
+	case q == "" || q == "best":
+		return vs[len(vs)-1]
+	case q == "worst":
+		return vs[0]
+	case strings.HasSuffix(q, "p"):
+		want, err := strconv.Atoi(strings.TrimSuffix(q, "p"))
+		if err != nil {
+			break
+		}
+		best := vs[0]
+		for _, v := range vs {
+			if v.Height <= want && v.Height >= best.Height {
+				best = v
+			}
+		}
+		return best
+	default:
+		limit, err := strconv.Atoi(q)
+		if err != nil {
+			break
+		}
+		best := vs[0]
+		for _, v := range vs {
+			if v.Bandwidth <= limit {
+				best = v
+			}
+		}
+		return best
+	}
+	return vs[len(vs)-1]
+}
+
+func isHLSURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Path), ".m3u8")
+}
@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePlaylist(t *testing.T) {
+	base, _ := url.Parse("https://cdn.example.com/live/channel/index.m3u8?token=abc")
+	aes := &hlsKey{
+		Method: "AES-128",
+		URI:    "https://cdn.example.com/keys/k1.bin",
+		IV:     []byte{15: 0x2a},
+	}
+
+	cases := []struct {
+		name    string
+		body    string
+		want    *hlsPlaylist
+		wantErr bool
+	}{
+		{
+			name: "master",
+			body: `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401e,mp4a.40.2"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,RESOLUTION=1920x1080,CODECS="avc1.640028,mp4a.40.2"
+https://other.example.net/hd/index.m3u8
+`,
+			want: &hlsPlaylist{
+				Master: true,
+				Variants: []hlsVariant{
+					{URI: "https://cdn.example.com/live/channel/low/index.m3u8", Bandwidth: 800000, Width: 640, Height: 360},
+					{URI: "https://other.example.net/hd/index.m3u8", Bandwidth: 5000000, Width: 1920, Height: 1080},
+				},
+				TargetDuration: 6 * time.Second,
+			},
+		},
+		{
+			name: "live variant",
+			body: `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:1041
+
+#EXTINF:4.5,
+seg1041.ts
+#EXTINF:3.5,title
+/abs/seg1042.ts
+`,
+			want: &hlsPlaylist{
+				TargetDuration: 4 * time.Second,
+				MediaSequence:  1041,
+				Segments: []hlsSegment{
+					{URI: "https://cdn.example.com/live/channel/seg1041.ts", Duration: 4500 * time.Millisecond, Seq: 1041},
+					{URI: "https://cdn.example.com/abs/seg1042.ts", Duration: 3500 * time.Millisecond, Seq: 1042},
+				},
+			},
+		},
+		{
+			name: "vod with key and map",
+			body: `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:10,
+a.m4s
+#EXT-X-KEY:METHOD=AES-128,URI="../../keys/k1.bin",IV=0x2A
+#EXTINF:10,
+b.m4s
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:2.5,
+c.m4s
+#EXT-X-ENDLIST
+`,
+			want: &hlsPlaylist{
+				TargetDuration: 10 * time.Second,
+				HasMap:         true,
+				EndList:        true,
+				Segments: []hlsSegment{
+					{URI: "https://cdn.example.com/live/channel/a.m4s", Duration: 10 * time.Second, Seq: 0},
+					{URI: "https://cdn.example.com/live/channel/b.m4s", Duration: 10 * time.Second, Seq: 1, Key: aes},
+					{URI: "https://cdn.example.com/live/channel/c.m4s", Duration: 2500 * time.Millisecond, Seq: 2},
+				},
+			},
+		},
+		{
+			name:    "not a playlist",
+			body:    "<html>404</html>",
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parsePlaylist(tc.body, base)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseAttrs(t *testing.T) {
+	got := parseAttrs(`BANDWIDTH=800000,CODECS="avc1.4d401e,mp4a.40.2",resolution=640x360`)
+	want := map[string]string{
+		"BANDWIDTH":  "800000",
+		"CODECS":     "avc1.4d401e,mp4a.40.2",
+		"RESOLUTION": "640x360",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"maps"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"renderctl/internal/cache"
+	"renderctl/internal/models"
+	"renderctl/internal/servers"
+	"renderctl/logger"
+)
+
+// slowStart is how long a TV waited for first bytes before we call
+// the source too slow and pre-open it next time.
+const slowStart = 3 * time.Second
+
+// tvHost is the cache key (IP) of the renderer being streamed to.
+// TryCache clears cfg.TIP, so fall back to the control URL host.
+func tvHost(cfg *models.Config) string {
+	if cfg.TIP != "" {
+		return cfg.TIP
+	}
+	u, err := url.Parse(cfg.CachedControlURL)
+	if err != nil {
+		return ""
+	}
+	host := u.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host
+}
+
+// loadLearned reads what earlier sessions learned about this TV.
+func loadLearned(cfg *models.Config, tv string) (cache.Behavior, bool) {
+	if !cfg.UseCache || tv == "" {
+		return cache.Behavior{}, false
+	}
+	return cache.LoadBehavior(tv)
+}
+
+func hintsFrom(b cache.Behavior) servers.ClientHints {
+	return servers.ClientHints{
+		Ranges:        b.SendsRange,
+		ContentLength: b.SendsRange || b.NeedsLength,
+		PreOpen:       b.SlowStart,
+	}
+}
+
+// learner buffers what the stream server observes about the TV and
+// writes it to the cache once the first GET is playing, then at stop
+// if anything new turned up after that.
+type learner struct {
+	tv string
+
+	mu      sync.Mutex
+	b       cache.Behavior // stored behaviour + this session's observations
+	dirty   bool           // b holds observations not yet persisted
+	flushed bool           // the first playing GET was persisted
+}
+
+// sessionLearner is the running session's learner, flushed at shutdown.
+var sessionLearner *learner
+
+// FlushLearned persists what this session learned about the TV that
+// has not been written yet. Call it once the stream is stopped.
+func FlushLearned() {
+	if sessionLearner != nil {
+		sessionLearner.flush()
+	}
+}
+
+func newLearner(tv string, known cache.Behavior) *learner {
+	known.Mimes = maps.Clone(known.Mimes)
+	return &learner{tv: tv, b: known}
+}
+
+// observe is the report hook. Requests from other clients are ignored.
+func (l *learner) observe(r servers.ClientReport) {
+	if r.IP != l.tv {
+		return
+	}
+
+	l.mu.Lock()
+	b := &l.b
+	set := func(field *bool, v bool, what string) {
+		if v && !*field {
+			*field = true
+			l.dirty = true
+			logger.Info("Learned about %s: %s", l.tv, what)
+		}
+	}
+
+	if r.UserAgent != "" && r.UserAgent != b.UserAgent {
+		b.UserAgent = r.UserAgent
+		l.dirty = true
+	}
+	set(&b.SendsHEAD, r.DidHEAD, "probes with HEAD")
+	set(&b.SendsRange, r.WantsRange, "requests byte ranges")
+	set(&b.NeedsLength, r.Aborted && r.Chunked && !r.Live, "drops chunked responses")
+	set(&b.SlowStart, r.Live &&
+		(r.FirstByte > slowStart || (r.Aborted && r.Bytes == 0)), "gives up on slow starts")
+
+	if r.Playing() && b.Mimes[r.Container] != r.Mime {
+		if b.Mimes == nil {
+			b.Mimes = map[string]string{}
+		}
+		b.Mimes[r.Container] = r.Mime
+		l.dirty = true
+		logger.Info("Learned about %s: plays %s as %s", l.tv, r.Container, r.Mime)
+	}
+
+	first := r.Playing() && !l.flushed
+	l.mu.Unlock()
+
+	if first {
+		l.flush()
+	}
+}
+
+// flush persists pending observations, if any.
+func (l *learner) flush() {
+	l.mu.Lock()
+	l.flushed = true
+	if !l.dirty {
+		l.mu.Unlock()
+		return
+	}
+	l.dirty = false
+	b := l.b
+	b.Mimes = maps.Clone(b.Mimes)
+	l.mu.Unlock()
+
+	cache.UpdateBehavior(l.tv, func(stored *cache.Behavior) bool {
+		return stored.Merge(b)
+	})
+}
+
+// rememberMime stores the mime a negotiation settled on, so the next
+// session offers it first.
+func rememberMime(tv, container, mime string) {
+	if tv == "" {
+		return
+	}
+	cache.UpdateBehavior(tv, func(b *cache.Behavior) bool {
+		if b.Mimes[container] == mime {
+			return false
+		}
+		if b.Mimes == nil {
+			b.Mimes = map[string]string{}
+		}
+		b.Mimes[container] = mime
+		logger.Notify("Remembering %s for %s on %s", mime, container, tv)
+		return true
+	})
+}
@@ -1,14 +1,23 @@
 package stream
 
-import "renderctl/internal/servers"
+import (
+	"renderctl/internal/avtransport"
+	"renderctl/internal/servers"
+)
 
 func selectMime(
 	container servers.StreamContainer,
 	supported map[string][]string,
 ) string {
+	fallback := "video/mpeg"
+	switch container.(type) {
+	case hlsContainer, audioContainer:
+		fallback = container.MimeCandidates()[0]
+	}
+
 	// If TV returned nothing → safe default for streaming
 	if len(supported) == 0 {
-		return "video/mpeg"
+		return fallback
 	}
 
 	for _, cand := range container.MimeCandidates() {
@@ -17,6 +26,11 @@ func selectMime(
 		}
 	}
 
+	// audio sinks often differ only in case (audio/L16, audio/x-flac)
+	if _, ok := container.(audioContainer); ok {
+		return avtransport.PickMime(container.MimeCandidates(), supported)
+	}
+
 	// Nothing matched → conservative fallback
-	return "video/mpeg"
+	return fallback
 }
@@ -8,6 +8,7 @@ import (
 	"renderctl/internal/servers"
 	"renderctl/internal/utils"
 	"renderctl/logger"
+	"renderctl/pkg/renderctl"
 )
 
 var runtimePlan *StreamPlan
@@ -21,6 +22,12 @@ func StartStreamServer(
 	plan *StreamPlan,
 	stop <-chan struct{},
 ) {
+	servers.SetClientHints(plan.Hints)
+	if cfg.UseCache && plan.TV != "" {
+		sessionLearner = newLearner(plan.TV, plan.Learned)
+		servers.OnClientReport(sessionLearner.observe)
+	}
+
 	servers.ServeStream(
 		cfg,
 		stop,
@@ -59,8 +66,157 @@ func StartStreamPlay(cfg *models.Config) {
 
 	target := avtransport.Target{
 		ControlURL: controlURL,
-		MediaURL:   BuildStreamURL(cfg, runtimePlan.StreamPath),
+		MediaURL:   BuildStreamURL(cfg, runtimePlan.MediaPath()),
+	}
+	servers.AllowRenderer(controlURL)
+	r := &renderctl.Renderer{ControlURL: controlURL, Logf: logger.Info}
+
+	// Audio files: track metadata + cover art so renderers show them
+	didl := false
+	rs, radio := runtimePlan.Source.(*radioSource)
+	if radio {
+		describeRadio(&target, rs)
+		didl = true
+	} else if _, ok := runtimePlan.Container.(audioContainer); ok && !isPipeSpec(cfg.LFile) {
+		tags := avtransport.DescribeFile(&target, cfg.LFile, nil)
+
+		if tags != nil {
+			if p := servers.SetAlbumArt(tags.Picture, tags.PictureMime); p != "" {
+				target.AlbumArtURL = BuildStreamURL(cfg, p)
+			}
+		}
+		didl = true
+	}
+
+	// no details: the generic flavour sends no DIDL for video
+	if didl {
+		r.Vendor = cfg.TVVendor
+	}
+
+	// metaFor builds the DIDL for one mime candidate
+	metaFor := func(t avtransport.Target) string {
+		if !didl {
+			return ""
+		}
+		return avtransport.MetadataForVendor(cfg.TVVendor, t)
+	}
+
+	candidates := mimeCandidates(runtimePlan)
+	if cfg.MimeTimeout <= 0 || len(candidates) < 2 {
+		target.Mime = runtimePlan.Mime
+		if err := r.Play(mediaOf(target)); err != nil {
+			logger.Error("%v", err)
+		}
+		if radio && cfg.RadioRefresh {
+			refreshRadio(r, target, rs)
+		}
+		return
+	}
+
+	mime, attempts, err := avtransport.Negotiate(
+		target,
+		candidates,
+		func(t avtransport.Target) string {
+			servers.SetStreamMime(t.Mime)
+			return metaFor(t)
+		},
+		servers.FetchedSince,
+		cfg.MimeTimeout,
+	)
+	reportAttempts(attempts)
+
+	if err != nil {
+		logger.Notify("Mime negotiation failed: %v (leaving the last attempt in place)", err)
+		return
+	}
+
+	runtimePlan.Mime = mime
+	if len(attempts) > 1 && cfg.UseCache {
+		rememberMime(runtimePlan.TV, runtimePlan.Container.Key(), mime)
 	}
 
-	avtransport.Run(target, "")
+	if radio && cfg.RadioRefresh {
+		target.Mime = mime
+		refreshRadio(r, target, rs)
+	}
+}
+
+// mimeCandidates puts the selected (or learned) mime first, then the
+// container's other candidates in their usual order.
+func mimeCandidates(p *StreamPlan) []string {
+	out := []string{p.Mime}
+	for _, m := range p.Container.MimeCandidates() {
+		if m != p.Mime {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func reportAttempts(attempts []avtransport.MimeAttempt) {
+	if len(attempts) < 2 {
+		return
+	}
+	logger.Status("Mime attempts:")
+	for i, a := range attempts {
+		logger.Status("  %d. %-32s %s", i+1, a.Mime, a.Result)
+	}
+}
+
+// describeRadio fills the station DIDL details.
+func describeRadio(t *avtransport.Target, rs *radioSource) {
+	t.Class = "object.item.audioItem.audioBroadcast"
+	t.Album = rs.Name()
+	t.Title = rs.Title()
+	if t.Title == "" {
+		t.Title = rs.Name()
+	}
+}
+
+// mediaOf is t for renderctl.Renderer.Play.
+func mediaOf(t avtransport.Target) renderctl.Media {
+	return renderctl.Media{
+		URL:         t.MediaURL,
+		Mime:        t.Mime,
+		Title:       t.Title,
+		Artist:      t.Artist,
+		Album:       t.Album,
+		Duration:    t.Duration,
+		Resolution:  t.Resolution,
+		AlbumArtURL: t.AlbumArtURL,
+		Class:       t.Class,
+	}
+}
+
+// refreshRadio re-sends the URI (--radio-refresh) with the new DIDL
+// whenever StreamTitle changes. base carries the accepted mime.
+//
+// One worker sends the refreshes in order; titles that change again
+// while a refresh is in flight collapse into the latest one.
+func refreshRadio(r *renderctl.Renderer, base avtransport.Target, rs *radioSource) {
+	wake := make(chan struct{}, 1)
+	rs.OnTitle(func(string) {
+		select {
+		case wake <- struct{}{}:
+		default: // a refresh is already pending
+		}
+	})
+
+	go func() {
+		sent := base.Title
+		for range wake {
+			title := rs.Title()
+			if title == sent {
+				continue
+			}
+			sent = title
+
+			next := base
+			next.Title = title
+			logger.Info("Refreshing renderer metadata: %s", title)
+			if err := r.Play(mediaOf(next)); err != nil {
+				logger.Notify("Metadata refresh failed: %v", err)
+			}
+		}
+	}()
 }
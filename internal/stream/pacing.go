@@ -0,0 +1,193 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"renderctl/internal/servers"
+	"renderctl/logger"
+)
+
+// ---- PCR pacing (MPEG-TS released at real-time rate) ----
+
+const (
+	tsPacket   = 188
+	pcrClock   = 27_000_000              // PCR ticks per second
+	pcrWrap    = (uint64(1) << 33) * 300 // PCR rolls over here
+	pcrMaxJump = 10 * pcrClock           // larger jumps are discontinuities
+)
+
+// pacedSource wraps a TS source so packets leave no faster than their
+// PCR timeline allows, at most lead ahead of real time. It always
+// reports Live so every client shares one paced timeline.
+type pacedSource struct {
+	inner servers.StreamSource
+	lead  time.Duration
+}
+
+func newPacedSource(inner servers.StreamSource, lead time.Duration) *pacedSource {
+	return &pacedSource{inner: inner, lead: lead}
+}
+
+func (s *pacedSource) Live() bool { return true }
+
+func (s *pacedSource) Details() map[string]any {
+	d := map[string]any{"paced": true, "pace_lead": s.lead.String()}
+	if inner, ok := s.inner.(servers.SourceDetails); ok {
+		for k, v := range inner.Details() {
+			d[k] = v
+		}
+	}
+	return d
+}
+
+func (s *pacedSource) Open() (servers.StreamReadCloser, error) {
+	rc, err := s.inner.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &pacedReader{rc: rc, lead: s.lead, pid: -1, done: make(chan struct{})}, nil
+}
+
+type pacedReader struct {
+	rc   servers.StreamReadCloser
+	lead time.Duration
+
+	in  []byte // upstream bytes not yet released
+	out []byte // released bytes not yet returned
+	eof error
+
+	pid   int // PCR PID we lock onto (-1 = none yet)
+	have  bool
+	base  uint64    // PCR at start
+	start time.Time // wall clock at start
+	last  uint64
+
+	once sync.Once
+	done chan struct{}
+}
+
+func (r *pacedReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if len(r.in) < tsPacket && r.eof == nil {
+			r.fill()
+			continue
+		}
+		if len(r.in) < tsPacket {
+			// trailing partial packet
+			r.out, r.in = r.in, nil
+			if len(r.out) == 0 {
+				return 0, r.eof
+			}
+			break
+		}
+		if err := r.release(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *pacedReader) fill() {
+	buf := make([]byte, 64*tsPacket)
+	n, err := r.rc.Read(buf)
+	r.in = append(r.in, buf[:n]...)
+	if err != nil {
+		r.eof = err
+	}
+}
+
+// release moves packets from in to out, stopping at a PCR packet that
+// is not due yet (after waiting for it when out is still empty).
+func (r *pacedReader) release() error {
+	for len(r.in) >= tsPacket {
+		// resync on lost alignment
+		if r.in[0] != 0x47 {
+			i := bytes.IndexByte(r.in[1:], 0x47)
+			if i < 0 {
+				r.out = append(r.out, r.in...)
+				r.in = nil
+				return nil
+			}
+			r.out = append(r.out, r.in[:i+1]...)
+			r.in = r.in[i+1:]
+			continue
+		}
+
+		pkt := r.in[:tsPacket]
+		if pcr, ok := r.pcrOf(pkt); ok {
+			if wait := r.due(pcr); wait > 0 {
+				if len(r.out) > 0 {
+					return nil // hand out what we have first
+				}
+				select {
+				case <-time.After(wait):
+				case <-r.done:
+					return io.EOF
+				}
+			}
+			r.last = pcr
+		}
+
+		r.out = append(r.out, pkt...)
+		r.in = r.in[tsPacket:]
+	}
+	return nil
+}
+
+// pcrOf returns the PCR of pkt if it is on the locked PCR PID.
+func (r *pacedReader) pcrOf(pkt []byte) (uint64, bool) {
+	pcr, ok := readPCR(pkt)
+	if !ok {
+		return 0, false
+	}
+	pid := int(pkt[1]&0x1F)<<8 | int(pkt[2])
+	if r.pid < 0 {
+		r.pid = pid
+	}
+	return pcr, pid == r.pid
+}
+
+// due returns how long to hold the packet carrying pcr.
+func (r *pacedReader) due(pcr uint64) time.Duration {
+	now := time.Now()
+
+	if r.have {
+		step := (pcr + pcrWrap - r.last) % pcrWrap
+		if step > pcrMaxJump {
+			logger.Info("PCR discontinuity — re-basing pacing clock")
+			r.have = false
+		}
+	}
+	if !r.have {
+		r.have = true
+		r.base = pcr
+		r.start = now
+		return 0
+	}
+
+	elapsed := (pcr + pcrWrap - r.base) % pcrWrap
+	target := r.start.Add(time.Duration(elapsed*1000/(pcrClock/1_000_000)) - r.lead)
+	return target.Sub(now)
+}
+
+func readPCR(pkt []byte) (uint64, bool) {
+	afc := (pkt[3] >> 4) & 0x3
+	if afc&0x2 == 0 || pkt[4] < 7 || pkt[5]&0x10 == 0 {
+		return 0, false
+	}
+	base := uint64(pkt[6])<<25 | uint64(pkt[7])<<17 | uint64(pkt[8])<<9 |
+		uint64(pkt[9])<<1 | uint64(pkt[10])>>7
+	ext := uint64(pkt[10]&0x1)<<8 | uint64(pkt[11])
+	return base*300 + ext, true
+}
+
+func (r *pacedReader) Close() error {
+	r.once.Do(func() { close(r.done) })
+	return r.rc.Close()
+}
@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// pcrPacket builds a TS packet on pid whose adaptation field carries pcr.
+func pcrPacket(pid int, pcr uint64) []byte {
+	pkt := make([]byte, tsPacket)
+	pkt[0] = 0x47
+	pkt[1] = byte(pid>>8) & 0x1F
+	pkt[2] = byte(pid)
+	pkt[3] = 0x30 // adaptation field + payload
+	pkt[4] = 7
+	pkt[5] = 0x10 // PCR flag
+
+	base, ext := pcr/300, pcr%300
+	pkt[6] = byte(base >> 25)
+	pkt[7] = byte(base >> 17)
+	pkt[8] = byte(base >> 9)
+	pkt[9] = byte(base >> 1)
+	pkt[10] = byte(base<<7) | 0x7E | byte(ext>>8)
+	pkt[11] = byte(ext)
+	return pkt
+}
+
+// payloadPacket builds a TS packet without an adaptation field.
+func payloadPacket(pid int) []byte {
+	pkt := bytes.Repeat([]byte{0xFF}, tsPacket)
+	pkt[0] = 0x47
+	pkt[1] = byte(pid>>8) & 0x1F
+	pkt[2] = byte(pid)
+	pkt[3] = 0x10
+	return pkt
+}
+
+func TestReadPCR(t *testing.T) {
+	noFlag := pcrPacket(0x100, 1)
+	noFlag[5] = 0
+	short := pcrPacket(0x100, 1)
+	short[4] = 1
+
+	cases := []struct {
+		name string
+		pkt  []byte
+		want uint64
+		ok   bool
+	}{
+		{"zero", pcrPacket(0x100, 0), 0, true},
+		{"one second", pcrPacket(0x100, pcrClock), pcrClock, true},
+		{"extension", pcrPacket(0x100, 12345*300+299), 12345*300 + 299, true},
+		{"last before wrap", pcrPacket(0x100, pcrWrap-1), pcrWrap - 1, true},
+		{"payload only", payloadPacket(0x100), 0, false},
+		{"no PCR flag", noFlag, 0, false},
+		{"short adaptation field", short, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := readPCR(tc.pkt)
+			if got != tc.want || ok != tc.ok {
+				t.Fatalf("got %d, %v; want %d, %v", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestPCROfLocksFirstPID(t *testing.T) {
+	r := &pacedReader{pid: -1}
+	if _, ok := r.pcrOf(pcrPacket(0x100, 1)); !ok {
+		t.Fatal("first PCR PID not accepted")
+	}
+	if _, ok := r.pcrOf(pcrPacket(0x200, 2)); ok {
+		t.Fatal("PCR on a second PID was used")
+	}
+}
+
+func TestPacingDue(t *testing.T) {
+	const lead = 500 * time.Millisecond
+	near := func(got, want time.Duration) bool {
+		d := got - want
+		return d > -100*time.Millisecond && d < 100*time.Millisecond
+	}
+
+	cases := []struct {
+		name   string
+		base   uint64 // first PCR, sets the clock
+		last   uint64 // previous PCR released
+		pcr    uint64
+		want   time.Duration
+		rebase bool
+	}{
+		{name: "on schedule", base: 0, last: pcrClock, pcr: 2 * pcrClock, want: 2*time.Second - lead},
+		{name: "inside lead", base: 0, last: 0, pcr: pcrClock / 10, want: 100*time.Millisecond - lead},
+		{name: "across wrap", base: pcrWrap - pcrClock, last: pcrWrap - 1, pcr: pcrClock, want: 2*time.Second - lead},
+		{name: "backstep", base: 5 * pcrClock, last: 6 * pcrClock, pcr: 5 * pcrClock, rebase: true},
+		{name: "forward jump", base: 0, last: pcrClock, pcr: pcrClock + pcrMaxJump + 1, rebase: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &pacedReader{lead: lead, have: true, base: tc.base, last: tc.last, start: time.Now()}
+			got := r.due(tc.pcr)
+			if tc.rebase {
+				if got != 0 || r.base != tc.pcr {
+					t.Fatalf("due %v base %d, want a rebase at %d", got, r.base, tc.pcr)
+				}
+				return
+			}
+			if !near(got, tc.want) || r.base != tc.base {
+				t.Fatalf("due %v base %d, want ~%v on base %d", got, r.base, tc.want, tc.base)
+			}
+		})
+	}
+
+	// the first PCR starts the clock and is due at once
+	r := &pacedReader{lead: lead}
+	if got := r.due(42); got != 0 || !r.have || r.base != 42 {
+		t.Fatalf("first PCR: due %v have %v base %d", got, r.have, r.base)
+	}
+}
+
+type bytesSource struct{ io.Reader }
+
+func (bytesSource) Close() error { return nil }
+
+// Paced output is the input byte for byte, including junk before the
+// first sync byte and a trailing partial packet.
+func TestPacedReaderPassesBytes(t *testing.T) {
+	var in []byte
+	in = append(in, 0x00, 0x01, 0x02) // lost alignment
+	in = append(in, pcrPacket(0x100, 0)...)
+	in = append(in, payloadPacket(0x101)...)
+	in = append(in, pcrPacket(0x100, pcrClock/100)...)
+	in = append(in, pcrPacket(0x200, 50*pcrClock)...) // other PID: not paced
+	in = append(in, payloadPacket(0x101)[:60]...)
+
+	r := &pacedReader{rc: bytesSource{bytes.NewReader(in)}, lead: 0, pid: -1, done: make(chan struct{})}
+	start := time.Now()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, in) {
+		t.Fatalf("got %d bytes, want %d unchanged", len(out), len(in))
+	}
+	if took := time.Since(start); took < 10*time.Millisecond || took > time.Second {
+		t.Fatalf("took %v, want ~10ms of pacing", took)
+	}
+}
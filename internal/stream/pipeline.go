@@ -0,0 +1,163 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"renderctl/logger"
+)
+
+// stage is one process of a pipeline, named for log output.
+type stage struct {
+	name string
+	cmd  *exec.Cmd
+}
+
+// stderrTail logs a stage's stderr (verbose) and keeps the last lines
+// so failures can be reported to the user.
+type stderrTail struct {
+	name string
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	lines []string
+}
+
+const stderrKeep = 5
+
+func (s *stderrTail) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Write(p)
+	for {
+		line, err := s.buf.ReadString('\n')
+		if err != nil {
+			// partial line, keep for next write
+			s.buf.Reset()
+			s.buf.WriteString(line)
+			break
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		logger.Info("[%s] %s", s.name, line)
+		s.lines = append(s.lines, line)
+		if len(s.lines) > stderrKeep {
+			s.lines = s.lines[1:]
+		}
+	}
+	return len(p), nil
+}
+
+func (s *stderrTail) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.lines, " | ")
+}
+
+// pipelineReadCloser is the stdout of the last stage.
+// Close kills every stage.
+type pipelineReadCloser struct {
+	io.ReadCloser
+	stages []stage
+	once   sync.Once
+
+	done chan struct{} // closed when the last stage exits
+}
+
+// exited reports whether the final stage has terminated.
+func (p *pipelineReadCloser) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *pipelineReadCloser) Close() error {
+	p.once.Do(func() {
+		for _, s := range p.stages {
+			if s.cmd.Process != nil {
+				_ = s.cmd.Process.Kill()
+			}
+		}
+	})
+	return p.ReadCloser.Close()
+}
+
+// startPipeline connects stages stdout→stdin (no shell involved)
+// and returns the final stdout.
+func startPipeline(stages ...stage) (*pipelineReadCloser, error) {
+	var closeAfterStart []*os.File
+
+	for i := 0; i < len(stages)-1; i++ {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		stages[i].cmd.Stdout = pw
+		stages[i+1].cmd.Stdin = pr
+		closeAfterStart = append(closeAfterStart, pr, pw)
+	}
+
+	// own pipe (not StdoutPipe) so Wait never races our reads
+	stdout, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stages[len(stages)-1].cmd.Stdout = pw
+	closeAfterStart = append(closeAfterStart, pw)
+
+	tails := make([]*stderrTail, len(stages))
+	for i, s := range stages {
+		tails[i] = &stderrTail{name: s.name}
+		s.cmd.Stderr = tails[i]
+	}
+
+	for i, s := range stages {
+		if err := s.cmd.Start(); err != nil {
+			for _, started := range stages[:i] {
+				_ = started.cmd.Process.Kill()
+			}
+			for _, f := range closeAfterStart {
+				_ = f.Close()
+			}
+			_ = stdout.Close()
+			return nil, err
+		}
+	}
+
+	// children hold their own copies now
+	for _, f := range closeAfterStart {
+		_ = f.Close()
+	}
+
+	done := make(chan struct{})
+	for i, s := range stages {
+		go func(s stage, tail *stderrTail, last bool) {
+			if last {
+				defer close(done)
+			}
+			if err := s.cmd.Wait(); err != nil {
+				if msg := tail.String(); msg != "" {
+					logger.Notify("%s exited: %v (%s)", s.name, err, msg)
+				} else {
+					logger.Notify("%s exited: %v", s.name, err)
+				}
+			}
+		}(s, tails[i], i == len(stages)-1)
+	}
+
+	return &pipelineReadCloser{
+		ReadCloser: stdout,
+		stages:     stages,
+		done:       done,
+	}, nil
+}
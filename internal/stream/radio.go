@@ -0,0 +1,309 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---- Internet radio (Icecast / Shoutcast) ----
+
+// station is one radio stream, from a URL or a .pls/.m3u station file.
+type station struct {
+	urls        []string // mirrors, tried in order
+	name        string   // icy-name or station file title
+	contentType string   // upstream audio mime
+	title       string   // first StreamTitle seen while probing
+}
+
+// containerKey maps the upstream mime to an audio container.
+func (s *station) containerKey() string {
+	ct := strings.ToLower(s.contentType)
+	switch {
+	case strings.Contains(ct, "aac"):
+		return "aac"
+	case strings.Contains(ct, "ogg"):
+		return "ogg"
+	case strings.Contains(ct, "flac"):
+		return "flac"
+	}
+	return "mp3"
+}
+
+// icyClient speaks HTTP to servers that answer "ICY 200 OK" (Shoutcast v1).
+var icyClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			c, err := (&net.Dialer{Timeout: 10 * time.Second}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &icyConn{Conn: c, r: bufio.NewReader(c)}, nil
+		},
+		ResponseHeaderTimeout: 15 * time.Second,
+	},
+}
+
+// icyConn rewrites a leading "ICY" status line to "HTTP/1.0".
+// TLS traffic never starts with "ICY " and passes through untouched.
+type icyConn struct {
+	net.Conn
+	r       *bufio.Reader
+	checked bool
+	prefix  []byte
+}
+
+func (c *icyConn) Read(p []byte) (int, error) {
+	if !c.checked {
+		c.checked = true
+		if head, err := c.r.Peek(4); err == nil && string(head) == "ICY " {
+			_, _ = c.r.Discard(3)
+			c.prefix = []byte("HTTP/1.0")
+		}
+	}
+	if len(c.prefix) > 0 {
+		n := copy(p, c.prefix)
+		c.prefix = c.prefix[n:]
+		return n, nil
+	}
+	return c.r.Read(p)
+}
+
+// icyGet opens url asking for in-band metadata.
+func icyGet(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+	req.Header.Set("User-Agent", "renderctl/1.0")
+
+	resp, err := icyClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New("radio: upstream returned " + resp.Status)
+	}
+	return resp, nil
+}
+
+func icyMetaint(resp *http.Response) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(resp.Header.Get("icy-metaint")))
+	return n
+}
+
+// isRadioResponse: ICY headers, or endless audio without Content-Length.
+func isRadioResponse(resp *http.Response) bool {
+	for k := range resp.Header {
+		if strings.HasPrefix(strings.ToLower(k), "icy-") {
+			return true
+		}
+	}
+	ct := strings.ToLower(resp.Header.Get("Content-Type"))
+	live := resp.ContentLength < 0
+	return live && (strings.HasPrefix(ct, "audio/") || ct == "application/ogg")
+}
+
+// parseStreamTitle extracts StreamTitle='...' from an ICY metadata block.
+func parseStreamTitle(meta string) (string, bool) {
+	const key = "StreamTitle='"
+	i := strings.Index(meta, key)
+	if i < 0 {
+		return "", false
+	}
+	rest := meta[i+len(key):]
+	j := strings.Index(rest, "';")
+	if j < 0 {
+		j = strings.LastIndex(rest, "'")
+	}
+	if j < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(rest[:j]), true
+}
+
+// readMetaBlock reads one length-prefixed ICY metadata block.
+func readMetaBlock(r io.Reader) (string, error) {
+	var l [1]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return "", err
+	}
+	if l[0] == 0 {
+		return "", nil
+	}
+	buf := make([]byte, int(l[0])*16)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buf), "\x00"), nil
+}
+
+// ---- Station detection ----
+
+var (
+	probeMu    sync.Mutex
+	probeCache = map[string]*station{}
+)
+
+// isStationFile reports a .pls / .m3u playlist (not HLS .m3u8).
+func isStationFile(lf string) bool {
+	p := lf
+	if i := strings.IndexAny(p, "?#"); i >= 0 && strings.Contains(lf, "://") {
+		p = p[:i]
+	}
+	switch strings.ToLower(path.Ext(p)) {
+	case ".pls", ".m3u":
+		return true
+	}
+	return false
+}
+
+// probeRadio checks (once per input) whether lf is an internet radio
+// stream and collects its name, mime and current title.
+func probeRadio(lf string) (*station, bool) {
+	probeMu.Lock()
+	defer probeMu.Unlock()
+
+	if s, ok := probeCache[lf]; ok {
+		return s, s != nil
+	}
+
+	s := &station{urls: []string{lf}}
+	if isStationFile(lf) {
+		var err error
+		if s, err = loadStationFile(lf); err != nil {
+			probeCache[lf] = nil
+			return nil, false
+		}
+	}
+
+	for i, u := range s.urls {
+		resp, err := icyGet(u)
+		if err != nil {
+			continue
+		}
+		ok := isRadioResponse(resp)
+		if ok {
+			// the mirror that answered goes first
+			s.urls[0], s.urls[i] = s.urls[i], s.urls[0]
+			s.contentType = resp.Header.Get("Content-Type")
+			if n := resp.Header.Get("icy-name"); n != "" && s.name == "" {
+				s.name = n
+			}
+			s.title = firstTitle(resp)
+		}
+		resp.Body.Close()
+
+		if ok {
+			probeCache[lf] = s
+			return s, true
+		}
+		break
+	}
+
+	probeCache[lf] = nil
+	return nil, false
+}
+
+// firstTitle reads past the first audio interval to the first metadata block.
+func firstTitle(resp *http.Response) string {
+	metaint := icyMetaint(resp)
+	if metaint <= 0 || metaint > 1<<20 {
+		return ""
+	}
+	// a stalled upstream must not hang stream setup
+	timer := time.AfterFunc(10*time.Second, func() { resp.Body.Close() })
+	defer timer.Stop()
+
+	if _, err := io.CopyN(io.Discard, resp.Body, int64(metaint)); err != nil {
+		return ""
+	}
+	meta, err := readMetaBlock(resp.Body)
+	if err != nil {
+		return ""
+	}
+	t, _ := parseStreamTitle(meta)
+	return t
+}
+
+// ---- Station files (.pls / .m3u) ----
+
+func loadStationFile(lf string) (*station, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if strings.HasPrefix(lf, "http://") || strings.HasPrefix(lf, "https://") {
+		resp, e := http.Get(lf)
+		if e != nil {
+			return nil, e
+		}
+		defer resp.Body.Close()
+		data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	} else {
+		data, err = os.ReadFile(lf)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := parseStationFile(string(data))
+	if len(s.urls) == 0 {
+		return nil, errors.New("radio: no stream URLs in " + lf)
+	}
+	return s, nil
+}
+
+func parseStationFile(body string) *station {
+	s := &station{}
+	isPLS := strings.Contains(strings.ToLower(body), "[playlist]")
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if isPLS {
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			k = strings.ToLower(k)
+			switch {
+			case strings.HasPrefix(k, "file"):
+				s.urls = append(s.urls, strings.TrimSpace(v))
+			case strings.HasPrefix(k, "title") && s.name == "":
+				s.name = strings.TrimSpace(v)
+			}
+			continue
+		}
+
+		// M3U / extended M3U
+		if strings.HasPrefix(line, "#EXTINF:") {
+			if _, t, ok := strings.Cut(line, ","); ok && s.name == "" {
+				s.name = strings.TrimSpace(t)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			s.urls = append(s.urls, line)
+		}
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestParseStreamTitle(t *testing.T) {
+	cases := []struct {
+		meta string
+		want string
+		ok   bool
+	}{
+		{"StreamTitle='Daft Punk - Around the World';StreamUrl='';", "Daft Punk - Around the World", true},
+		{"StreamTitle='It's Alright';", "It's Alright", true},
+		{"StreamTitle=' padded ';", "padded", true},
+		{"StreamTitle='no terminator'", "no terminator", true},
+		{"StreamTitle='';", "", true},
+		{"StreamUrl='http://example.com';", "", false},
+		{"", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.meta, func(t *testing.T) {
+			got, ok := parseStreamTitle(tc.meta)
+			if got != tc.want || ok != tc.ok {
+				t.Fatalf("got %q, %v; want %q, %v", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+// icyBody interleaves audio with a metadata block after every metaint
+// bytes, the way Icecast sends it. An empty meta is a zero-length block.
+func icyBody(audio []byte, metaint int, metas ...string) []byte {
+	var out bytes.Buffer
+	for i := 0; len(audio) > 0; i++ {
+		n := min(metaint, len(audio))
+		out.Write(audio[:n])
+		audio = audio[n:]
+		if n < metaint {
+			break
+		}
+
+		var meta string
+		if i < len(metas) {
+			meta = metas[i]
+		}
+		blocks := (len(meta) + 15) / 16
+		out.WriteByte(byte(blocks))
+		out.WriteString(meta)
+		out.Write(make([]byte, blocks*16-len(meta))) // NUL padding
+	}
+	return out.Bytes()
+}
+
+func TestRadioStripsMetadata(t *testing.T) {
+	const metaint = 16
+	audio := make([]byte, 5*metaint+7)
+	for i := range audio {
+		audio[i] = byte(i)
+	}
+	body := icyBody(audio, metaint,
+		"StreamTitle='First Song';",
+		"", // no change
+		"StreamTitle='Second Song';StreamUrl='';",
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Icy-MetaData") != "1" {
+			t.Error("metadata not requested")
+		}
+		w.Header().Set("icy-metaint", strconv.Itoa(metaint))
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	src := newRadioSource(&station{urls: []string{srv.URL}})
+	rc, err := src.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	// reads larger than metaint must still stop at each block
+	got := make([]byte, len(audio))
+	if _, err := io.ReadFull(rc, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, audio) {
+		t.Fatalf("audio mismatch\ngot  % x\nwant % x", got, audio)
+	}
+	if title := src.Title(); title != "Second Song" {
+		t.Fatalf("title %q, want %q", title, "Second Song")
+	}
+}
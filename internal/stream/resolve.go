@@ -2,9 +2,13 @@ package stream
 
 import (
 	"errors"
+	"os/exec"
 	"renderctl/internal/avtransport"
+	"renderctl/internal/cache"
+	"renderctl/internal/mediainfo"
 	"renderctl/internal/models"
 	"renderctl/internal/servers"
+	"renderctl/logger"
 	"strings"
 )
 
@@ -13,6 +17,10 @@ type StreamPlan struct {
 	Mime       string
 	Container  servers.StreamContainer
 	Source     servers.StreamSource
+
+	TV      string              // renderer cache key, for learned behaviour
+	Learned cache.Behavior      // what earlier sessions recorded
+	Hints   servers.ClientHints // derived from Learned
 }
 
 func ResolveStreamPlan(cfg *models.Config) (*StreamPlan, error) {
@@ -20,8 +28,33 @@ func ResolveStreamPlan(cfg *models.Config) (*StreamPlan, error) {
 	kind := ResolveStreamKind(cfg)
 
 	containerKey := "ts"
-	if kind == StreamExternal {
+	var mi *mediainfo.Info
+	switch kind {
+	case StreamExternal:
 		containerKey = "passthrough"
+	case StreamRadio:
+		st, ok := probeRadio(cfg.LFile)
+		if !ok {
+			return nil, errors.New("no reachable radio stream in " + cfg.LFile)
+		}
+		containerKey = st.containerKey()
+		logger.Info("Radio: %q (%s)", st.name, st.contentType)
+	case StreamFile:
+		// decide from the actual file headers, not the extension
+		if info, err := mediainfo.ParseFile(cfg.LFile); err == nil {
+			mi = info
+			containerKey = containerForFile(mi.Container)
+			logger.Info("Detected container: %s (%v)", mi.Container, mi.Duration)
+		}
+	}
+
+	// --container: required knowledge for pipes, an override elsewhere
+	if cfg.Container != "" {
+		key, err := containerFromFlag(cfg.Container)
+		if err != nil {
+			return nil, err
+		}
+		containerKey = key
 	}
 
 	container, err := GetContainer(containerKey)
@@ -55,13 +88,56 @@ resolved:
 		media = map[string][]string{}
 	}
 
+	// Transcode when the renderer cannot play the input as-is
+	if kind == StreamFile || kind == StreamExternal {
+		if tc, ts, ok := planTranscode(cfg, media); ok {
+			container = tc
+			src = ts
+		}
+	}
+
+	// HLS output when the renderer prefers playlists
+	if useHLSOutput(cfg, media) {
+		hls := hlsContainer{audio: hlsAudio(container, mi)}
+		container = hls
+		logger.Notify("Renderer accepts HLS — serving playlist output")
+		if hls.audio != "" {
+			logger.Info("HLS output: audio re-encoded to %s (not MPEG-TS compatible)", hls.audio)
+		}
+	}
+
+	// Real-time pacing for TS (replay a recording as a live broadcast)
+	if cfg.Pace {
+		if container.Key() == "ts" {
+			src = newPacedSource(src, cfg.PaceLead)
+			logger.Notify("PCR pacing enabled (lead %v)", cfg.PaceLead)
+		} else {
+			logger.Notify("PCR pacing applies to MPEG-TS only (container: %s)", container.Key())
+		}
+	}
+
 	mime := selectMime(container, media)
 
+	// What earlier sessions learned about this TV's HTTP client
+	tv := tvHost(cfg)
+	learned, ok := loadLearned(cfg, tv)
+	if ok {
+		logger.Info("Known client %s: range=%v length=%v pre-open=%v",
+			tv, learned.SendsRange, learned.NeedsLength, learned.SlowStart)
+	}
+	if m := learned.Mimes[container.Key()]; m != "" && m != mime {
+		logger.Notify("Using mime %s (played before on %s)", m, tv)
+		mime = m
+	}
+
 	return &StreamPlan{
 		StreamPath: "/stream",
 		Mime:       mime,
 		Container:  container,
 		Source:     src,
+		TV:         tv,
+		Learned:    learned,
+		Hints:      hintsFrom(learned),
 	}, nil
 }
 
@@ -70,34 +146,70 @@ type StreamKind int
 const (
 	StreamFile StreamKind = iota
 	StreamExternal
-	StreamResolved // needs yt-dlp (youtube/vimeo/etc)
+	StreamResolved // needs a Resolver (yt-dlp, streamlink, custom)
+	StreamHLS      // external .m3u8, ingested and re-emitted as TS
+	StreamCapture  // capture:<format>:<input>, encoded live by ffmpeg
+	StreamRadio    // Icecast/Shoutcast URL or .pls/.m3u station file
+	StreamPipe     // "-" (stdin) or fifo:/path, container from --container
 )
 
 func ResolveStreamKind(cfg *models.Config) StreamKind {
 	lf := strings.TrimSpace(cfg.LFile)
 
-	if looksLikeResolvableURL(lf) {
+	if isPipeSpec(lf) {
+		return StreamPipe
+	}
+	if isCaptureSpec(lf) {
+		return StreamCapture
+	}
+	if matchResolver(lf) != nil {
 		return StreamResolved
 	}
+	if cfg.Resolver != "" && (strings.HasPrefix(lf, "http://") || strings.HasPrefix(lf, "https://")) {
+		return StreamResolved
+	}
+
+	if isStationFile(lf) {
+		return StreamRadio
+	}
 
 	if strings.HasPrefix(lf, "http://") || strings.HasPrefix(lf, "https://") {
+		if isHLSURL(lf) {
+			return StreamHLS
+		}
+		if _, ok := probeRadio(lf); ok {
+			return StreamRadio
+		}
 		return StreamExternal
 	}
 
 	return StreamFile
 }
 
-// minimal resolver detection (expand later)
-func looksLikeResolvableURL(u string) bool {
-	u = strings.ToLower(strings.TrimSpace(u))
-	if !strings.HasPrefix(u, "http") {
+func useHLSOutput(cfg *models.Config, media map[string][]string) bool {
+	switch strings.ToLower(strings.TrimSpace(cfg.HLSOutput)) {
+	case "off":
+		return false
+	case "on":
+	default:
+		if !sinkWantsHLS(media) {
+			return false
+		}
+	}
+
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		logger.Notify("HLS output unavailable: ffmpeg not found")
 		return false
 	}
+	return true
+}
 
-	return strings.Contains(u, "youtube.com") ||
-		strings.Contains(u, "youtu.be") ||
-		strings.Contains(u, "vimeo.com") ||
-		strings.Contains(u, "twitch.tv")
+// MediaPath is the path the TV is told to play.
+func (p *StreamPlan) MediaPath() string {
+	if p.Container.Key() == "hls" {
+		return servers.HLSPlaylistPath(p.StreamPath)
+	}
+	return p.StreamPath
 }
 
 func BuildStreamURL(cfg *models.Config, streamPath string) string {
@@ -0,0 +1,133 @@
+package stream
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Resolver turns a platform URL (YouTube, Twitch, ...) into media bytes.
+// Commands returns the pipeline stages; the last stage writes MPEG-TS to stdout.
+type Resolver interface {
+	Name() string
+	Binaries() []string
+	Commands(url, format string) []stage
+}
+
+// remuxStage normalizes whatever the resolver emits into MPEG-TS.
+func remuxStage() stage {
+	return stage{
+		name: "ffmpeg",
+		cmd: exec.Command(
+			"ffmpeg",
+			"-hide_banner",
+			"-loglevel", "error",
+			"-i", "pipe:0",
+			"-f", "mpegts",
+			"-codec", "copy",
+			"pipe:1",
+		),
+	}
+}
+
+// ---- yt-dlp ----
+
+type ytdlpResolver struct{}
+
+func (ytdlpResolver) Name() string { return "yt-dlp" }
+
+func (ytdlpResolver) Binaries() []string { return []string{"yt-dlp", "ffmpeg"} }
+
+func (ytdlpResolver) Commands(url, format string) []stage {
+	if format == "" {
+		format = "bv*[vcodec^=avc1]+ba/best"
+	}
+	return []stage{
+		{
+			name: "yt-dlp",
+			cmd: exec.Command(
+				"yt-dlp",
+				"--no-progress",
+				"-f", format,
+				"-o", "-",
+				"--", url,
+			),
+		},
+		remuxStage(),
+	}
+}
+
+// ---- streamlink ----
+
+type streamlinkResolver struct{}
+
+func (streamlinkResolver) Name() string { return "streamlink" }
+
+func (streamlinkResolver) Binaries() []string { return []string{"streamlink", "ffmpeg"} }
+
+func (streamlinkResolver) Commands(url, format string) []stage {
+	if format == "" {
+		format = "best"
+	}
+	return []stage{
+		{
+			name: "streamlink",
+			cmd: exec.Command(
+				"streamlink",
+				"--stdout",
+				"--loglevel", "warning",
+				"--", url, format,
+			),
+		},
+		remuxStage(),
+	}
+}
+
+// ---- custom (from resolvers.json) ----
+
+// commandResolver runs a user-configured argv.
+// "{url}" and "{format}" placeholders are substituted per argument.
+type commandResolver struct {
+	name    string
+	command []string
+	remux   bool
+}
+
+func (c commandResolver) Name() string { return c.name }
+
+func (c commandResolver) Binaries() []string {
+	bins := []string{c.command[0]}
+	if c.remux {
+		bins = append(bins, "ffmpeg")
+	}
+	return bins
+}
+
+func (c commandResolver) Commands(url, format string) []stage {
+	args := make([]string, len(c.command)-1)
+	for i, a := range c.command[1:] {
+		a = strings.ReplaceAll(a, "{url}", url)
+		a = strings.ReplaceAll(a, "{format}", format)
+		args[i] = a
+	}
+
+	stages := []stage{{name: c.name, cmd: exec.Command(c.command[0], args...)}}
+	if c.remux {
+		stages = append(stages, remuxStage())
+	}
+	return stages
+}
+
+var builtinResolvers = map[string]Resolver{
+	"yt-dlp":     ytdlpResolver{},
+	"streamlink": streamlinkResolver{},
+}
+
+func checkBinaries(r Resolver) error {
+	for _, b := range r.Binaries() {
+		if _, err := exec.LookPath(b); err != nil {
+			return errors.New(r.Name() + " requires " + b + " (run --install)")
+		}
+	}
+	return nil
+}
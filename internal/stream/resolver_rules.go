@@ -0,0 +1,148 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"renderctl/internal/models"
+	"renderctl/logger"
+)
+
+// ResolverRule routes URLs whose host matches to a resolver.
+// Match is a domain ("youtube.com" also matches "www.youtube.com") or "*".
+type ResolverRule struct {
+	Match    string `json:"match"`
+	Resolver string `json:"resolver"`
+	Format   string `json:"format,omitempty"`
+}
+
+type customResolver struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	Remux   bool     `json:"remux,omitempty"`
+}
+
+// ~/.renderctl/resolvers.json
+type resolverConfig struct {
+	Resolvers []customResolver `json:"resolvers,omitempty"`
+	Rules     []ResolverRule   `json:"rules"`
+}
+
+var defaultRules = []ResolverRule{
+	{Match: "youtube.com", Resolver: "yt-dlp"},
+	{Match: "youtu.be", Resolver: "yt-dlp"},
+	{Match: "vimeo.com", Resolver: "yt-dlp"},
+	{Match: "twitch.tv", Resolver: "yt-dlp"},
+}
+
+var (
+	rulesOnce sync.Once
+	rules     []ResolverRule
+	resolvers map[string]Resolver
+)
+
+func resolverConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".renderctl", "resolvers.json"), nil
+}
+
+// loadResolverConfig merges user rules (first, they win) with the defaults.
+func loadResolverConfig() {
+	resolvers = map[string]Resolver{}
+	for n, r := range builtinResolvers {
+		resolvers[n] = r
+	}
+	rules = defaultRules
+
+	path, err := resolverConfigPath()
+	if err != nil {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Notify("Resolver config unreadable: %v", err)
+		}
+		return
+	}
+
+	var rc resolverConfig
+	if err := json.Unmarshal(b, &rc); err != nil {
+		logger.Notify("Resolver config invalid (%s): %v", path, err)
+		return
+	}
+
+	for _, c := range rc.Resolvers {
+		if c.Name == "" || len(c.Command) == 0 {
+			logger.Notify("Resolver config: skipping custom resolver without name/command")
+			continue
+		}
+		resolvers[c.Name] = commandResolver{name: c.Name, command: c.Command, remux: c.Remux}
+	}
+
+	rules = append(append([]ResolverRule{}, rc.Rules...), defaultRules...)
+	logger.Info("Loaded %d resolver rule(s) from %s", len(rc.Rules), path)
+}
+
+func hostMatches(host, match string) bool {
+	host = strings.ToLower(host)
+	match = strings.ToLower(strings.TrimSpace(match))
+
+	if match == "*" {
+		return true
+	}
+	return host == match || strings.HasSuffix(host, "."+match)
+}
+
+// matchResolver returns the rule for a URL, or nil when it is plain media.
+func matchResolver(raw string) *ResolverRule {
+	rulesOnce.Do(loadResolverConfig)
+
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+
+	for i := range rules {
+		if hostMatches(u.Hostname(), rules[i].Match) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// pickResolver resolves the rule for cfg.LFile, honoring --resolver / --resolver-format.
+func pickResolver(cfg *models.Config) (Resolver, string, error) {
+	rulesOnce.Do(loadResolverConfig)
+
+	name, format := "", ""
+	if rule := matchResolver(cfg.LFile); rule != nil {
+		name, format = rule.Resolver, rule.Format
+	}
+	if cfg.Resolver != "" {
+		name = cfg.Resolver
+	}
+	if cfg.ResolverFormat != "" {
+		format = cfg.ResolverFormat
+	}
+	if name == "" {
+		return nil, "", errors.New("no resolver rule matches " + cfg.LFile)
+	}
+
+	r, ok := resolvers[name]
+	if !ok {
+		return nil, "", errors.New("unknown resolver: " + name)
+	}
+	if err := checkBinaries(r); err != nil {
+		return nil, "", err
+	}
+	return r, format, nil
+}
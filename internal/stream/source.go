@@ -4,16 +4,38 @@ import (
 	"errors"
 	"renderctl/internal/models"
 	"renderctl/internal/servers"
+	"renderctl/logger"
 )
 
-// This is generic in structure, but currently only applies to URLs that need
-// resolution via yt-dlp (YouTube, Vimeo, etc).
+// This is generic in structure; resolved URLs are routed to a Resolver
+// by the rules in ~/.renderctl/resolvers.json (see resolver_rules.go).
 func BuildStreamSource(cfg *models.Config) (servers.StreamSource, error) {
 	kind := ResolveStreamKind(cfg)
 
 	switch kind {
 	case StreamResolved:
-		return newResolverSource(cfg.LFile), nil
+		r, format, err := pickResolver(cfg)
+		if err != nil {
+			return nil, err
+		}
+		logger.Notify("Resolver: %s", r.Name())
+		return newResolverSource(cfg.LFile, r, format), nil
+
+	case StreamPipe:
+		return newPipeSource(cfg.LFile), nil
+
+	case StreamCapture:
+		return newCaptureSource(cfg.LFile)
+
+	case StreamRadio:
+		st, ok := probeRadio(cfg.LFile)
+		if !ok {
+			return nil, errors.New("no reachable radio stream in " + cfg.LFile)
+		}
+		return newRadioSource(st), nil
+
+	case StreamHLS:
+		return newHLSSource(cfg.LFile, cfg.Quality), nil
 
 	case StreamExternal:
 		return urlSource{url: cfg.LFile}, nil
@@ -0,0 +1,187 @@
+package stream
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"renderctl/internal/servers"
+	"renderctl/logger"
+)
+
+const capturePrefix = "capture:"
+
+// captureInput is one ffmpeg input: -f <format> [opts] -i <input>
+type captureInput struct {
+	format string
+	input  string
+}
+
+// formats that only produce audio / only produce video
+var (
+	audioCaptureFormats = map[string]bool{"pulse": true, "alsa": true, "jack": true}
+	videoCaptureFormats = map[string]bool{"x11grab": true, "kmsgrab": true, "v4l2": true, "gdigrab": true}
+)
+
+func isCaptureSpec(lf string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(lf)), capturePrefix)
+}
+
+// parseCaptureSpec parses "capture:<format>:<input>[+<format>:<input>...]".
+//
+//	capture:x11grab::0            desktop :0
+//	capture:lavfi:testsrc         generated test card
+//	capture:pulse:default         default PulseAudio source
+//	capture:x11grab::0+pulse:default
+func parseCaptureSpec(spec string) ([]captureInput, error) {
+	body := strings.TrimSpace(spec)[len(capturePrefix):]
+	if body == "" {
+		return nil, errors.New("empty capture spec (expected capture:<format>:<input>)")
+	}
+
+	var inputs []captureInput
+	for _, part := range strings.Split(body, "+") {
+		format, input, ok := strings.Cut(part, ":")
+		if !ok || format == "" || input == "" {
+			return nil, errors.New("invalid capture input: " + part + " (expected <format>:<input>)")
+		}
+		inputs = append(inputs, captureInput{format: strings.ToLower(format), input: input})
+	}
+	return inputs, nil
+}
+
+// ffmpegCaptureArgs builds a low-latency H.264/AAC MPEG-TS encode.
+// Missing audio/video is filled with silence/black so TVs always get both.
+func ffmpegCaptureArgs(inputs []captureInput) []string {
+	args := []string{"-hide_banner", "-loglevel", "error"}
+
+	hasVideo, hasAudio := false, false
+	for _, in := range inputs {
+		switch {
+		case audioCaptureFormats[in.format]:
+			hasAudio = true
+		case videoCaptureFormats[in.format]:
+			hasVideo = true
+			args = append(args, "-framerate", "25")
+		case in.format == "lavfi":
+			hasVideo = true
+			args = append(args, "-re")
+		default:
+			hasVideo = true
+		}
+		args = append(args, "-f", in.format, "-i", in.input)
+	}
+
+	count := len(inputs)
+	if !hasVideo {
+		args = append(args, "-f", "lavfi", "-i", "color=c=black:s=1280x720:r=25")
+		count++
+	}
+	if !hasAudio {
+		args = append(args, "-f", "lavfi", "-i", "anullsrc=r=48000:cl=stereo")
+		count++
+	}
+
+	for i := 0; i < count; i++ {
+		args = append(args, "-map", strconv.Itoa(i)+":v?", "-map", strconv.Itoa(i)+":a?")
+	}
+
+	return append(args,
+		"-c:v", "libx264",
+		"-preset", "ultrafast",
+		"-tune", "zerolatency",
+		"-pix_fmt", "yuv420p",
+		"-g", "50",
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-ac", "2",
+		"-f", "mpegts",
+		"pipe:1",
+	)
+}
+
+// captureSource runs ffmpeg against a capture device.
+// Only one reader at a time; ffmpeg is (re)started when not running.
+type captureSource struct {
+	inputs []captureInput
+
+	mu       sync.Mutex
+	active   *pipelineReadCloser
+	restarts int
+}
+
+func newCaptureSource(spec string) (*captureSource, error) {
+	inputs, err := parseCaptureSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, errors.New("capture requires ffmpeg (run --install)")
+	}
+	return &captureSource{inputs: inputs}, nil
+}
+
+// Live: the stream server shares one capture between clients.
+func (c *captureSource) Live() bool { return true }
+
+func (c *captureSource) Open() (servers.StreamReadCloser, error) {
+	logger.Status("Opening capture source")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active != nil && !c.active.exited() {
+		return nil, errors.New("capture stream already active")
+	}
+	if c.active != nil {
+		logger.Notify("ffmpeg exited, restarting")
+		c.restarts++
+	}
+
+	rc, err := startPipeline(stage{
+		name: "ffmpeg",
+		cmd:  exec.Command("ffmpeg", ffmpegCaptureArgs(c.inputs)...),
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Done("ffmpeg started")
+
+	c.active = rc
+	return &captureReadCloser{pipelineReadCloser: rc, owner: c}, nil
+}
+
+type captureReadCloser struct {
+	*pipelineReadCloser
+	owner *captureSource
+}
+
+func (c *captureReadCloser) Close() error {
+	err := c.pipelineReadCloser.Close()
+
+	c.owner.mu.Lock()
+	if c.owner.active == c.pipelineReadCloser {
+		c.owner.active = nil
+	}
+	c.owner.mu.Unlock()
+
+	return err
+}
+
+func (c *captureSource) Details() map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var inputs []string
+	for _, in := range c.inputs {
+		inputs = append(inputs, in.format+":"+in.input)
+	}
+	return map[string]any{
+		"kind":            "capture",
+		"inputs":          inputs,
+		"ffmpeg_running":  c.active != nil && !c.active.exited(),
+		"ffmpeg_restarts": c.restarts,
+	}
+}
@@ -1,12 +1,10 @@
 package stream
 
 import (
-	"errors"
+	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"renderctl/internal/servers"
-	"renderctl/logger"
 )
 
 type fileSource struct{ path string }
@@ -27,49 +25,15 @@ func (u urlSource) Open() (servers.StreamReadCloser, error) {
 	return resp.Body, nil
 }
 
-type rollingFileSource struct {
-	path    string
-	cmd     *exec.Cmd
-	started bool
-	opened  bool
+func (f fileSource) Details() map[string]any {
+	return map[string]any{"kind": "file", "path": f.path}
 }
 
-func (r *rollingFileSource) Open() (servers.StreamReadCloser, error) {
-	logger.Status("Opening rolling file source")
-
-	if r.opened {
-		return nil, errors.New("screen stream already active")
-	}
-
-	if r.started && r.cmd.ProcessState != nil && r.cmd.ProcessState.Exited() {
-		logger.Notify("ffmpeg exited, restarting")
-		r.started = false
-	}
-
-	if !r.started {
-		if err := r.cmd.Start(); err != nil {
-			return nil, err
-		}
-		r.started = true
-		logger.Done("ffmpeg started")
-	}
-
-	rc, err := os.Open(r.path)
-	if err != nil {
-		return nil, err
-	}
-
-	r.opened = true
-	return rc, nil
+func (u urlSource) Details() map[string]any {
+	return map[string]any{"kind": "url", "url": u.url}
 }
 
-func (r *rollingFileSource) Close() error {
-	r.opened = false
-
-	if r.cmd != nil && r.cmd.Process != nil {
-		_ = r.cmd.Process.Kill()
-		_, _ = r.cmd.Process.Wait()
-	}
-
-	return nil
+// OpenSeekable lets the stream server answer Range requests for files.
+func (f fileSource) OpenSeekable() (io.ReadSeekCloser, error) {
+	return os.Open(f.path)
 }
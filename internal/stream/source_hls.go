@@ -0,0 +1,280 @@
+package stream
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"renderctl/internal/servers"
+	"renderctl/logger"
+)
+
+const hlsPrefetch = 3
+
+// hlsSource ingests an HLS playlist and emits one continuous MPEG-TS stream.
+type hlsSource struct {
+	url     string
+	quality string
+	client  *http.Client
+
+	keyMu sync.Mutex
+	keys  map[string][]byte // AES-128 keys by URI
+}
+
+func newHLSSource(u, quality string) *hlsSource {
+	return &hlsSource{
+		url:     u,
+		quality: quality,
+		client:  &http.Client{Timeout: 20 * time.Second},
+		keys:    map[string][]byte{},
+	}
+}
+
+// Live: one playlist follower is shared by all stream clients.
+func (h *hlsSource) Live() bool { return true }
+
+func (h *hlsSource) Open() (servers.StreamReadCloser, error) {
+	logger.Status("Opening HLS playlist: %s", h.url)
+
+	mediaURL, pl, err := h.resolveMedia(h.url)
+	if err != nil {
+		return nil, err
+	}
+	if pl.HasMap {
+		return nil, errors.New("hls: fMP4 segments are not supported (TS only)")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+
+	go h.follow(ctx, mediaURL, pl, pw)
+
+	logger.Done("HLS ingest started")
+	return &hlsReadCloser{PipeReader: pr, cancel: cancel}, nil
+}
+
+type hlsReadCloser struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (h *hlsReadCloser) Close() error {
+	h.cancel()
+	return h.PipeReader.Close()
+}
+
+func (h *hlsSource) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (h *hlsSource) fetchPlaylist(ctx context.Context, u string) (*hlsPlaylist, error) {
+	body, err := h.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	base, _ := url.Parse(u)
+	return parsePlaylist(string(body), base)
+}
+
+// resolveMedia follows a master playlist to the selected variant.
+func (h *hlsSource) resolveMedia(u string) (string, *hlsPlaylist, error) {
+	pl, err := h.fetchPlaylist(context.Background(), u)
+	if err != nil {
+		return "", nil, err
+	}
+	if !pl.Master {
+		return u, pl, nil
+	}
+	if len(pl.Variants) == 0 {
+		return "", nil, errors.New("hls: master playlist has no variants")
+	}
+
+	v := pickVariant(pl.Variants, h.quality)
+	logger.Notify("HLS variant: %dx%d @ %d bps", v.Width, v.Height, v.Bandwidth)
+
+	media, err := h.fetchPlaylist(context.Background(), v.URI)
+	if err != nil {
+		return "", nil, err
+	}
+	return v.URI, media, nil
+}
+
+type segmentResult struct {
+	data []byte
+	err  error
+}
+
+// follow fetches segments in order (with prefetch) and refreshes live playlists.
+func (h *hlsSource) follow(ctx context.Context, mediaURL string, pl *hlsPlaylist, w *io.PipeWriter) {
+	var (
+		next    int64 = -1 // next sequence number to emit
+		pending []chan segmentResult
+		queued  []hlsSegment
+	)
+
+	// live: start near the live edge (last 3 segments)
+	if !pl.EndList && len(pl.Segments) > 3 {
+		next = pl.Segments[len(pl.Segments)-3].Seq
+	}
+
+	for {
+		// ---- enqueue new segments ----
+		for _, seg := range pl.Segments {
+			if seg.Seq < next {
+				continue
+			}
+			queued = append(queued, seg)
+			next = seg.Seq + 1
+		}
+
+		// ---- fetch + emit in order, keeping hlsPrefetch in flight ----
+		for len(queued) > 0 || len(pending) > 0 {
+			for len(pending) < hlsPrefetch && len(queued) > 0 {
+				seg := queued[0]
+				queued = queued[1:]
+				ch := make(chan segmentResult, 1)
+				pending = append(pending, ch)
+				go func(seg hlsSegment) {
+					data, err := h.segment(ctx, seg)
+					ch <- segmentResult{data, err}
+				}(seg)
+			}
+
+			var res segmentResult
+			select {
+			case res = <-pending[0]:
+			case <-ctx.Done():
+				_ = w.CloseWithError(ctx.Err())
+				return
+			}
+			pending = pending[1:]
+
+			if res.err != nil {
+				logger.Notify("HLS segment skipped: %v", res.err)
+				continue
+			}
+			if _, err := w.Write(res.data); err != nil {
+				return // reader gone
+			}
+		}
+
+		if pl.EndList {
+			_ = w.Close()
+			return
+		}
+
+		// ---- live refresh ----
+		wait := pl.TargetDuration / 2
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			_ = w.CloseWithError(ctx.Err())
+			return
+		}
+
+		fresh, err := h.fetchPlaylist(ctx, mediaURL)
+		if err != nil {
+			logger.Notify("HLS playlist refresh failed: %v", err)
+			continue
+		}
+		pl = fresh
+	}
+}
+
+// segment downloads (and decrypts) one media segment.
+func (h *hlsSource) segment(ctx context.Context, seg hlsSegment) ([]byte, error) {
+	data, err := h.get(ctx, seg.URI)
+	if err != nil {
+		return nil, err
+	}
+	if seg.Key == nil {
+		return data, nil
+	}
+	if seg.Key.Method != "AES-128" {
+		return nil, errors.New("hls: unsupported encryption " + seg.Key.Method)
+	}
+
+	key, err := h.key(ctx, seg.Key.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := seg.Key.IV
+	if iv == nil {
+		iv = make([]byte, 16)
+		binary.BigEndian.PutUint64(iv[8:], uint64(seg.Seq))
+	}
+	return decryptAES128(data, key, iv)
+}
+
+func (h *hlsSource) key(ctx context.Context, u string) ([]byte, error) {
+	h.keyMu.Lock()
+	k, ok := h.keys[u]
+	h.keyMu.Unlock()
+	if ok {
+		return k, nil
+	}
+
+	k, err := h.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if len(k) != 16 {
+		return nil, errors.New("hls: invalid AES-128 key length")
+	}
+
+	h.keyMu.Lock()
+	h.keys[u] = k
+	h.keyMu.Unlock()
+	return k, nil
+}
+
+func decryptAES128(data, key, iv []byte) ([]byte, error) {
+	if len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("hls: encrypted segment not block aligned")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	// PKCS#7 padding
+	if n := len(out); n > 0 {
+		pad := int(out[n-1])
+		if pad > 0 && pad <= aes.BlockSize && pad <= n {
+			out = out[:n-pad]
+		}
+	}
+	return out, nil
+}
+
+func (h *hlsSource) Details() map[string]any {
+	return map[string]any{
+		"kind":    "hls",
+		"url":     h.url,
+		"quality": h.quality,
+	}
+}
@@ -0,0 +1,199 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"renderctl/internal/servers"
+	"renderctl/logger"
+)
+
+const fifoPrefix = "fifo:"
+
+func isPipeSpec(lf string) bool {
+	return lf == "-" || strings.HasPrefix(lf, fifoPrefix)
+}
+
+// pipeSource reads media from stdin or a named pipe. The pipe has ONE
+// reader: once a TV has attached, it is drained continuously (bytes are
+// dropped while no client is connected) so the producer keeps live timing,
+// and a reconnecting TV picks up at the current position.
+type pipeSource struct {
+	path string // "" = stdin
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	started bool
+	ended   error
+
+	// current consumer; nil while detached
+	sink *pipeReader
+}
+
+func newPipeSource(lf string) *pipeSource {
+	p := &pipeSource{}
+	if lf != "-" {
+		p.path = strings.TrimPrefix(lf, fifoPrefix)
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+func (p *pipeSource) Live() bool { return true }
+
+func (p *pipeSource) label() string {
+	if p.path == "" {
+		return "stdin"
+	}
+	return p.path
+}
+
+func (p *pipeSource) Open() (servers.StreamReadCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ended != nil {
+		return nil, p.ended
+	}
+	if !p.started {
+		p.started = true
+		go p.drain()
+		logger.Notify("Reading media from %s", p.label())
+	}
+
+	// a new consumer replaces a stale one
+	if p.sink != nil {
+		p.sink.detach()
+	}
+	r := &pipeReader{src: p}
+	p.sink = r
+	return r, nil
+}
+
+// open returns the pipe; a fifo is reopened when its writer goes away.
+func (p *pipeSource) open() (io.ReadCloser, error) {
+	if p.path == "" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(p.path) // blocks until a writer appears
+}
+
+func (p *pipeSource) drain() {
+	buf := make([]byte, 64<<10)
+
+	for {
+		in, err := p.open()
+		if err != nil {
+			p.finish(err)
+			return
+		}
+
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				p.deliver(buf[:n])
+			}
+			if err != nil {
+				break
+			}
+		}
+		in.Close()
+
+		if p.path == "" {
+			p.finish(io.EOF)
+			return
+		}
+		logger.Notify("FIFO writer closed — waiting for the next one")
+	}
+}
+
+// deliver hands a chunk to the attached consumer, waiting for it to be
+// taken; with no consumer the chunk is dropped.
+func (p *pipeSource) deliver(b []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.sink != nil && p.sink.pending != nil {
+		p.cond.Wait()
+	}
+	if p.sink == nil {
+		return
+	}
+	p.sink.pending = append([]byte(nil), b...)
+	p.cond.Broadcast()
+}
+
+func (p *pipeSource) finish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == io.EOF {
+		logger.Notify("%s ended", p.label())
+		err = errors.New(p.label() + " ended")
+	}
+	p.ended = err
+	p.cond.Broadcast()
+}
+
+type pipeReader struct {
+	src      *pipeSource
+	pending  []byte
+	detached bool
+}
+
+func (r *pipeReader) Read(b []byte) (int, error) {
+	p := r.src
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for r.pending == nil && !r.detached && p.ended == nil {
+		p.cond.Wait()
+	}
+	if r.pending == nil {
+		return 0, io.EOF
+	}
+
+	n := copy(b, r.pending)
+	r.pending = r.pending[n:]
+	if len(r.pending) == 0 {
+		r.pending = nil
+		p.cond.Broadcast()
+	}
+	return n, nil
+}
+
+// detach is called with p.mu held.
+func (r *pipeReader) detach() {
+	r.detached = true
+	r.pending = nil
+	if r.src.sink == r {
+		r.src.sink = nil
+	}
+	r.src.cond.Broadcast()
+}
+
+func (r *pipeReader) Close() error {
+	r.src.mu.Lock()
+	r.detach()
+	r.src.mu.Unlock()
+	return nil
+}
+
+func (p *pipeSource) Details() map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := map[string]any{
+		"kind":     "pipe",
+		"input":    p.label(),
+		"reading":  p.started && p.ended == nil,
+		"attached": p.sink != nil,
+	}
+	if p.ended != nil {
+		d["ended"] = p.ended.Error()
+	}
+	return d
+}
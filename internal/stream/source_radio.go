@@ -0,0 +1,238 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"renderctl/internal/servers"
+	"renderctl/logger"
+)
+
+const radioMaxBackoff = 30 * time.Second
+
+// radioSource relays an Icecast/Shoutcast stream with the ICY metadata
+// removed, reconnecting (across mirrors) whenever the upstream drops.
+type radioSource struct {
+	st *station
+
+	mu         sync.Mutex
+	title      string
+	onTitle    func(string)
+	reconnects int
+}
+
+func newRadioSource(st *station) *radioSource {
+	return &radioSource{st: st, title: st.title}
+}
+
+func (r *radioSource) Live() bool { return true }
+
+// Name is the station name (may be empty).
+func (r *radioSource) Name() string { return r.st.name }
+
+// Title is the current StreamTitle (may be empty).
+func (r *radioSource) Title() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.title
+}
+
+// OnTitle registers fn to run (in its own goroutine) on title changes.
+func (r *radioSource) OnTitle(fn func(string)) {
+	r.mu.Lock()
+	r.onTitle = fn
+	r.mu.Unlock()
+}
+
+func (r *radioSource) setTitle(t string) {
+	r.mu.Lock()
+	if t == "" || t == r.title {
+		r.mu.Unlock()
+		return
+	}
+	r.title = t
+	fn := r.onTitle
+	r.mu.Unlock()
+
+	logger.Status("Now playing: %s", t)
+	if fn != nil {
+		go fn(t)
+	}
+}
+
+func (r *radioSource) Open() (servers.StreamReadCloser, error) {
+	rr := &radioReader{src: r, done: make(chan struct{})}
+	if err := rr.connect(); err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+type radioReader struct {
+	src  *radioSource
+	done chan struct{}
+
+	mu     sync.Mutex
+	body   io.ReadCloser
+	closed bool
+
+	mirror  int // index into src.st.urls
+	metaint int // audio bytes between metadata blocks (0 = none)
+	left    int // audio bytes until the next block
+}
+
+// connect opens the first mirror that answers.
+func (rr *radioReader) connect() error {
+	urls := rr.src.st.urls
+	var lastErr error
+
+	for i := 0; i < len(urls); i++ {
+		idx := (rr.mirror + i) % len(urls)
+
+		resp, err := icyGet(urls[idx])
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		rr.mu.Lock()
+		if rr.closed {
+			rr.mu.Unlock()
+			resp.Body.Close()
+			return io.EOF
+		}
+		rr.body = resp.Body
+		rr.mirror = idx
+		rr.metaint = icyMetaint(resp)
+		rr.left = rr.metaint
+		rr.mu.Unlock()
+
+		logger.Info("Radio connected: %s (metaint=%d)", urls[idx], rr.metaint)
+		return nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("radio: no stream URLs")
+	}
+	return lastErr
+}
+
+// reconnect retries with backoff until connected or closed.
+func (rr *radioReader) reconnect() error {
+	backoff := time.Second
+	for {
+		select {
+		case <-rr.done:
+			return io.EOF
+		case <-time.After(backoff):
+		}
+
+		err := rr.connect()
+		if err == nil {
+			rr.src.mu.Lock()
+			rr.src.reconnects++
+			rr.src.mu.Unlock()
+			logger.Done("Radio stream reconnected")
+			return nil
+		}
+		if err == io.EOF {
+			return err
+		}
+
+		logger.Notify("Radio reconnect failed: %v (retry in %v)", err, backoff)
+		if backoff *= 2; backoff > radioMaxBackoff {
+			backoff = radioMaxBackoff
+		}
+	}
+}
+
+func (rr *radioReader) Read(p []byte) (int, error) {
+	for {
+		rr.mu.Lock()
+		body, closed := rr.body, rr.closed
+		rr.mu.Unlock()
+
+		if closed {
+			return 0, io.EOF
+		}
+		if body == nil {
+			if err := rr.reconnect(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		n, err := rr.readAudio(body, p)
+		if n > 0 {
+			return n, nil
+		}
+		if err == nil {
+			continue
+		}
+
+		rr.mu.Lock()
+		closed = rr.closed
+		rr.body = nil
+		rr.mu.Unlock()
+		body.Close()
+
+		if closed {
+			return 0, io.EOF
+		}
+		logger.Notify("Radio upstream dropped: %v — reconnecting", err)
+	}
+}
+
+// readAudio returns audio bytes only, consuming metadata blocks in between.
+func (rr *radioReader) readAudio(body io.Reader, p []byte) (int, error) {
+	if rr.metaint <= 0 {
+		return body.Read(p)
+	}
+
+	if rr.left == 0 {
+		meta, err := readMetaBlock(body)
+		if err != nil {
+			return 0, err
+		}
+		if t, ok := parseStreamTitle(meta); ok {
+			rr.src.setTitle(t)
+		}
+		rr.left = rr.metaint
+	}
+
+	if len(p) > rr.left {
+		p = p[:rr.left]
+	}
+	n, err := body.Read(p)
+	rr.left -= n
+	return n, err
+}
+
+func (rr *radioReader) Close() error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if rr.closed {
+		return nil
+	}
+	rr.closed = true
+	close(rr.done)
+	if rr.body != nil {
+		rr.body.Close()
+	}
+	return nil
+}
+
+func (r *radioSource) Details() map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return map[string]any{
+		"kind":       "radio",
+		"station":    r.st.name,
+		"url":        r.st.urls[0],
+		"title":      r.title,
+		"reconnects": r.reconnects,
+	}
+}
@@ -0,0 +1,54 @@
+package stream
+
+import (
+	"os/exec"
+
+	"renderctl/internal/servers"
+	"renderctl/internal/transcode"
+	"renderctl/logger"
+)
+
+type transcodeSource struct {
+	input string
+	plan  *transcode.Plan
+}
+
+// Live: one ffmpeg process is shared by all stream clients.
+func (t *transcodeSource) Live() bool { return true }
+
+func (t *transcodeSource) Open() (servers.StreamReadCloser, error) {
+	logger.Status(
+		"Starting ffmpeg (%s, profile=%s)",
+		t.plan.Route,
+		t.plan.Profile.Name,
+	)
+
+	rc, err := startPipeline(stage{
+		name: "ffmpeg",
+		cmd:  exec.Command("ffmpeg", t.plan.FFmpegArgs(t.input)...),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Done("Transcoder started")
+	return rc, nil
+}
+
+// transcodeContainer exposes the profile output as a stream container.
+type transcodeContainer struct {
+	profile transcode.Profile
+}
+
+func (t transcodeContainer) Key() string { return t.profile.Container }
+
+func (t transcodeContainer) MimeCandidates() []string { return t.profile.Mimes }
+
+func (t *transcodeSource) Details() map[string]any {
+	return map[string]any{
+		"kind":    "transcode",
+		"input":   t.input,
+		"route":   t.plan.Route.String(),
+		"profile": t.plan.Profile.Name,
+	}
+}
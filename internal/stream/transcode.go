@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"os/exec"
+	"strings"
+
+	"renderctl/internal/models"
+	"renderctl/internal/servers"
+	"renderctl/internal/transcode"
+	"renderctl/logger"
+)
+
+// planTranscode inspects the input and, when the renderer cannot play it
+// directly, returns a transcoding container/source pair.
+func planTranscode(
+	cfg *models.Config,
+	media map[string][]string,
+) (servers.StreamContainer, servers.StreamSource, bool) {
+	mode := strings.ToLower(strings.TrimSpace(cfg.Transcode))
+	if mode == "" || mode == "off" {
+		return nil, nil, false
+	}
+
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		logger.Notify("Transcoding unavailable: ffmpeg not found (serving as-is)")
+		return nil, nil, false
+	}
+
+	info, err := transcode.Inspect(cfg.LFile)
+	if err != nil {
+		logger.Notify("Media inspection failed: %v (serving as-is)", err)
+		return nil, nil, false
+	}
+
+	// Video profiles would add a black picture; serve audio as-is
+	if info.PrimaryVideo() == nil && info.PrimaryAudio() != nil {
+		logger.Info("Audio-only input — no transcoding")
+		return nil, nil, false
+	}
+
+	plan, err := transcode.Decide(info, media, mode)
+	if err != nil {
+		logger.Error("%v", err)
+		return nil, nil, false
+	}
+
+	logger.Info(
+		"Media: container=%s video=%v audio=%v",
+		info.Container,
+		plan.Verdict.Video,
+		plan.Verdict.Audio,
+	)
+
+	if plan.Route == transcode.RouteDirect {
+		logger.Notify("Renderer supports media directly — no transcoding")
+		return nil, nil, false
+	}
+
+	logger.Notify(
+		"Transcode route: %s (profile=%s)",
+		plan.Route,
+		plan.Profile.Name,
+	)
+
+	return transcodeContainer{profile: plan.Profile},
+		&transcodeSource{input: cfg.LFile, plan: plan},
+		true
+}
@@ -0,0 +1,133 @@
+package transcode
+
+import "strings"
+
+// Renderer capabilities come from the cached ConnectionManager sink:
+// mime -> []profile, where profile is the 4th protocolInfo field
+// (e.g. "DLNA.ORG_PN=AVC_TS_HD_EU_ISO;DLNA.ORG_OP=01" or "*").
+
+var containerMimes = map[string][]string{
+	"mp4":    {"video/mp4"},
+	"mov":    {"video/quicktime", "video/mp4"},
+	"mkv":    {"video/x-matroska", "video/x-mkv", "video/mkv"},
+	"webm":   {"video/webm"},
+	"ts":     {"video/mp2t", "video/vnd.dlna.mpeg-tts", "video/mpeg"},
+	"mpegps": {"video/mpeg"},
+	"avi":    {"video/avi", "video/x-msvideo", "video/divx"},
+
+	"mp3":  {"audio/mpeg", "audio/mp3"},
+	"flac": {"audio/flac", "audio/x-flac"},
+	"ogg":  {"audio/ogg", "application/ogg", "audio/x-ogg"},
+	"wav":  {"audio/wav", "audio/x-wav", "audio/wave", "audio/L16"},
+	"aac":  {"audio/aac", "audio/x-aac", "audio/vnd.dlna.adts"},
+	"m4a":  {"audio/mp4", "audio/x-m4a"},
+}
+
+// DLNA.ORG_PN tokens that imply a codec is decodable
+var videoTokens = map[string][]string{
+	"h264":       {"AVC"},
+	"hevc":       {"HEVC"},
+	"mpeg2video": {"MPEG_TS", "MPEG_PS", "MPEG2"},
+	"mpeg1video": {"MPEG1"},
+	"mpeg4":      {"MPEG4_P2"},
+	"vc1":        {"VC1"},
+	"vp9":        {"VP9"},
+}
+
+var audioTokens = map[string][]string{
+	"aac":       {"AAC", "HEAAC"},
+	"ac3":       {"AC3"},
+	"eac3":      {"EAC3", "DDPLUS"},
+	"mp3":       {"MP3"},
+	"mp2":       {"MP2", "MPEG_TS", "MPEG_PS"},
+	"dts":       {"DTS"},
+	"pcm_s16le": {"LPCM"},
+	"flac":      {"FLAC"},
+	"wmav2":     {"WMA"},
+}
+
+// When the renderer lists mimes but no DLNA profile names,
+// assume only the mainstream codecs every DLNA TV decodes.
+var (
+	baselineVideo = map[string]bool{"h264": true, "mpeg2video": true}
+	baselineAudio = map[string]bool{"aac": true, "mp3": true, "ac3": true, "mp2": true}
+)
+
+func hasProfileNames(media map[string][]string) bool {
+	for _, profiles := range media {
+		for _, p := range profiles {
+			if strings.Contains(strings.ToUpper(p), "DLNA.ORG_PN=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchToken(media map[string][]string, tokens []string) bool {
+	for _, profiles := range media {
+		for _, p := range profiles {
+			up := strings.ToUpper(p)
+			for _, t := range tokens {
+				if strings.Contains(up, t) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// SupportsContainer reports whether the renderer sink lists a mime for the container.
+func SupportsContainer(container string, media map[string][]string) bool {
+	for _, m := range containerMimes[container] {
+		if _, ok := media[m]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsVideo reports whether the renderer is expected to decode the codec.
+func SupportsVideo(codec string, media map[string][]string) bool {
+	if !hasProfileNames(media) {
+		return baselineVideo[codec]
+	}
+	return matchToken(media, videoTokens[codec])
+}
+
+// SupportsAudio reports whether the renderer is expected to decode the codec.
+func SupportsAudio(codec string, media map[string][]string) bool {
+	if !hasProfileNames(media) {
+		return baselineAudio[codec]
+	}
+	return matchToken(media, audioTokens[codec])
+}
+
+// Verdict is the compatibility of one input against one renderer.
+type Verdict struct {
+	Known     bool // false when the renderer reported no media at all
+	Container bool
+	Video     bool
+	Audio     bool
+}
+
+func Check(info *Info, media map[string][]string) Verdict {
+	if len(media) == 0 {
+		return Verdict{Container: true, Video: true, Audio: true}
+	}
+
+	v := Verdict{
+		Known:     true,
+		Container: SupportsContainer(info.Container, media),
+		Video:     true,
+		Audio:     true,
+	}
+	if t := info.PrimaryVideo(); t != nil {
+		v.Video = SupportsVideo(t.Codec, media)
+	}
+	if t := info.PrimaryAudio(); t != nil {
+		v.Audio = SupportsAudio(t.Codec, media)
+	}
+	return v
+}
@@ -0,0 +1,238 @@
+package transcode
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"renderctl/internal/mediainfo"
+)
+
+// Info is what we know about a media input.
+type Info struct {
+	Input     string
+	Container string // normalized: mp4, mkv, ts, avi, mov, webm, ...
+	Duration  time.Duration
+	Bitrate   int64 // bits per second, 0 if unknown
+
+	Video     []Track
+	Audio     []Track
+	Subtitles []Track
+}
+
+type Track struct {
+	Index    int
+	Codec    string // ffprobe codec_name (h264, hevc, aac, dts, ...)
+	Profile  string
+	Language string
+
+	// video
+	Width  int
+	Height int
+
+	// audio
+	Channels      int
+	ChannelLayout string
+
+	Bitrate int64
+}
+
+// PrimaryVideo returns the first video track, or nil.
+func (i *Info) PrimaryVideo() *Track {
+	if len(i.Video) == 0 {
+		return nil
+	}
+	return &i.Video[0]
+}
+
+// PrimaryAudio returns the first audio track, or nil.
+func (i *Info) PrimaryAudio() *Track {
+	if len(i.Audio) == 0 {
+		return nil
+	}
+	return &i.Audio[0]
+}
+
+var ErrNoProbe = errors.New("ffprobe not found in PATH")
+
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		Index         int    `json:"index"`
+		CodecType     string `json:"codec_type"`
+		CodecName     string `json:"codec_name"`
+		Profile       string `json:"profile"`
+		Width         int    `json:"width"`
+		Height        int    `json:"height"`
+		Channels      int    `json:"channels"`
+		ChannelLayout string `json:"channel_layout"`
+		BitRate       string `json:"bit_rate"`
+		Tags          struct {
+			Language string `json:"language"`
+		} `json:"tags"`
+	} `json:"streams"`
+}
+
+// Inspect runs ffprobe on a local file or URL.
+// Without ffprobe, local files fall back to the built-in header parser.
+func Inspect(input string) (*Info, error) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		if isURL(input) {
+			return nil, ErrNoProbe
+		}
+		return inspectNative(input)
+	}
+
+	out, err := exec.Command(
+		"ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		input,
+	).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && len(ee.Stderr) > 0 {
+			return nil, errors.New(strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, err
+	}
+
+	var raw ffprobeOutput
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		Input:     input,
+		Container: normalizeContainer(raw.Format.FormatName, input),
+		Duration:  parseSeconds(raw.Format.Duration),
+		Bitrate:   parseInt(raw.Format.BitRate),
+	}
+
+	for _, s := range raw.Streams {
+		t := Track{
+			Index:         s.Index,
+			Codec:         strings.ToLower(s.CodecName),
+			Profile:       s.Profile,
+			Language:      s.Tags.Language,
+			Width:         s.Width,
+			Height:        s.Height,
+			Channels:      s.Channels,
+			ChannelLayout: s.ChannelLayout,
+			Bitrate:       parseInt(s.BitRate),
+		}
+
+		switch s.CodecType {
+		case "video":
+			// cover art shows up as a single-frame video stream
+			if t.Codec == "mjpeg" || t.Codec == "png" {
+				continue
+			}
+			info.Video = append(info.Video, t)
+		case "audio":
+			info.Audio = append(info.Audio, t)
+		case "subtitle":
+			info.Subtitles = append(info.Subtitles, t)
+		}
+	}
+
+	return info, nil
+}
+
+func inspectNative(input string) (*Info, error) {
+	mi, err := mediainfo.ParseFile(input)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		Input:     input,
+		Container: mi.Container,
+		Duration:  mi.Duration,
+	}
+	if st, err := os.Stat(input); err == nil && mi.Duration > 0 {
+		info.Bitrate = int64(float64(st.Size()*8) / mi.Duration.Seconds())
+	}
+
+	for _, t := range mi.Tracks {
+		tr := Track{
+			Index:         t.ID,
+			Codec:         t.Codec,
+			Language:      t.Language,
+			Width:         t.Width,
+			Height:        t.Height,
+			Channels:      t.Channels,
+			ChannelLayout: t.ChannelLayout(),
+		}
+		switch t.Kind {
+		case mediainfo.KindVideo:
+			info.Video = append(info.Video, tr)
+		case mediainfo.KindAudio:
+			info.Audio = append(info.Audio, tr)
+		case mediainfo.KindSubtitle:
+			info.Subtitles = append(info.Subtitles, tr)
+		}
+	}
+
+	return info, nil
+}
+
+func isURL(s string) bool {
+	return strings.Contains(s, "://")
+}
+
+func normalizeContainer(formatName, input string) string {
+	f := strings.ToLower(formatName)
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(input)), ".")
+
+	switch {
+	case strings.Contains(f, "matroska"):
+		if ext == "webm" {
+			return "webm"
+		}
+		return "mkv"
+	case strings.Contains(f, "mp4"), strings.Contains(f, "mov"):
+		if ext == "mov" {
+			return "mov"
+		}
+		return "mp4"
+	case f == "mpegts":
+		return "ts"
+	case f == "mpeg":
+		return "mpegps"
+	case f == "avi":
+		return "avi"
+	}
+
+	if f == "" {
+		return ext
+	}
+	return strings.Split(f, ",")[0]
+}
+
+func parseSeconds(s string) time.Duration {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(v * float64(time.Second))
+}
+
+func parseInt(s string) int64 {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+package transcode
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Profile is a named ffmpeg output target.
+type Profile struct {
+	Name      string
+	Container string   // stream container key served to the TV ("ts")
+	Format    string   // ffmpeg -f
+	Mimes     []string // candidates, best first
+
+	VideoCodec string // codec name as reported by ffprobe
+	VideoArgs  []string
+	AudioCodec string
+	AudioArgs  []string
+}
+
+var profiles = map[string]Profile{
+	"h264-aac-ts": {
+		Name:      "h264-aac-ts",
+		Container: "ts",
+		Format:    "mpegts",
+		Mimes:     []string{"video/mpeg", "video/mp2t", "video/vnd.dlna.mpeg-tts"},
+
+		VideoCodec: "h264",
+		VideoArgs: []string{
+			"-c:v", "libx264",
+			"-preset", "veryfast",
+			"-profile:v", "high",
+			"-level", "4.1",
+			"-pix_fmt", "yuv420p",
+			"-g", "50",
+		},
+		AudioCodec: "aac",
+		AudioArgs:  []string{"-c:a", "aac", "-b:a", "192k", "-ac", "2"},
+	},
+	"mpeg2-ts": {
+		Name:      "mpeg2-ts",
+		Container: "ts",
+		Format:    "mpegts",
+		Mimes:     []string{"video/mpeg", "video/mp2t", "video/vnd.dlna.mpeg-tts"},
+
+		VideoCodec: "mpeg2video",
+		VideoArgs: []string{
+			"-c:v", "mpeg2video",
+			"-q:v", "3",
+			"-pix_fmt", "yuv420p",
+			"-g", "15",
+		},
+		AudioCodec: "ac3",
+		AudioArgs:  []string{"-c:a", "ac3", "-b:a", "192k", "-ac", "2"},
+	},
+}
+
+// autoOrder is tried when no profile is forced
+var autoOrder = []string{"h264-aac-ts", "mpeg2-ts"}
+
+func GetProfile(name string) (Profile, error) {
+	p, ok := profiles[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Profile{}, errors.New("unknown transcode profile: " + name +
+			" (available: " + strings.Join(ProfileNames(), ", ") + ")")
+	}
+	return p, nil
+}
+
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pickProfile returns the first auto profile the renderer can decode.
+func pickProfile(media map[string][]string) Profile {
+	for _, n := range autoOrder {
+		p := profiles[n]
+		if SupportsContainer(p.Container, media) &&
+			SupportsVideo(p.VideoCodec, media) &&
+			SupportsAudio(p.AudioCodec, media) {
+			return p
+		}
+	}
+	return profiles[autoOrder[0]]
+}
@@ -0,0 +1,124 @@
+package transcode
+
+import (
+	"fmt"
+	"time"
+)
+
+func mark(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "unsupported"
+}
+
+func orNA(v string) string {
+	if v == "" {
+		return "n/a"
+	}
+	return v
+}
+
+func formatBitrate(bps int64) string {
+	if bps <= 0 {
+		return "n/a"
+	}
+	if bps >= 1_000_000 {
+		return fmt.Sprintf("%.1f Mb/s", float64(bps)/1_000_000)
+	}
+	return fmt.Sprintf("%d kb/s", bps/1000)
+}
+
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "n/a (live or unknown)"
+	}
+	return d.Truncate(time.Second).String()
+}
+
+// PrintReport prints media details and, when renderer media is known,
+// a per-track compatibility verdict.
+func PrintReport(info *Info, media map[string][]string, renderer string) {
+	known := len(media) > 0
+
+	fmt.Printf("\n%s\n", info.Input)
+
+	// ---- CONTAINER ----
+	if known {
+		fmt.Printf("├── container: %s [%s]\n", info.Container, mark(SupportsContainer(info.Container, media)))
+	} else {
+		fmt.Printf("├── container: %s\n", info.Container)
+	}
+	fmt.Printf("├── duration : %s\n", formatDuration(info.Duration))
+	fmt.Printf("├── bitrate  : %s\n", formatBitrate(info.Bitrate))
+
+	// ---- VIDEO ----
+	fmt.Printf("├── video: %d\n", len(info.Video))
+	for i, t := range info.Video {
+		p := "│   ├──"
+		if i == len(info.Video)-1 {
+			p = "│   └──"
+		}
+		line := fmt.Sprintf("%s #%d %s (%s) %dx%d", p, t.Index, t.Codec, orNA(t.Profile), t.Width, t.Height)
+		if known {
+			line += " [" + mark(SupportsVideo(t.Codec, media)) + "]"
+		}
+		fmt.Println(line)
+	}
+
+	// ---- AUDIO ----
+	fmt.Printf("├── audio: %d\n", len(info.Audio))
+	for i, t := range info.Audio {
+		p := "│   ├──"
+		if i == len(info.Audio)-1 {
+			p = "│   └──"
+		}
+		layout := t.ChannelLayout
+		if layout == "" && t.Channels > 0 {
+			layout = fmt.Sprintf("%dch", t.Channels)
+		}
+		line := fmt.Sprintf("%s #%d %s %s lang=%s", p, t.Index, t.Codec, orNA(layout), orNA(t.Language))
+		if known {
+			line += " [" + mark(SupportsAudio(t.Codec, media)) + "]"
+		}
+		fmt.Println(line)
+	}
+
+	// ---- SUBTITLES ----
+	fmt.Printf("└── subtitles: %d\n", len(info.Subtitles))
+	for i, t := range info.Subtitles {
+		p := "    ├──"
+		if i == len(info.Subtitles)-1 {
+			p = "    └──"
+		}
+		fmt.Printf("%s #%d %s lang=%s (not sent to renderer)\n", p, t.Index, t.Codec, orNA(t.Language))
+	}
+
+	fmt.Println()
+
+	// ---- VERDICT ----
+	if !known {
+		if renderer != "" {
+			fmt.Printf("Renderer %s: no cached media profiles (run --show-media after a scan)\n\n", renderer)
+		} else {
+			fmt.Println("No renderer selected (use --select-cache or -Tip) — no verdict")
+			fmt.Println()
+		}
+		return
+	}
+
+	plan, _ := Decide(info, media, "auto")
+
+	fmt.Printf("Renderer %s: ", renderer)
+	switch plan.Route {
+	case RouteDirect:
+		fmt.Println("plays directly")
+	case RouteRemux:
+		fmt.Printf("needs remux (%s)\n", plan.Profile.Name)
+	case RouteAudio:
+		fmt.Printf("needs audio transcode (%s)\n", plan.Profile.Name)
+	default:
+		fmt.Printf("needs full transcode (%s)\n", plan.Profile.Name)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,111 @@
+package transcode
+
+import "strings"
+
+type Route int
+
+const (
+	RouteDirect Route = iota // serve as-is
+	RouteRemux               // copy streams into a new container
+	RouteAudio               // copy video, transcode audio
+	RouteVideo               // full transcode
+)
+
+func (r Route) String() string {
+	switch r {
+	case RouteDirect:
+		return "direct"
+	case RouteRemux:
+		return "remux"
+	case RouteAudio:
+		return "audio transcode"
+	case RouteVideo:
+		return "video transcode"
+	}
+	return "unknown"
+}
+
+// Plan is the decided transformation for one input.
+type Plan struct {
+	Route   Route
+	Profile Profile
+	Verdict Verdict
+}
+
+// Decide picks the cheapest working route.
+//
+// mode is "auto" (renderer-driven) or a profile name (forced target).
+func Decide(info *Info, media map[string][]string, mode string) (*Plan, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+
+	if mode == "" || mode == "auto" {
+		v := Check(info, media)
+		plan := &Plan{
+			Profile: pickProfile(media),
+			Verdict: v,
+		}
+
+		switch {
+		case v.Container && v.Video && v.Audio:
+			plan.Route = RouteDirect
+		case v.Video && v.Audio:
+			plan.Route = RouteRemux
+		case v.Video:
+			plan.Route = RouteAudio
+		default:
+			plan.Route = RouteVideo
+		}
+		return plan, nil
+	}
+
+	p, err := GetProfile(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// forced profile: copy only what already matches the target
+	v := Verdict{Known: true, Video: true, Audio: true}
+	if t := info.PrimaryVideo(); t != nil {
+		v.Video = t.Codec == p.VideoCodec
+	}
+	if t := info.PrimaryAudio(); t != nil {
+		v.Audio = t.Codec == p.AudioCodec
+	}
+
+	plan := &Plan{Profile: p, Verdict: v}
+	switch {
+	case v.Video && v.Audio:
+		plan.Route = RouteRemux
+	case v.Video:
+		plan.Route = RouteAudio
+	default:
+		plan.Route = RouteVideo
+	}
+	return plan, nil
+}
+
+// FFmpegArgs builds the ffmpeg argv (without "ffmpeg") writing to stdout.
+func (p *Plan) FFmpegArgs(input string) []string {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-re",
+		"-i", input,
+		"-map", "0:v:0?",
+		"-map", "0:a:0?",
+		"-sn",
+	}
+
+	switch p.Route {
+	case RouteRemux:
+		args = append(args, "-c:v", "copy", "-c:a", "copy")
+	case RouteAudio:
+		args = append(args, "-c:v", "copy")
+		args = append(args, p.Profile.AudioArgs...)
+	default:
+		args = append(args, p.Profile.VideoArgs...)
+		args = append(args, p.Profile.AudioArgs...)
+	}
+
+	return append(args, "-f", p.Profile.Format, "pipe:1")
+}
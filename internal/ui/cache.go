@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/url"
 	"renderctl/internal/cache"
-	"sort"
 )
 
 func openCachePopup(ctx *uiContext, index int, state *uiState) {
@@ -52,47 +51,7 @@ func applyCachedDevice(ctx *uiContext, ip string, dev cache.Device) {
 }
 
 func cacheSelect(index int) (string, cache.Device, bool) {
-	store, err := cache.Load()
-	if err != nil {
-		return "", cache.Device{}, false
-	}
-
-	keys := make([]string, 0, len(store))
-	for ip := range store {
-		keys = append(keys, ip)
-	}
-	sort.Strings(keys)
-
-	if index < 0 || index >= len(keys) {
-		return "", cache.Device{}, false
-	}
-
-	ip := keys[index]
-	cd := store[ip]
-
-	if cd == nil || len(cd.Endpoints) == 0 {
-		return "", cache.Device{}, false
-	}
-
-	// pick primary endpoint deterministically
-	var urls []string
-	for u, ep := range cd.Endpoints {
-		if len(ep.Actions) > 0 {
-			urls = append(urls, u)
-		}
-	}
-	sort.Strings(urls)
-
-	ep := cd.Endpoints[urls[0]]
-
-	return ip, cache.Device{
-		Vendor:     cd.Vendor,
-		ControlURL: ep.ControlURL,
-		ConnMgrURL: ep.ConnMgrURL,
-		Identity:   cd.Identity,
-		Actions:    ep.Actions,
-		Media:      ep.Media,
-	}, true
+	return cache.SelectIndex(index)
 }
 
 func clearCachedSelection(ctx *uiContext) {
@@ -34,9 +34,9 @@ type uiContext struct {
 	popup *popupState
 }
 
-// reset working copy to defaults
+// reset working copy to defaults (config file included)
 func (u *uiContext) resetWorking() {
-	u.working = models.DefaultConfig
+	u.working = models.ResolvedConfig
 	u.ssdpTimeoutSec = int(u.working.SSDPTimeout / time.Second)
 }
 
@@ -3,7 +3,6 @@ package utils
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"renderctl/internal/models"
 	"renderctl/logger"
@@ -49,13 +48,15 @@ func ValidateFile(path string) error {
 	return nil
 }
 
-func MediaURL(cfg *models.Config) string {
-	file := filepath.Base(cfg.LFile)
-	return "http://" + cfg.LIP + ":" + cfg.ServePort + "/" + file
-}
+// StdinIsMedia is set when the media itself arrives on stdin (-Lf -);
+// prompts must not read from it.
+var StdinIsMedia bool
 
 func LocalIP(ip string) string {
 	if ip == "" {
+		if StdinIsMedia {
+			logger.Error("Missing -Lip (cannot prompt while reading media from stdin)")
+		}
 		var newip string
 		fmt.Print("Enter local IP: ")
 		fmt.Scan(&newip)
@@ -69,6 +70,10 @@ func LocalIP(ip string) string {
 }
 
 func Confirm(msg string) bool {
+	if StdinIsMedia {
+		logger.Notify("%s → yes (stdin is the media input)", msg)
+		return true
+	}
 	var ans string
 	logger.Prompt("%s (y/n): ", msg)
 	fmt.Scanln(&ans)
@@ -0,0 +1,127 @@
+// Package renderctl is the embeddable renderctl API: find DLNA/UPnP
+// renderers, serve local files to them and drive playback. The renderctl
+// CLI and daemon are built on it.
+//
+// Nothing here exits the process or reads the CLI configuration; every
+// value is an independent instance, so one program may run several media
+// servers and control several renderers at once.
+package renderctl
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"renderctl/internal/ssdp"
+)
+
+const defaultDiscoverTimeout = 5 * time.Second
+
+// Device is a renderer found on the network.
+type Device struct {
+	UDN          string `json:"udn,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Vendor       string `json:"vendor"` // samsung, lg, sony, philips or generic
+	IP           string `json:"ip"`
+
+	ControlURL string `json:"control_url"`            // AVTransport
+	ConnMgrURL string `json:"conn_mgr_url,omitempty"` // ConnectionManager
+	SCPDURL    string `json:"scpd_url,omitempty"`     // AVTransport service description
+	Location   string `json:"location"`               // device description
+}
+
+// Discoverer finds AVTransport renderers over SSDP.
+type Discoverer struct {
+	// Timeout bounds each SSDP phase (default 5s).
+	Timeout time.Duration
+	// LocalIP also listens for NOTIFY announcements on that interface;
+	// empty runs the active M-SEARCH only.
+	LocalIP string
+	// Logf receives progress messages; nil keeps discovery silent.
+	Logf func(format string, args ...any)
+}
+
+// Discover returns every renderer that answered, once per UDN.
+// It fails only when no search could be run at all.
+func (d *Discoverer) Discover() ([]Device, error) {
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = defaultDiscoverTimeout
+	}
+	log := ssdp.Log{Notify: d.Logf, Info: d.Logf, Done: d.Logf}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		found []ssdp.SSDPDevice
+		errs  []error
+	)
+	collect := func(devs []ssdp.SSDPDevice, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		found = append(found, devs...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if d.LocalIP != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collect(log.ListenNotify(timeout, d.LocalIP))
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		collect(log.Discover(timeout))
+	}()
+	wg.Wait()
+
+	if len(found) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	seen := map[string]bool{}
+	var out []Device
+	for _, s := range found {
+		if s.Location == "" || seen[s.Location] || !ssdp.LooksLikeTV(s) {
+			continue
+		}
+		seen[s.Location] = true
+		if strings.Contains(s.Location, "nservice") {
+			continue
+		}
+
+		tv, err := log.FetchAndDetect(s.Location)
+		if err != nil || tv.ControlURL == "" {
+			continue
+		}
+		key := tv.UDN
+		if key == "" {
+			key = tv.ControlURL
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		out = append(out, Device{
+			UDN:          tv.UDN,
+			Name:         tv.FriendlyName,
+			Manufacturer: tv.Manufacturer,
+			Model:        tv.ModelName,
+			Vendor:       tv.Vendor,
+			IP:           tv.IP,
+			ControlURL:   tv.ControlURL,
+			ConnMgrURL:   tv.ConnectionManagerCtrl,
+			SCPDURL:      tv.AVTransportSCPD,
+			Location:     s.Location,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package renderctl
+
+import (
+	"time"
+
+	"renderctl/internal/avtransport"
+)
+
+// UPnPError is a SOAP fault returned by a renderer (e.g. 714, illegal
+// MIME type). Use errors.As to inspect the code.
+type UPnPError = avtransport.UPnPError
+
+// Renderer drives one AVTransport renderer. It keeps no connection and
+// no state; any number may be used concurrently.
+type Renderer struct {
+	ControlURL string
+	ConnMgrURL string // optional, lets MediaServer.Media pick a mime the TV lists
+	Vendor     string // DIDL-Lite flavour ("" = generic)
+
+	// Logf receives SOAP traces; nil keeps the calls silent.
+	Logf func(format string, args ...any)
+}
+
+func NewRenderer(d Device) *Renderer {
+	return &Renderer{
+		ControlURL: d.ControlURL,
+		ConnMgrURL: d.ConnMgrURL,
+		Vendor:     d.Vendor,
+	}
+}
+
+// Media is what to play. Only URL is required; the rest ends up in the
+// DIDL-Lite metadata.
+type Media struct {
+	URL         string        `json:"url"`
+	Mime        string        `json:"mime,omitempty"`
+	Title       string        `json:"title,omitempty"`
+	Artist      string        `json:"artist,omitempty"`
+	Album       string        `json:"album,omitempty"`
+	Duration    time.Duration `json:"duration_ns,omitempty"`
+	Resolution  string        `json:"resolution,omitempty"` // "WxH"
+	AlbumArtURL string        `json:"album_art_url,omitempty"`
+	Class       string        `json:"class,omitempty"` // upnp:class override (e.g. audioBroadcast)
+}
+
+// Status is the renderer's transport state and position.
+type Status struct {
+	State     string        `json:"state"`                      // PLAYING, PAUSED_PLAYBACK, STOPPED, ...
+	Transport string        `json:"transport_status,omitempty"` // OK or ERROR_OCCURRED
+	Track     string        `json:"track_uri,omitempty"`
+	Position  time.Duration `json:"position_ns"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Play loads m and starts playback (Stop, SetAVTransportURI, Play).
+func (r *Renderer) Play(m Media) error {
+	t := avtransport.Target{
+		ControlURL:  r.ControlURL,
+		MediaURL:    m.URL,
+		Mime:        m.Mime,
+		Title:       m.Title,
+		Artist:      m.Artist,
+		Album:       m.Album,
+		Duration:    m.Duration,
+		Resolution:  m.Resolution,
+		AlbumArtURL: m.AlbumArtURL,
+		Class:       m.Class,
+	}
+	return r.soap().Cast(t, avtransport.MetadataForVendor(r.Vendor, t))
+}
+
+func (r *Renderer) Pause() error {
+	return r.soap().Pause(r.ControlURL)
+}
+
+func (r *Renderer) Resume() error {
+	return r.soap().Play(r.ControlURL)
+}
+
+func (r *Renderer) Stop() error {
+	return r.soap().Stop(r.ControlURL)
+}
+
+// Seek jumps to an absolute position in the current track.
+func (r *Renderer) Seek(pos time.Duration) error {
+	return r.soap().Seek(r.ControlURL, pos)
+}
+
+func (r *Renderer) Status() (Status, error) {
+	pos, err := r.soap().PositionInfo(r.ControlURL)
+	if err != nil {
+		return Status{}, err
+	}
+	state, transport := r.soap().TransportInfo(r.ControlURL)
+	return Status{
+		State:     state,
+		Transport: transport,
+		Track:     pos.Track,
+		Position:  pos.Elapsed,
+		Duration:  pos.Duration,
+	}, nil
+}
+
+// Volume reads the Master volume (0-100) through RenderingControl.
+func (r *Renderer) Volume() (int, error) {
+	return r.soap().Volume(r.ControlURL)
+}
+
+func (r *Renderer) SetVolume(level int) error {
+	return r.soap().SetVolume(r.ControlURL, level)
+}
+
+// Protocols returns the renderer's sink protocol info keyed by mime,
+// nil when it has no ConnectionManager.
+func (r *Renderer) Protocols() (map[string][]string, error) {
+	if r.ConnMgrURL == "" {
+		return nil, nil
+	}
+	return avtransport.FetchMediaProtocols(r.ConnMgrURL)
+}
+
+func (r *Renderer) soap() avtransport.Log {
+	return avtransport.Log{Info: r.Logf}
+}
+
+// ParsePosition accepts "90s", "1m30s" or "0:01:30".
+func ParsePosition(v string) (time.Duration, error) {
+	return avtransport.ParsePosition(v)
+}
@@ -0,0 +1,38 @@
+package renderctl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// SOAP traces go to the caller's Logf, not to the CLI logger.
+func TestRendererLogf(t *testing.T) {
+	var actions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actions = append(actions, r.Header.Get("SOAPAction"))
+	}))
+	defer srv.Close()
+
+	var logged []string
+	r := &Renderer{
+		ControlURL: srv.URL + "/upnp/control/AVTransport1",
+		Logf:       func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) },
+	}
+	if err := r.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetVolume(20); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Pause: 200", "SetVolume: 200"}
+	if !slices.Equal(logged, want) {
+		t.Fatalf("logged %q, want %q", logged, want)
+	}
+	if len(actions) != 2 || actions[1] != `"urn:schemas-upnp-org:service:RenderingControl:1#SetVolume"` {
+		t.Fatalf("renderer saw %q", actions)
+	}
+}
@@ -0,0 +1,167 @@
+package renderctl
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"renderctl/internal/avtransport"
+	"renderctl/internal/servers"
+	"renderctl/internal/utils"
+)
+
+// MediaServerOptions configure a MediaServer.
+type MediaServerOptions struct {
+	// Addr is the listen address (default ":0", any free port).
+	Addr string
+	// Host is the address renderers reach this machine on; it goes into
+	// every published URL. Empty picks the interface of the default route.
+	Host string
+	// Allow lists extra client IPs/CIDRs. Renderers handed to Media or
+	// Allow are added as they come; this machine is always allowed.
+	Allow []string
+	// Logf receives media inspection messages; nil keeps them silent.
+	Logf func(format string, args ...any)
+}
+
+// MediaServer serves published files over HTTP to allowed clients only.
+// Files are reachable under unguessable URLs, nothing else on disk is.
+type MediaServer struct {
+	base   string
+	media  *servers.MediaRegistry
+	access *servers.Access
+	mux    *http.ServeMux
+	srv    *http.Server
+	logf   func(format string, args ...any)
+}
+
+// NewMediaServer starts listening right away; Close stops it.
+func NewMediaServer(opts MediaServerOptions) (*MediaServer, error) {
+	host := opts.Host
+	if host == "" {
+		ip, err := outboundIP()
+		if err != nil {
+			return nil, err
+		}
+		host = ip
+	}
+
+	nets, err := utils.ParseAllowList(append([]string{host}, opts.Allow...))
+	if err != nil {
+		return nil, err
+	}
+
+	addr := opts.Addr
+	if addr == "" {
+		addr = ":0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	s := &MediaServer{
+		base:   "http://" + net.JoinHostPort(host, port),
+		media:  servers.NewMediaRegistry(),
+		access: servers.NewAccess(nets),
+		mux:    http.NewServeMux(),
+		logf:   opts.Logf,
+	}
+
+	s.media.Register(s.mux)
+	s.srv = &http.Server{
+		Handler:           s.access.Guard(s.mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() { _ = s.srv.Serve(ln) }()
+
+	return s, nil
+}
+
+// URL is the server base URL, e.g. http://192.168.1.10:41234.
+func (s *MediaServer) URL() string {
+	return s.base
+}
+
+// Publish makes a file available and returns the URL to hand a renderer.
+// Publishing the same file again returns the same URL.
+func (s *MediaServer) Publish(path string) (string, error) {
+	p, err := s.media.Publish(path)
+	if err != nil {
+		return "", err
+	}
+	return s.base + p, nil
+}
+
+// Handle serves extra endpoints next to the media, behind the same
+// client allowlist. pattern must not overlap /media/.
+func (s *MediaServer) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// Allow lets a client fetch media. host is an IP, a host name or a
+// renderer control URL.
+func (s *MediaServer) Allow(host string) {
+	s.access.AllowRenderer(host)
+}
+
+// Media publishes path for r: it allows the renderer, reads the file
+// headers and tags for DIDL-Lite, picks the mime spelling r lists and
+// serves embedded cover art. r may be nil.
+func (s *MediaServer) Media(path string, r *Renderer) (Media, error) {
+	u, err := s.Publish(path)
+	if err != nil {
+		return Media{}, err
+	}
+
+	t := avtransport.Target{MediaURL: u}
+	var protocols map[string][]string
+	if r != nil {
+		s.Allow(r.ControlURL)
+		protocols, _ = r.Protocols()
+	}
+
+	tags := avtransport.Log{Info: s.logf}.DescribeFile(&t, path, protocols)
+	if t.Mime != "" {
+		s.media.SetContentType(u, t.Mime)
+	}
+	if tags != nil && len(tags.Picture) > 0 {
+		name := "cover.jpg"
+		if tags.PictureMime == "image/png" {
+			name = "cover.png"
+		}
+		abs, _ := filepath.Abs(path)
+		if p, err := s.media.PublishData("cover:"+abs, name, tags.Picture, tags.PictureMime); err == nil {
+			t.AlbumArtURL = s.base + p
+		}
+	}
+
+	return Media{
+		URL:         t.MediaURL,
+		Mime:        t.Mime,
+		Title:       t.Title,
+		Artist:      t.Artist,
+		Album:       t.Album,
+		Duration:    t.Duration,
+		Resolution:  t.Resolution,
+		AlbumArtURL: t.AlbumArtURL,
+	}, nil
+}
+
+// Close stops the server and drops open connections.
+func (s *MediaServer) Close() error {
+	return s.srv.Close()
+}
+
+// outboundIP is the local address of the default route (no packet is sent).
+func outboundIP() (string, error) {
+	conn, err := net.Dial("udp4", "239.255.255.250:1900")
+	if err != nil {
+		return "", errors.New("cannot pick a local address, set MediaServerOptions.Host: " + err.Error())
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}